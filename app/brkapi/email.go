@@ -0,0 +1,33 @@
+package brkapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/xgfone/ship/v5"
+)
+
+func Email(svc service.EmailService) Router {
+	return &emailREST{
+		svc: svc,
+	}
+}
+
+type emailREST struct {
+	svc service.EmailService
+}
+
+func (rest *emailREST) Route(r *ship.RouteGroupBuilder) {
+	r.Route("/email/server").GET(rest.Enabled)
+}
+
+// Enabled broker 启动和收到 accord.FPNotifierReset 时拉取启用的邮件服务器配置
+func (rest *emailREST) Enabled(c *ship.Context) error {
+	ctx := c.Request().Context()
+	dat, err := rest.svc.Enabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dat)
+}
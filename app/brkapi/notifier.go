@@ -0,0 +1,33 @@
+package brkapi
+
+import (
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/xgfone/ship/v5"
+)
+
+func Notifier(svc service.NotifierService) Router {
+	return &notifierREST{
+		svc: svc,
+	}
+}
+
+type notifierREST struct {
+	svc service.NotifierService
+}
+
+func (rest *notifierREST) Route(r *ship.RouteGroupBuilder) {
+	r.Route("/notifier/deliveries").POST(rest.Report)
+}
+
+// Report broker 发送告警通知后上报发送结果
+func (rest *notifierREST) Report(c *ship.Context) error {
+	var req param.NotifierDeliveryReport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Report(ctx, brokerID(c), &req)
+}
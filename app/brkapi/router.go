@@ -0,0 +1,19 @@
+package brkapi
+
+import (
+	"github.com/vela-ssoc/vela-manager/bridge/linkhub"
+	"github.com/xgfone/ship/v5"
+)
+
+// Router broker 通过连接通道调用 manager 的路由绑定接口
+type Router interface {
+	Route(r *ship.RouteGroupBuilder)
+}
+
+// brokerID 获取发起请求的 broker ID
+func brokerID(c *ship.Context) int64 {
+	if conn, ok := linkhub.Ctx(c.Request().Context()).(interface{ ID() int64 }); ok {
+		return conn.ID()
+	}
+	return 0
+}
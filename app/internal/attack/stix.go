@@ -0,0 +1,136 @@
+package attack
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+var ErrBundle = errors.New("不是有效的 ATT&CK STIX bundle")
+
+// Tactic ATT&CK 战术，如 TA0006 Credential Access
+type Tactic struct {
+	ID          string // 战术编号，如 TA0006
+	Name        string // 战术名称
+	ShortName   string // 短名称，技术通过短名称关联战术，如 credential-access
+	Description string
+	URL         string
+}
+
+// Technique ATT&CK 技术或子技术，如 T1110 T1110.001
+type Technique struct {
+	ID           string   // 技术编号
+	Name         string   // 技术名称
+	Tactics      []string // 所属战术的短名称
+	Platforms    []string // 适用平台
+	Description  string
+	URL          string
+	Subtechnique bool // 是否是子技术
+	Deprecated   bool // 已废弃或已撤销
+}
+
+// Bundle 从 STIX bundle 中解析出的战术与技术
+type Bundle struct {
+	Tactics    []*Tactic
+	Techniques []*Technique
+}
+
+type stixReference struct {
+	SourceName string `json:"source_name"`
+	ExternalID string `json:"external_id"`
+	URL        string `json:"url"`
+}
+
+type stixObject struct {
+	Type               string          `json:"type"`
+	Name               string          `json:"name"`
+	Description        string          `json:"description"`
+	Revoked            bool            `json:"revoked"`
+	Deprecated         bool            `json:"x_mitre_deprecated"`
+	Subtechnique       bool            `json:"x_mitre_is_subtechnique"`
+	ShortName          string          `json:"x_mitre_shortname"`
+	Platforms          []string        `json:"x_mitre_platforms"`
+	ExternalReferences []stixReference `json:"external_references"`
+	KillChainPhases    []struct {
+		KillChainName string `json:"kill_chain_name"`
+		PhaseName     string `json:"phase_name"`
+	} `json:"kill_chain_phases"`
+}
+
+// reference 返回 mitre-attack 的外部引用，即 ATT&CK 编号与链接
+func (so stixObject) reference() (string, string) {
+	for _, ref := range so.ExternalReferences {
+		if ref.SourceName == "mitre-attack" {
+			return ref.ExternalID, ref.URL
+		}
+	}
+	return "", ""
+}
+
+// Parse 解析 MITRE 官方发布的 ATT&CK STIX 2.x bundle（如 enterprise-attack.json），
+// 只保留战术（x-mitre-tactic）与技术（attack-pattern）。
+func Parse(r io.Reader) (*Bundle, error) {
+	var bundle struct {
+		Type    string        `json:"type"`
+		Objects []*stixObject `json:"objects"`
+	}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Type != "bundle" {
+		return nil, ErrBundle
+	}
+
+	ret := new(Bundle)
+	techniques := make(map[string]*Technique, 1024)
+	for _, obj := range bundle.Objects {
+		id, url := obj.reference()
+		if id == "" {
+			continue
+		}
+		switch obj.Type {
+		case "x-mitre-tactic":
+			ret.Tactics = append(ret.Tactics, &Tactic{
+				ID:          id,
+				Name:        obj.Name,
+				ShortName:   obj.ShortName,
+				Description: obj.Description,
+				URL:         url,
+			})
+		case "attack-pattern":
+			tactics := make([]string, 0, len(obj.KillChainPhases))
+			for _, phase := range obj.KillChainPhases {
+				if strings.HasPrefix(phase.KillChainName, "mitre-") {
+					tactics = append(tactics, phase.PhaseName)
+				}
+			}
+			tech := &Technique{
+				ID:           id,
+				Name:         obj.Name,
+				Tactics:      tactics,
+				Platforms:    obj.Platforms,
+				Description:  obj.Description,
+				URL:          url,
+				Subtechnique: obj.Subtechnique,
+				Deprecated:   obj.Revoked || obj.Deprecated,
+			}
+			// 同一编号可能同时存在已撤销与有效的对象，优先保留有效的
+			if old := techniques[id]; old == nil || old.Deprecated {
+				techniques[id] = tech
+			}
+		}
+	}
+	for _, tech := range techniques {
+		ret.Techniques = append(ret.Techniques, tech)
+	}
+	if len(ret.Techniques) == 0 {
+		return nil, ErrBundle
+	}
+
+	sort.Slice(ret.Tactics, func(i, j int) bool { return ret.Tactics[i].ID < ret.Tactics[j].ID })
+	sort.Slice(ret.Techniques, func(i, j int) bool { return ret.Techniques[i].ID < ret.Techniques[j].ID })
+
+	return ret, nil
+}
@@ -0,0 +1,75 @@
+package ciphertext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sealPrefix 加密后的密文前缀，用于区分历史明文数据
+const sealPrefix = "aes:"
+
+var ErrEmptySecret = errors.New("加密密钥不能为空")
+
+// Sealer 使用 AES-256-GCM 加密存储敏感配置，密钥由 secret 做 SHA-256 得到。
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+// NewSealer 密钥为空时返回错误，避免使用 sha256("") 这样的固定密钥。
+func NewSealer(secret string) (*Sealer, error) {
+	if secret == "" {
+		return nil, ErrEmptySecret
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sealer{aead: aead}, nil
+}
+
+// Seal 加密
+func (s *Sealer) Seal(plain string) string {
+	nonce := make([]byte, s.aead.NonceSize())
+	_, _ = io.ReadFull(rand.Reader, nonce)
+	enc := s.aead.Seal(nonce, nonce, []byte(plain), nil)
+
+	return sealPrefix + base64.StdEncoding.EncodeToString(enc)
+}
+
+// Sealed 是否是加密后的密文
+func Sealed(s string) bool {
+	return strings.HasPrefix(s, sealPrefix)
+}
+
+// Open 解密，没有密文前缀的数据视为历史明文直接返回。
+func (s *Sealer) Open(enc string) (string, error) {
+	if !Sealed(enc) {
+		return enc, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(enc[len(sealPrefix):])
+	if err != nil {
+		return "", err
+	}
+	size := s.aead.NonceSize()
+	if len(raw) < size {
+		return "", errors.New("密文长度错误")
+	}
+	plain, err := s.aead.Open(nil, raw[:size], raw[size:], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
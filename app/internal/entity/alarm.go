@@ -0,0 +1,181 @@
+package entity
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type AlertSeverity uint8
+
+const (
+	// ASInfo 提示
+	ASInfo AlertSeverity = iota + 1
+	// ASWarning 警告
+	ASWarning
+	// ASMajor 重要
+	ASMajor
+	// ASCritical 紧急
+	ASCritical
+)
+
+func (as AlertSeverity) String() string {
+	switch as {
+	case ASInfo:
+		return "提示"
+	case ASWarning:
+		return "警告"
+	case ASMajor:
+		return "重要"
+	case ASCritical:
+		return "紧急"
+	default:
+		return "未知"
+	}
+}
+
+type AlertStatus uint8
+
+const (
+	// AlsFiring 告警中
+	AlsFiring AlertStatus = iota + 1
+	// AlsAcknowledged 已确认
+	AlsAcknowledged
+	// AlsResolved 已解决
+	AlsResolved
+)
+
+func (as AlertStatus) String() string {
+	switch as {
+	case AlsFiring:
+		return "告警中"
+	case AlsAcknowledged:
+		return "已确认"
+	case AlsResolved:
+		return "已解决"
+	default:
+		return "未知"
+	}
+}
+
+// AlertRule 告警规则
+//
+// 条件类型：
+//   - threshold: 时间窗口内的条数达到 Threshold
+//   - rate: 时间窗口内的条数达到 Threshold，且比上一个窗口增长了 Ratio 百分比
+//   - absence: 上一个窗口有数据，当前窗口没有数据（未分组时只要当前窗口没有数据就触发）
+type AlertRule struct {
+	ID          int64         `json:"id,string"         gorm:"column:id;primaryKey"`     // 规则 ID
+	Name        string        `json:"name"              gorm:"column:name"`              // 规则名称
+	Source      string        `json:"source"            gorm:"column:source"`            // 数据源：event risk logon minion
+	Filters     AlertFilters  `json:"filters"           gorm:"column:filters;json"`      // 过滤条件，全部满足才参与统计
+	Condition   string        `json:"condition"         gorm:"column:cond"`              // 条件类型：threshold rate absence
+	Threshold   int           `json:"threshold"         gorm:"column:threshold"`         // 条数阈值
+	Ratio       int           `json:"ratio"             gorm:"column:ratio"`             // rate 条件的增长百分比
+	Window      int           `json:"window"            gorm:"column:period"`            // 时间窗口（秒）
+	GroupBy     Strings       `json:"group_by"          gorm:"column:group_by;json"`     // 分组字段
+	Dedupe      int           `json:"dedupe"            gorm:"column:dedupe"`            // 去重窗口（秒），窗口内同一分组的告警只通知一次
+	Severity    AlertSeverity `json:"severity"          gorm:"column:severity"`          // 默认告警级别
+	SeverityMap SeverityMap   `json:"severity_map"      gorm:"column:severity_map;json"` // 数据源级别到告警级别的映射
+	NotifierIDs Int64s        `json:"notifier_ids"      gorm:"column:notifier_ids;json"` // 通知人
+	Enabled     bool          `json:"enabled"           gorm:"column:enabled"`           // 是否启用
+	Comment     string        `json:"comment"           gorm:"column:comment"`           // 说明
+	CreatedID   int64         `json:"created_id,string" gorm:"column:created_id"`        // 创建者 ID
+	CreatedAt   time.Time     `json:"created_at"        gorm:"column:created_at"`        // 创建时间
+	UpdatedAt   time.Time     `json:"updated_at"        gorm:"column:updated_at"`        // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (AlertRule) TableName() string {
+	return "alert_rule"
+}
+
+// Alert 告警规则触发的告警
+type Alert struct {
+	ID         int64           `json:"id,string"          gorm:"column:id;primaryKey"` // 告警 ID
+	RuleID     int64           `json:"rule_id,string"     gorm:"column:rule_id"`       // 规则 ID
+	RuleName   string          `json:"rule_name"          gorm:"column:rule_name"`     // 规则名称
+	Source     string          `json:"source"             gorm:"column:source"`        // 数据源
+	GroupKey   string          `json:"group_key"          gorm:"column:group_key"`     // 分组键，用于去重
+	Labels     json.RawMessage `json:"labels"             gorm:"column:labels"`        // 分组字段的值（JSON）
+	Severity   AlertSeverity   `json:"severity"           gorm:"column:severity"`      // 告警级别
+	Status     AlertStatus     `json:"status"             gorm:"column:status"`        // 告警状态
+	Summary    string          `json:"summary"            gorm:"column:summary"`       // 告警摘要
+	Count      int             `json:"count"              gorm:"column:count"`         // 去重窗口内的触发次数
+	Notified   bool            `json:"notified"           gorm:"column:notified"`      // 是否通知成功
+	NotifyErr  string          `json:"notify_err"         gorm:"column:notify_err"`    // 通知失败的原因
+	FirstAt    time.Time       `json:"first_at"           gorm:"column:first_at"`      // 首次触发时间
+	LastAt     time.Time       `json:"last_at"            gorm:"column:last_at"`       // 最近一次触发时间
+	AckedID    int64           `json:"acked_id,string"    gorm:"column:acked_id"`      // 确认人
+	AckedAt    sql.NullTime    `json:"acked_at"           gorm:"column:acked_at"`      // 确认时间
+	ResolvedID int64           `json:"resolved_id,string" gorm:"column:resolved_id"`   // 解决人
+	ResolvedAt sql.NullTime    `json:"resolved_at"        gorm:"column:resolved_at"`   // 解决时间
+	Remark     string          `json:"remark"             gorm:"column:remark"`        // 处理备注
+	CreatedAt  time.Time       `json:"created_at"         gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time       `json:"updated_at"         gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (Alert) TableName() string {
+	return "alert"
+}
+
+// AlertFilter 告警规则的过滤条件
+type AlertFilter struct {
+	Field    string   `json:"field"`    // 字段
+	Operator string   `json:"operator"` // 操作符：eq ne contains prefix
+	Values   []string `json:"values"`   // 匹配的值，多个值之间是或的关系
+}
+
+type AlertFilters []*AlertFilter
+
+// Scan implement sql.Scanner
+func (afs *AlertFilters) Scan(src any) error {
+	switch raw := src.(type) {
+	case nil:
+		*afs = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(raw, afs)
+	case string:
+		return json.Unmarshal([]byte(raw), afs)
+	default:
+		return errors.New("不支持的 AlertFilters 数据类型")
+	}
+}
+
+// Value implement driver.Valuer
+func (afs AlertFilters) Value() (driver.Value, error) {
+	if afs == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]*AlertFilter(afs))
+}
+
+// SeverityMap 数据源级别（如：紧急 重要 次要 普通）到告警级别的映射
+type SeverityMap map[string]AlertSeverity
+
+// Scan implement sql.Scanner
+func (sm *SeverityMap) Scan(src any) error {
+	switch raw := src.(type) {
+	case nil:
+		*sm = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(raw, sm)
+	case string:
+		return json.Unmarshal([]byte(raw), sm)
+	default:
+		return errors.New("不支持的 SeverityMap 数据类型")
+	}
+}
+
+// Value implement driver.Valuer
+func (sm SeverityMap) Value() (driver.Value, error) {
+	if sm == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]AlertSeverity(sm))
+}
@@ -0,0 +1,64 @@
+package entity
+
+import "time"
+
+// AttackTactic MITRE ATT&CK 战术
+type AttackTactic struct {
+	ID          int64     `json:"id,string"   gorm:"column:id;primaryKey"` // ID
+	TacticID    string    `json:"tactic_id"   gorm:"column:tactic_id"`     // 战术编号，如 TA0006
+	Name        string    `json:"name"        gorm:"column:name"`          // 战术名称
+	ShortName   string    `json:"short_name"  gorm:"column:short_name"`    // 短名称，如 credential-access
+	Description string    `json:"description" gorm:"column:description"`   // 描述
+	URL         string    `json:"url"         gorm:"column:url"`           // 官方链接
+	CreatedAt   time.Time `json:"created_at"  gorm:"column:created_at"`    // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"  gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (AttackTactic) TableName() string {
+	return "attack_tactic"
+}
+
+// AttackTechnique MITRE ATT&CK 技术与子技术
+type AttackTechnique struct {
+	ID           int64     `json:"id,string"    gorm:"column:id;primaryKey"`  // ID
+	TechniqueID  string    `json:"technique_id" gorm:"column:technique_id"`   // 技术编号，如 T1110.001
+	Name         string    `json:"name"         gorm:"column:name"`           // 技术名称
+	Tactics      Strings   `json:"tactics"      gorm:"column:tactics;json"`   // 所属战术的短名称
+	Platforms    Strings   `json:"platforms"    gorm:"column:platforms;json"` // 适用平台
+	Description  string    `json:"description"  gorm:"column:description"`    // 描述
+	URL          string    `json:"url"          gorm:"column:url"`            // 官方链接
+	Subtechnique bool      `json:"subtechnique" gorm:"column:subtechnique"`   // 是否是子技术
+	Deprecated   bool      `json:"deprecated"   gorm:"column:deprecated"`     // 是否已废弃
+	CreatedAt    time.Time `json:"created_at"   gorm:"column:created_at"`     // 创建时间
+	UpdatedAt    time.Time `json:"updated_at"   gorm:"column:updated_at"`     // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (AttackTechnique) TableName() string {
+	return "attack_technique"
+}
+
+// 风险映射到 ATT&CK 技术时匹配的字段
+const (
+	AttackFieldRiskType = "risk_type" // 风险类型
+	AttackFieldFromCode = "from_code" // 来源模块，一般为产生风险的配置名称
+)
+
+// AttackMapping 风险类型或来源模块与 ATT&CK 技术的映射关系
+type AttackMapping struct {
+	ID         int64     `json:"id,string"         gorm:"column:id;primaryKey"`   // ID
+	Field      string    `json:"field"             gorm:"column:field"`           // 匹配字段：risk_type from_code
+	Value      string    `json:"value"             gorm:"column:value"`           // 字段的值
+	Techniques Strings   `json:"techniques"        gorm:"column:techniques;json"` // 技术编号
+	Remark     string    `json:"remark"            gorm:"column:remark"`          // 备注
+	CreatedID  int64     `json:"created_id,string" gorm:"column:created_id"`      // 创建者 ID
+	UpdatedID  int64     `json:"updated_id,string" gorm:"column:updated_id"`      // 修改者 ID
+	CreatedAt  time.Time `json:"created_at"        gorm:"column:created_at"`      // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"        gorm:"column:updated_at"`      // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (AttackMapping) TableName() string {
+	return "attack_mapping"
+}
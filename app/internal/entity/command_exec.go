@@ -0,0 +1,108 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type ExecStatus uint8
+
+const (
+	// ESRunning 执行中
+	ESRunning ExecStatus = iota + 1
+	// ESFinished 已完成
+	ESFinished
+	// ESInterrupted 中断（manager 重启导致）
+	ESInterrupted
+)
+
+func (es ExecStatus) String() string {
+	switch es {
+	case ESRunning:
+		return "执行中"
+	case ESFinished:
+		return "已完成"
+	case ESInterrupted:
+		return "已中断"
+	default:
+		return "未知"
+	}
+}
+
+// CommandExec 远程指令执行记录，一次执行可以下发到多个节点
+type CommandExec struct {
+	ID         int64        `json:"id,string"         gorm:"column:id;primaryKey"` // 执行 ID
+	Cmd        string       `json:"cmd"               gorm:"column:cmd"`           // 指令
+	Filters    []byte       `json:"filters"           gorm:"column:filters"`       // 选择节点的 dynsql 条件（JSON），单节点执行时为空
+	Keyword    string       `json:"keyword"           gorm:"column:keyword"`       // 选择节点的关键字
+	Status     ExecStatus   `json:"status"            gorm:"column:status"`        // 执行状态
+	Total      int          `json:"total"             gorm:"column:total"`         // 节点总数
+	Succeed    int          `json:"succeed"           gorm:"column:succeed"`       // 执行成功数
+	Failed     int          `json:"failed"            gorm:"column:failed"`        // 执行失败数
+	CreatedID  int64        `json:"created_id,string" gorm:"column:created_id"`    // 执行者 ID
+	FinishedAt sql.NullTime `json:"finished_at"       gorm:"column:finished_at"`   // 结束时间
+	CreatedAt  time.Time    `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time    `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (CommandExec) TableName() string {
+	return "command_exec"
+}
+
+type ExecItemStatus uint8
+
+const (
+	// EISPending 等待执行
+	EISPending ExecItemStatus = iota + 1
+	// EISSucceed 执行成功
+	EISSucceed
+	// EISFailed 执行失败
+	EISFailed
+)
+
+func (eis ExecItemStatus) String() string {
+	switch eis {
+	case EISPending:
+		return "等待执行"
+	case EISSucceed:
+		return "执行成功"
+	case EISFailed:
+		return "执行失败"
+	default:
+		return "未知"
+	}
+}
+
+// CommandExecItem 指令在每个节点上的执行结果
+type CommandExecItem struct {
+	ID         int64          `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	ExecID     int64          `json:"exec_id,string"   gorm:"column:exec_id"`       // 执行 ID
+	MinionID   int64          `json:"minion_id,string" gorm:"column:minion_id"`     // 节点 ID
+	Inet       string         `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	BrokerID   int64          `json:"broker_id,string" gorm:"column:broker_id"`     // broker ID
+	BrokerName string         `json:"broker_name"      gorm:"column:broker_name"`   // broker 名字
+	Status     ExecItemStatus `json:"status"           gorm:"column:status"`        // 执行状态
+	Output     string         `json:"output"           gorm:"column:output"`        // 节点响应的输出
+	Digest     string         `json:"digest"           gorm:"column:digest"`        // 输出的 SHA-1，用于聚合相同的输出
+	Reason     string         `json:"reason"           gorm:"column:reason"`        // 失败原因
+	FinishedAt sql.NullTime   `json:"finished_at"      gorm:"column:finished_at"`   // 结束时间
+	CreatedAt  time.Time      `json:"created_at"       gorm:"column:created_at"`    // 创建时间
+}
+
+// TableName implement gorm schema.Tabler
+func (CommandExecItem) TableName() string {
+	return "command_exec_item"
+}
+
+type CommandExecItems []*CommandExecItem
+
+// BrokerMap 整理为 key: brokerID; value: 执行记录
+func (items CommandExecItems) BrokerMap() map[int64]CommandExecItems {
+	ret := make(map[int64]CommandExecItems, 16)
+	for _, item := range items {
+		bid := item.BrokerID
+		ret[bid] = append(ret[bid], item)
+	}
+	return ret
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// CursorSeen 按 ID 游标读取数据时，回扫窗口内已经处理过的数据 ID，用于去重。
+type CursorSeen struct {
+	ID        int64     `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	CursorKey string    `json:"cursor_key"       gorm:"column:cursor_key"`    // 游标，如 siem:<游标 ID>、webhook:<主题>
+	RecordID  int64     `json:"record_id,string" gorm:"column:record_id"`     // 已处理的数据 ID
+	CreatedAt time.Time `json:"created_at"       gorm:"column:created_at"`    // 处理时间
+}
+
+// TableName implement gorm schema.Tabler
+func (CursorSeen) TableName() string {
+	return "cursor_seen"
+}
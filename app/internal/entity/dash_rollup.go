@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// 趋势统计的指标
+const (
+	DashMetricRisk   = "risk"   // 风险事件数
+	DashMetricEvent  = "event"  // 安全事件数
+	DashMetricLogon  = "logon"  // 登录事件数
+	DashMetricOnline = "online" // 在线节点数，每小时取峰值
+)
+
+// 趋势统计的细分维度，空字符串代表总数
+const (
+	DashDimensionBroker   = "broker"
+	DashDimensionIDC      = "idc"
+	DashDimensionIBu      = "ibu"
+	DashDimensionRiskType = "risk_type"
+)
+
+// DashRollup 按小时预聚合的趋势统计数据，由后台任务定时维护，避免首页每次都扫描大表。
+type DashRollup struct {
+	ID        int64     `json:"id,string"  gorm:"column:id;primaryKey"` // ID
+	Metric    string    `json:"metric"     gorm:"column:metric"`        // 统计指标
+	Bucket    time.Time `json:"bucket"     gorm:"column:bucket"`        // 统计的小时
+	Dimension string    `json:"dimension"  gorm:"column:dimension"`     // 细分维度，空代表总数
+	Name      string    `json:"name"       gorm:"column:name"`          // 维度的值
+	Count     int64     `json:"count"      gorm:"column:count"`         // 数量
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (DashRollup) TableName() string {
+	return "dash_rollup"
+}
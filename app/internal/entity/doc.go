@@ -0,0 +1,3 @@
+// Package entity 存放 manager 自身使用的数据表模型，
+// broker 与 manager 公用的数据表模型在 vela-common-mb/dal/model 中维护。
+package entity
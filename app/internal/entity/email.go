@@ -0,0 +1,18 @@
+package entity
+
+// Email 邮件服务器配置，在 model.Email 的基础上增加了端口、加密方式与发件人，密码加密存储。
+type Email struct {
+	ID       int64  `json:"id,string" gorm:"column:id;primaryKey"` // ID
+	Host     string `json:"host"      gorm:"column:host"`          // 邮箱服务器
+	Port     int    `json:"port"      gorm:"column:port"`          // 端口
+	TLSMode  string `json:"tls_mode"  gorm:"column:tls_mode"`      // 加密方式：none starttls tls
+	Username string `json:"username"  gorm:"column:username"`      // 邮箱账号
+	Password string `json:"-"         gorm:"column:password"`      // 密码（密文）
+	Sender   string `json:"sender"    gorm:"column:sender"`        // 发件人地址，为空时使用邮箱账号
+	Enable   bool   `json:"enable"    gorm:"column:enable"`        // 当前使用的
+}
+
+// TableName implement gorm schema.Tabler
+func (Email) TableName() string {
+	return "email"
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+)
+
+type IncidentStatus uint8
+
+const (
+	// IncOpen 待处理
+	IncOpen IncidentStatus = iota + 1
+	// IncInvestigating 调查中
+	IncInvestigating
+	// IncClosed 已关闭
+	IncClosed
+)
+
+func (is IncidentStatus) String() string {
+	switch is {
+	case IncOpen:
+		return "待处理"
+	case IncInvestigating:
+		return "调查中"
+	case IncClosed:
+		return "已关闭"
+	default:
+		return "未知"
+	}
+}
+
+// Incident 由多条相关的风险事件聚合而成的安全事件
+type Incident struct {
+	ID        int64           `json:"id,string"        gorm:"column:id;primaryKey"` // 事件 ID
+	Title     string          `json:"title"            gorm:"column:title"`         // 事件标题
+	CorrKey   string          `json:"corr_key"         gorm:"column:corr_key"`      // 关联键，手动拆分的事件为空
+	Level     model.RiskLevel `json:"level"            gorm:"column:level"`         // 成员风险的最高级别
+	Status    IncidentStatus  `json:"status"           gorm:"column:status"`        // 状态
+	RiskCount int             `json:"risk_count"       gorm:"column:risk_count"`    // 成员风险数量
+	FirstAt   time.Time       `json:"first_at"         gorm:"column:first_at"`      // 最早的风险发生时间
+	LastAt    time.Time       `json:"last_at"          gorm:"column:last_at"`       // 最近的风险发生时间
+	ClosedID  int64           `json:"closed_id,string" gorm:"column:closed_id"`     // 关闭人
+	ClosedAt  sql.NullTime    `json:"closed_at"        gorm:"column:closed_at"`     // 关闭时间
+	CreatedAt time.Time       `json:"created_at"       gorm:"column:created_at"`    // 创建时间
+	UpdatedAt time.Time       `json:"updated_at"       gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (Incident) TableName() string {
+	return "incident"
+}
+
+// IncidentRisk 事件与风险的关联，一条风险只能属于一个事件
+type IncidentRisk struct {
+	ID         int64     `json:"id,string"          gorm:"column:id;primaryKey"` // ID
+	IncidentID int64     `json:"incident_id,string" gorm:"column:incident_id"`   // 事件 ID
+	RiskID     int64     `json:"risk_id,string"     gorm:"column:risk_id"`       // 风险 ID
+	CreatedAt  time.Time `json:"created_at"         gorm:"column:created_at"`    // 关联时间
+}
+
+// TableName implement gorm schema.Tabler
+func (IncidentRisk) TableName() string {
+	return "incident_risk"
+}
+
+// IncidentTimeline 事件的时间线，记录创建、合并、拆分与状态变更
+type IncidentTimeline struct {
+	ID         int64     `json:"id,string"          gorm:"column:id;primaryKey"` // ID
+	IncidentID int64     `json:"incident_id,string" gorm:"column:incident_id"`   // 事件 ID
+	UserID     int64     `json:"user_id,string"     gorm:"column:user_id"`       // 操作人，0 代表系统自动关联
+	Action     string    `json:"action"             gorm:"column:action"`        // 动作：create merge split status
+	Content    string    `json:"content"            gorm:"column:content"`       // 内容
+	CreatedAt  time.Time `json:"created_at"         gorm:"column:created_at"`    // 时间
+}
+
+// TableName implement gorm schema.Tabler
+func (IncidentTimeline) TableName() string {
+	return "incident_timeline"
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type ImportStatus uint8
+
+const (
+	// ISRunning 导入中
+	ISRunning ImportStatus = iota + 1
+	// ISFinished 已完成
+	ISFinished
+	// ISInterrupted 中断（manager 重启导致）
+	ISInterrupted
+)
+
+func (is ImportStatus) String() string {
+	switch is {
+	case ISRunning:
+		return "导入中"
+	case ISFinished:
+		return "已完成"
+	case ISInterrupted:
+		return "已中断"
+	default:
+		return "未知"
+	}
+}
+
+// MinionImport 批量导入节点任务
+type MinionImport struct {
+	ID         int64        `json:"id,string"         gorm:"column:id;primaryKey"` // 任务 ID
+	Filename   string       `json:"filename"          gorm:"column:filename"`      // 上传的文件名
+	Status     ImportStatus `json:"status"            gorm:"column:status"`        // 任务状态
+	Total      int          `json:"total"             gorm:"column:total"`         // 数据总行数
+	Succeed    int          `json:"succeed"           gorm:"column:succeed"`       // 导入成功数
+	Failed     int          `json:"failed"            gorm:"column:failed"`        // 导入失败数（含校验失败）
+	CreatedID  int64        `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	FinishedAt sql.NullTime `json:"finished_at"       gorm:"column:finished_at"`   // 结束时间
+	CreatedAt  time.Time    `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time    `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (MinionImport) TableName() string {
+	return "minion_import"
+}
+
+// MinionImportItem 批量导入任务中每一行的导入结果
+type MinionImportItem struct {
+	ID        int64     `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	ImportID  int64     `json:"import_id,string" gorm:"column:import_id"`     // 任务 ID
+	Row       int       `json:"row"              gorm:"column:row"`           // 文件中的行号
+	Inet      string    `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	MinionID  int64     `json:"minion_id,string" gorm:"column:minion_id"`     // 导入成功后的节点 ID
+	Succeed   bool      `json:"succeed"          gorm:"column:succeed"`       // 是否导入成功
+	Reason    string    `json:"reason"           gorm:"column:reason"`        // 失败原因
+	CreatedAt time.Time `json:"created_at"       gorm:"column:created_at"`    // 创建时间
+}
+
+// TableName implement gorm schema.Tabler
+func (MinionImportItem) TableName() string {
+	return "minion_import_item"
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type OfflineStatus uint8
+
+const (
+	// OSPending 等待通知
+	OSPending OfflineStatus = iota + 1
+	// OSSucceed 通知成功
+	OSSucceed
+	// OSFailed 通知失败（一般是 broker 不可达）
+	OSFailed
+)
+
+func (st OfflineStatus) String() string {
+	switch st {
+	case OSPending:
+		return "等待通知"
+	case OSSucceed:
+		return "通知成功"
+	case OSFailed:
+		return "通知失败"
+	default:
+		return "未知"
+	}
+}
+
+// MinionOffline 节点删除后通知 broker 下线节点的记录
+type MinionOffline struct {
+	ID         int64         `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	MinionID   int64         `json:"minion_id,string" gorm:"column:minion_id"`     // 节点 ID
+	Inet       string        `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	BrokerID   int64         `json:"broker_id,string" gorm:"column:broker_id"`     // broker ID
+	BrokerName string        `json:"broker_name"      gorm:"column:broker_name"`   // broker 名字
+	Status     OfflineStatus `json:"status"           gorm:"column:status"`        // 通知状态
+	Retries    int           `json:"retries"          gorm:"column:retries"`       // 已重试次数
+	Reason     string        `json:"reason"           gorm:"column:reason"`        // 失败原因
+	NotifiedAt sql.NullTime  `json:"notified_at"      gorm:"column:notified_at"`   // 通知成功时间
+	CreatedAt  time.Time     `json:"created_at"       gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time     `json:"updated_at"       gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (MinionOffline) TableName() string {
+	return "minion_offline"
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+type PurgeStatus uint8
+
+const (
+	// PSPending 排队中
+	PSPending PurgeStatus = iota + 1
+	// PSRunning 清理中
+	PSRunning
+	// PSFinished 已完成
+	PSFinished
+	// PSInterrupted 中断（manager 重启导致）
+	PSInterrupted
+)
+
+func (ps PurgeStatus) String() string {
+	switch ps {
+	case PSPending:
+		return "排队中"
+	case PSRunning:
+		return "清理中"
+	case PSFinished:
+		return "已完成"
+	case PSInterrupted:
+		return "已中断"
+	default:
+		return "未知"
+	}
+}
+
+// MinionPurge 物理删除节点及其关联数据的清理任务
+type MinionPurge struct {
+	ID         int64        `json:"id,string"         gorm:"column:id;primaryKey"` // 任务 ID
+	Total      int          `json:"total"             gorm:"column:total"`         // 清理的节点数
+	Status     PurgeStatus  `json:"status"            gorm:"column:status"`        // 任务状态
+	Removed    int64        `json:"removed"           gorm:"column:removed"`       // 累计删除的数据行数
+	Failed     int          `json:"failed"            gorm:"column:failed"`        // 清理失败的数据表数
+	CreatedID  int64        `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	FinishedAt sql.NullTime `json:"finished_at"       gorm:"column:finished_at"`   // 结束时间
+	CreatedAt  time.Time    `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time    `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (MinionPurge) TableName() string {
+	return "minion_purge"
+}
+
+// MinionPurgeStep 清理任务中每张数据表的清理结果
+type MinionPurgeStep struct {
+	ID        int64     `json:"id,string"       gorm:"column:id;primaryKey"` // ID
+	PurgeID   int64     `json:"purge_id,string" gorm:"column:purge_id"`      // 任务 ID
+	Name      string    `json:"name"            gorm:"column:name"`          // 数据表名
+	Removed   int64     `json:"removed"         gorm:"column:removed"`       // 删除的数据行数
+	Failed    bool      `json:"failed"          gorm:"column:failed"`        // 是否清理失败
+	Reason    string    `json:"reason"          gorm:"column:reason"`        // 失败原因
+	Elapsed   int64     `json:"elapsed"         gorm:"column:elapsed"`       // 耗时（毫秒）
+	CreatedAt time.Time `json:"created_at"      gorm:"column:created_at"`    // 创建时间
+}
+
+// TableName implement gorm schema.Tabler
+func (MinionPurgeStep) TableName() string {
+	return "minion_purge_step"
+}
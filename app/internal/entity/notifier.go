@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// 通知的执行方
+const (
+	PerformerManager = "manager"
+	PerformerBroker  = "broker"
+)
+
+type NotifyStatus uint8
+
+const (
+	// NsSucceed 发送成功
+	NsSucceed NotifyStatus = iota + 1
+	// NsFailed 发送失败
+	NsFailed
+)
+
+func (ns NotifyStatus) String() string {
+	switch ns {
+	case NsSucceed:
+		return "发送成功"
+	case NsFailed:
+		return "发送失败"
+	default:
+		return "未知"
+	}
+}
+
+// NotifierDelivery 告警通知人的通知记录，manager 与 broker 发送的通知都记录在此表中。
+type NotifierDelivery struct {
+	ID         int64        `json:"id,string"          gorm:"column:id;primaryKey"` // ID
+	NotifierID int64        `json:"notifier_id,string" gorm:"column:notifier_id"`   // 通知人 ID
+	Channel    string       `json:"channel"            gorm:"column:channel"`       // 通知方式：dong email wechat sms call
+	Source     string       `json:"source"             gorm:"column:source"`        // 通知来源：event risk alert
+	Subject    string       `json:"subject"            gorm:"column:subject"`       // 通知标题
+	Status     NotifyStatus `json:"status"             gorm:"column:status"`        // 发送状态
+	Error      string       `json:"error"              gorm:"column:error"`         // 发送失败的原因
+	Performer  string       `json:"performer"          gorm:"column:performer"`     // 执行方：manager broker
+	BrokerID   int64        `json:"broker_id,string"   gorm:"column:broker_id"`     // 执行通知的 broker 节点 ID
+	Test       bool         `json:"test"               gorm:"column:test"`          // 是否是测试通知
+	CreatedAt  time.Time    `json:"created_at"         gorm:"column:created_at"`    // 发送时间
+}
+
+// TableName implement gorm schema.Tabler
+func (NotifierDelivery) TableName() string {
+	return "notifier_delivery"
+}
@@ -0,0 +1,73 @@
+package entity
+
+import "time"
+
+// PassIP IP 白名单，在 model.PassIP 的基础上增加了原因与操作人。
+type PassIP struct {
+	ID        int64     `json:"id,string"         gorm:"column:id;primaryKey"` // ID
+	IP        string    `json:"ip"                gorm:"column:ip"`            // IP 地址
+	Kind      string    `json:"kind"              gorm:"column:kind"`          // 数据维度
+	Reason    string    `json:"reason"            gorm:"column:reason"`        // 加白原因
+	BeforeAt  time.Time `json:"before_at"         gorm:"column:before_at"`     // 有效期
+	CreatedID int64     `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	UpdatedID int64     `json:"updated_id,string" gorm:"column:updated_id"`    // 修改者 ID
+	CreatedAt time.Time `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt time.Time `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (PassIP) TableName() string {
+	return "pass_ip"
+}
+
+// PassDNS DNS 白名单，在 model.PassDNS 的基础上增加了原因与操作人。
+type PassDNS struct {
+	ID        int64     `json:"id,string"         gorm:"column:id;primaryKey"` // ID
+	Domain    string    `json:"domain"            gorm:"column:domain"`        // 域名
+	Kind      string    `json:"kind"              gorm:"column:kind"`          // 数据维度
+	Reason    string    `json:"reason"            gorm:"column:reason"`        // 加白原因
+	BeforeAt  time.Time `json:"before_at"         gorm:"column:before_at"`     // 有效期
+	CreatedID int64     `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	UpdatedID int64     `json:"updated_id,string" gorm:"column:updated_id"`    // 修改者 ID
+	CreatedAt time.Time `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt time.Time `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (PassDNS) TableName() string {
+	return "pass_dns"
+}
+
+// 白名单类型
+const (
+	PassTargetIP  = "ip"
+	PassTargetDNS = "dns"
+)
+
+// 白名单变更类型
+const (
+	PassCreate = "create"
+	PassUpdate = "update"
+	PassDelete = "delete"
+	PassExpire = "expire"
+	PassImport = "import"
+)
+
+// PassChangelog 白名单变更记录
+type PassChangelog struct {
+	ID        int64     `json:"id,string"      gorm:"column:id;primaryKey"` // ID
+	Target    string    `json:"target"         gorm:"column:target"`        // 白名单类型：ip dns
+	RuleID    int64     `json:"rule_id,string" gorm:"column:rule_id"`       // 白名单 ID
+	Value     string    `json:"value"          gorm:"column:value"`         // IP 或域名
+	Kind      string    `json:"kind"           gorm:"column:kind"`          // 数据维度
+	Action    string    `json:"action"         gorm:"column:action"`        // 变更类型：create update delete expire import
+	Detail    string    `json:"detail"         gorm:"column:detail"`        // 变更内容
+	UserID    int64     `json:"user_id,string" gorm:"column:user_id"`       // 操作人 ID
+	Username  string    `json:"username"       gorm:"column:username"`      // 操作人
+	CreatedAt time.Time `json:"created_at"     gorm:"column:created_at"`    // 变更时间
+}
+
+// TableName implement gorm schema.Tabler
+func (PassChangelog) TableName() string {
+	return "pass_changelog"
+}
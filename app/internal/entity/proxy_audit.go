@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// ProxyAudit BRR/ARR 代理请求的审计记录
+type ProxyAudit struct {
+	ID         int64     `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	Kind       string    `json:"kind"             gorm:"column:kind"`          // 通道类型：brr arr
+	UserID     int64     `json:"user_id,string"   gorm:"column:user_id"`       // 用户 ID
+	MinionID   int64     `json:"minion_id,string" gorm:"column:minion_id"`     // 节点 ID，BRR 时为 0
+	BrokerID   int64     `json:"broker_id,string" gorm:"column:broker_id"`     // broker ID
+	Inet       string    `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	Method     string    `json:"method"           gorm:"column:method"`        // 请求方法
+	Path       string    `json:"path"             gorm:"column:path"`          // 去掉通道前缀后的请求路径
+	Query      string    `json:"query"            gorm:"column:query"`         // 脱敏后的请求参数
+	ClientIP   string    `json:"client_ip"        gorm:"column:client_ip"`     // 客户端 IP
+	Status     int       `json:"status"           gorm:"column:status"`        // 上游响应状态码
+	ReqSize    int64     `json:"req_size"         gorm:"column:req_size"`      // 请求报文大小
+	RespSize   int64     `json:"resp_size"        gorm:"column:resp_size"`     // 响应报文大小
+	Latency    int64     `json:"latency"          gorm:"column:latency"`       // 耗时（毫秒）
+	RespSample string    `json:"resp_sample"      gorm:"column:resp_sample"`   // 响应报文采样
+	CreatedAt  time.Time `json:"created_at"       gorm:"column:created_at"`    // 请求时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ProxyAudit) TableName() string {
+	return "proxy_audit"
+}
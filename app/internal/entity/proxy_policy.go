@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ProxyPolicy BRR/ARR/BWS/AWS 节点代理访问策略，各个匹配条件为空时表示不限制。
+type ProxyPolicy struct {
+	ID        int64        `json:"id,string"         gorm:"column:id;primaryKey"`   // 策略 ID
+	Name      string       `json:"name"              gorm:"column:name"`            // 策略名称
+	UserID    int64        `json:"user_id,string"    gorm:"column:user_id"`         // 适用的用户，0 代表所有用户
+	Allow     bool         `json:"allow"             gorm:"column:allow"`           // true-允许 false-拒绝
+	Kinds     Strings      `json:"kinds"             gorm:"column:kinds;json"`      // 通道类型：brr arr bws aws
+	Tags      Strings      `json:"tags"              gorm:"column:tags;json"`       // 节点标签，只对 agent 节点生效
+	IDCs      Strings      `json:"idcs"              gorm:"column:idcs;json"`       // 节点 IDC，只对 agent 节点生效
+	BrokerIDs Int64s       `json:"broker_ids"        gorm:"column:broker_ids;json"` // broker ID，agent 节点按照所属 broker 匹配
+	Paths     Strings      `json:"paths"             gorm:"column:paths;json"`      // 路径，以 * 结尾为前缀匹配，其余按照 path.Match 匹配
+	Methods   Strings      `json:"methods"           gorm:"column:methods;json"`    // HTTP 请求方法
+	Enabled   bool         `json:"enabled"           gorm:"column:enabled"`         // 是否启用
+	ExpiredAt sql.NullTime `json:"expired_at"        gorm:"column:expired_at"`      // 过期时间，用于临时授权，为空代表永久有效
+	Comment   string       `json:"comment"           gorm:"column:comment"`         // 说明
+	CreatedID int64        `json:"created_id,string" gorm:"column:created_id"`      // 创建者 ID
+	CreatedAt time.Time    `json:"created_at"        gorm:"column:created_at"`      // 创建时间
+	UpdatedAt time.Time    `json:"updated_at"        gorm:"column:updated_at"`      // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ProxyPolicy) TableName() string {
+	return "proxy_policy"
+}
+
+// ProxyDenial 被访问策略拒绝的代理请求
+type ProxyDenial struct {
+	ID        int64     `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	UserID    int64     `json:"user_id,string"   gorm:"column:user_id"`       // 用户 ID
+	Kind      string    `json:"kind"             gorm:"column:kind"`          // 通道类型
+	MinionID  int64     `json:"minion_id,string" gorm:"column:minion_id"`     // 节点 ID
+	BrokerID  int64     `json:"broker_id,string" gorm:"column:broker_id"`     // broker ID
+	Inet      string    `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	Method    string    `json:"method"           gorm:"column:method"`        // 请求方法
+	Path      string    `json:"path"             gorm:"column:path"`          // 请求路径
+	PolicyID  int64     `json:"policy_id,string" gorm:"column:policy_id"`     // 命中的拒绝策略，0 代表没有允许的策略
+	ClientIP  string    `json:"client_ip"        gorm:"column:client_ip"`     // 客户端 IP
+	CreatedAt time.Time `json:"created_at"       gorm:"column:created_at"`    // 请求时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ProxyDenial) TableName() string {
+	return "proxy_denial"
+}
+
+// Strings 以 JSON 数组存储的字符串切片
+type Strings []string
+
+// Scan implement sql.Scanner
+func (ss *Strings) Scan(src any) error {
+	switch raw := src.(type) {
+	case nil:
+		*ss = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(raw, ss)
+	case string:
+		return json.Unmarshal([]byte(raw), ss)
+	default:
+		return errors.New("不支持的 Strings 数据类型")
+	}
+}
+
+// Value implement driver.Valuer
+func (ss Strings) Value() (driver.Value, error) {
+	if ss == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(ss))
+}
+
+// Contains 是否包含 s
+func (ss Strings) Contains(s string) bool {
+	for _, str := range ss {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Int64s 以 JSON 数组存储的 int64 切片，JSON 序列化为字符串数组避免前端精度丢失。
+type Int64s []int64
+
+// Scan implement sql.Scanner
+func (is *Int64s) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*is = nil
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("不支持的 Int64s 数据类型")
+	}
+	var nums []int64
+	if err := json.Unmarshal(raw, &nums); err != nil {
+		return err
+	}
+	*is = nums
+
+	return nil
+}
+
+// Value implement driver.Valuer
+func (is Int64s) Value() (driver.Value, error) {
+	if is == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]int64(is))
+}
+
+func (is Int64s) MarshalJSON() ([]byte, error) {
+	strs := make([]string, 0, len(is))
+	for _, i := range is {
+		strs = append(strs, strconv.FormatInt(i, 10))
+	}
+	return json.Marshal(strs)
+}
+
+func (is *Int64s) UnmarshalJSON(raw []byte) error {
+	var strs []string
+	if err := json.Unmarshal(raw, &strs); err != nil {
+		return err
+	}
+	nums := make([]int64, 0, len(strs))
+	for _, s := range strs {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		nums = append(nums, n)
+	}
+	*is = nums
+
+	return nil
+}
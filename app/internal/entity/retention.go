@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RetentionPolicy 数据表的保留策略，按照保留天数与保留行数清理数据，两者都设置时任一条件满足即清理。
+type RetentionPolicy struct {
+	ID        int64     `json:"id,string"         gorm:"column:id;primaryKey"` // 策略 ID
+	Table     string    `json:"table"             gorm:"column:table_name"`    // 数据表
+	MaxDays   int       `json:"max_days"          gorm:"column:max_days"`      // 保留天数，0 代表不按照时间清理
+	MaxRows   int64     `json:"max_rows"          gorm:"column:max_rows"`      // 保留行数，0 代表不按照行数清理
+	Archive   bool      `json:"archive"           gorm:"column:archive"`       // 清理前是否归档到 gridfs
+	Enabled   bool      `json:"enabled"           gorm:"column:enabled"`       // 是否启用
+	UpdatedID int64     `json:"updated_id,string" gorm:"column:updated_id"`    // 修改者 ID
+	CreatedAt time.Time `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt time.Time `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (RetentionPolicy) TableName() string {
+	return "retention_policy"
+}
+
+// RetentionRun 数据清理记录
+type RetentionRun struct {
+	ID        int64        `json:"id,string"      gorm:"column:id;primaryKey"` // 记录 ID
+	Table     string       `json:"table"          gorm:"column:table_name"`    // 数据表
+	Deleted   int64        `json:"deleted"        gorm:"column:deleted"`       // 删除的行数
+	Archived  int64        `json:"archived"       gorm:"column:archived"`      // 归档的行数
+	FileID    int64        `json:"file_id,string" gorm:"column:file_id"`       // 归档文件在 gridfs 中的 ID
+	FileSize  int64        `json:"file_size"      gorm:"column:file_size"`     // 归档文件大小
+	Cutoff    sql.NullTime `json:"cutoff"         gorm:"column:cutoff"`        // 按照时间清理的截止时间
+	Error     string       `json:"error"          gorm:"column:error"`         // 清理出错的原因
+	Manual    bool         `json:"manual"         gorm:"column:manual"`        // 是否手动触发
+	StartedAt time.Time    `json:"started_at"     gorm:"column:started_at"`    // 开始时间
+	EndedAt   time.Time    `json:"ended_at"       gorm:"column:ended_at"`      // 结束时间
+}
+
+// TableName implement gorm schema.Tabler
+func (RetentionRun) TableName() string {
+	return "retention_run"
+}
@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+)
+
+// RiskCase 风险事件的处置工单，与风险事件一一对应
+type RiskCase struct {
+	ID         int64        `json:"id,string"          gorm:"column:id;primaryKey"` // 工单 ID
+	RiskID     int64        `json:"risk_id,string"     gorm:"column:risk_id"`       // 风险事件 ID
+	AssigneeID int64        `json:"assignee_id,string" gorm:"column:assignee_id"`   // 处理人
+	Assignee   string       `json:"assignee"           gorm:"column:assignee"`      // 处理人用户名
+	AssignerID int64        `json:"assigner_id,string" gorm:"column:assigner_id"`   // 分派人
+	DueAt      sql.NullTime `json:"due_at"             gorm:"column:due_at"`        // 处理期限
+	Breached   bool         `json:"breached"           gorm:"column:breached"`      // 是否超过处理期限
+	CreatedAt  time.Time    `json:"created_at"         gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time    `json:"updated_at"         gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (RiskCase) TableName() string {
+	return "risk_case"
+}
+
+// RiskComment 风险事件的评论，可以附带一个附件
+type RiskComment struct {
+	ID        int64     `json:"id,string"      gorm:"column:id;primaryKey"` // 评论 ID
+	RiskID    int64     `json:"risk_id,string" gorm:"column:risk_id"`       // 风险事件 ID
+	UserID    int64     `json:"user_id,string" gorm:"column:user_id"`       // 评论人
+	Username  string    `json:"username"       gorm:"column:username"`      // 评论人用户名
+	Content   string    `json:"content"        gorm:"column:content"`       // 评论内容
+	FileID    int64     `json:"file_id,string" gorm:"column:file_id"`       // 附件在 gridfs 中的 ID，0 代表没有附件
+	FileName  string    `json:"file_name"      gorm:"column:file_name"`     // 附件名称
+	FileSize  int64     `json:"file_size"      gorm:"column:file_size"`     // 附件大小
+	CreatedAt time.Time `json:"created_at"     gorm:"column:created_at"`    // 评论时间
+}
+
+// TableName implement gorm schema.Tabler
+func (RiskComment) TableName() string {
+	return "risk_comment"
+}
+
+// RiskTransition 风险事件的状态变更记录
+type RiskTransition struct {
+	ID         int64            `json:"id,string"      gorm:"column:id;primaryKey"` // 记录 ID
+	RiskID     int64            `json:"risk_id,string" gorm:"column:risk_id"`       // 风险事件 ID
+	UserID     int64            `json:"user_id,string" gorm:"column:user_id"`       // 操作人
+	Username   string           `json:"username"       gorm:"column:username"`      // 操作人用户名
+	FromStatus model.RiskStatus `json:"from_status"    gorm:"column:from_status"`   // 变更前的状态
+	ToStatus   model.RiskStatus `json:"to_status"      gorm:"column:to_status"`     // 变更后的状态
+	Reason     string           `json:"reason"         gorm:"column:reason"`        // 变更原因
+	CreatedAt  time.Time        `json:"created_at"     gorm:"column:created_at"`    // 变更时间
+}
+
+// TableName implement gorm schema.Tabler
+func (RiskTransition) TableName() string {
+	return "risk_transition"
+}
@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+// SessionRecord AWS/BWS websocket 通道的会话录像，录像文件以 gzip 压缩后存放在 gridfs 中
+type SessionRecord struct {
+	ID        int64     `json:"id,string"        gorm:"column:id;primaryKey"` // ID
+	Kind      string    `json:"kind"             gorm:"column:kind"`          // 通道类型：aws bws
+	UserID    int64     `json:"user_id,string"   gorm:"column:user_id"`       // 用户 ID
+	Username  string    `json:"username"         gorm:"column:username"`      // 用户名
+	MinionID  int64     `json:"minion_id,string" gorm:"column:minion_id"`     // 节点 ID，BWS 时为 0
+	BrokerID  int64     `json:"broker_id,string" gorm:"column:broker_id"`     // broker ID
+	Inet      string    `json:"inet"             gorm:"column:inet"`          // 节点 IP
+	Path      string    `json:"path"             gorm:"column:path"`          // 请求路径
+	FileID    int64     `json:"file_id,string"   gorm:"column:file_id"`       // gridfs 文件 ID
+	Size      int64     `json:"size"             gorm:"column:size"`          // 压缩后的录像大小
+	Frames    int       `json:"frames"           gorm:"column:frames"`        // 录制的帧数
+	Input     int64     `json:"input"            gorm:"column:input"`         // 控制台发送给节点的字节数
+	Output    int64     `json:"output"           gorm:"column:output"`        // 节点发送给控制台的字节数
+	Truncated bool      `json:"truncated"        gorm:"column:truncated"`     // 超过录像大小限制后不再录制
+	StartedAt time.Time `json:"started_at"       gorm:"column:started_at"`    // 会话开始时间
+	EndedAt   time.Time `json:"ended_at"         gorm:"column:ended_at"`      // 会话结束时间
+	CreatedAt time.Time `json:"created_at"       gorm:"column:created_at"`    // 创建时间
+}
+
+// TableName implement gorm schema.Tabler
+func (SessionRecord) TableName() string {
+	return "session_record"
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SiemForwarder 将 risk event oplog 数据以 syslog 的方式转发到 SIEM
+type SiemForwarder struct {
+	ID         int64        `json:"id,string"         gorm:"column:id;primaryKey"` // 转发器 ID
+	Name       string       `json:"name"              gorm:"column:name"`          // 名称
+	Network    string       `json:"network"           gorm:"column:network"`       // 传输协议：udp tcp tls
+	Address    string       `json:"address"           gorm:"column:address"`       // 接收端地址 host:port
+	Insecure   bool         `json:"insecure"          gorm:"column:insecure"`      // TLS 是否跳过证书校验
+	Format     string       `json:"format"            gorm:"column:format"`        // 消息格式：cef leef json
+	Sources    Strings      `json:"sources"           gorm:"column:sources;json"`  // 转发的数据：risk event oplog
+	Facility   int          `json:"facility"          gorm:"column:facility"`      // syslog facility
+	Enabled    bool         `json:"enabled"           gorm:"column:enabled"`       // 是否启用
+	LastError  string       `json:"last_error"        gorm:"column:last_error"`    // 最近一次转发出错的原因
+	LastSentAt sql.NullTime `json:"last_sent_at"      gorm:"column:last_sent_at"`  // 最近一次转发成功的时间
+	CreatedID  int64        `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	CreatedAt  time.Time    `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time    `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (SiemForwarder) TableName() string {
+	return "siem_forwarder"
+}
+
+// SiemCursor 转发器在每个数据源上的转发进度，重启后从游标处继续转发。
+type SiemCursor struct {
+	ID          int64     `json:"id,string"           gorm:"column:id;primaryKey"` // ID
+	ForwarderID int64     `json:"forwarder_id,string" gorm:"column:forwarder_id"`  // 转发器 ID
+	Source      string    `json:"source"              gorm:"column:source"`        // 数据源
+	FloorID     int64     `json:"floor_id,string"     gorm:"column:floor_id"`      // 创建游标时的最大数据 ID，回扫不早于此 ID
+	LastID      int64     `json:"last_id,string"      gorm:"column:last_id"`       // 已转发的最大数据 ID
+	Sent        int64     `json:"sent"                gorm:"column:sent"`          // 累计转发条数
+	UpdatedAt   time.Time `json:"updated_at"          gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (SiemCursor) TableName() string {
+	return "siem_cursor"
+}
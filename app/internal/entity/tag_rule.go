@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+)
+
+// TkRule 自动标签规则生成的标签，只能由规则维护，手动修改标签时会保留。
+const TkRule = model.TkMinion + 1
+
+// TagRule 自动标签规则，节点满足全部条件时打上 Tag 标签
+type TagRule struct {
+	ID         int64         `json:"id,string"         gorm:"column:id;primaryKey"`   // 规则 ID
+	Name       string        `json:"name"              gorm:"column:name"`            // 规则名称
+	Tag        string        `json:"tag"               gorm:"column:tag"`             // 满足条件时打上的标签
+	Conditions TagConditions `json:"conditions"        gorm:"column:conditions;json"` // 匹配条件，全部满足才算匹配
+	Enabled    bool          `json:"enabled"           gorm:"column:enabled"`         // 是否启用
+	Comment    string        `json:"comment"           gorm:"column:comment"`         // 说明
+	CreatedID  int64         `json:"created_id,string" gorm:"column:created_id"`      // 创建者 ID
+	CreatedAt  time.Time     `json:"created_at"        gorm:"column:created_at"`      // 创建时间
+	UpdatedAt  time.Time     `json:"updated_at"        gorm:"column:updated_at"`      // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (TagRule) TableName() string {
+	return "tag_rule"
+}
+
+// TagCondition 自动标签匹配条件
+type TagCondition struct {
+	Field    string   `json:"field"`    // 匹配的字段，如：inet goos idc cmdb.env
+	Operator string   `json:"operator"` // 操作符：eq ne contains prefix cidr
+	Values   []string `json:"values"`   // 匹配的值，多个值之间是或的关系
+}
+
+type TagConditions []*TagCondition
+
+// Scan implement sql.Scanner
+func (tcs *TagConditions) Scan(src any) error {
+	switch raw := src.(type) {
+	case nil:
+		*tcs = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(raw, tcs)
+	case string:
+		return json.Unmarshal([]byte(raw), tcs)
+	default:
+		return errors.New("不支持的 TagConditions 数据类型")
+	}
+}
+
+// Value implement driver.Valuer
+func (tcs TagConditions) Value() (driver.Value, error) {
+	return json.Marshal(tcs)
+}
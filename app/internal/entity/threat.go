@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ThreatIndicator 威胁情报指标，按照类型与值去重
+type ThreatIndicator struct {
+	ID          int64        `json:"id,string"   gorm:"column:id;primaryKey"` // 指标 ID
+	Type        string       `json:"type"        gorm:"column:type"`          // 类型：ip cidr domain url md5 sha1 sha256
+	Value       string       `json:"value"       gorm:"column:value"`         // 值
+	Kind        string       `json:"kind"        gorm:"column:kind"`          // 威胁分类
+	Source      string       `json:"source"      gorm:"column:source"`        // 情报来源
+	Confidence  int          `json:"confidence"  gorm:"column:confidence"`    // 置信度 0-100
+	Description string       `json:"description" gorm:"column:description"`   // 描述
+	ExpiredAt   sql.NullTime `json:"expired_at"  gorm:"column:expired_at"`    // 过期时间，为空代表永久有效
+	CreatedAt   time.Time    `json:"created_at"  gorm:"column:created_at"`    // 首次导入时间
+	UpdatedAt   time.Time    `json:"updated_at"  gorm:"column:updated_at"`    // 最近导入时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ThreatIndicator) TableName() string {
+	return "threat_indicator"
+}
+
+// ThreatImport 情报文件导入记录
+type ThreatImport struct {
+	ID        int64     `json:"id,string"         gorm:"column:id;primaryKey"` // 记录 ID
+	Source    string    `json:"source"            gorm:"column:source"`        // 情报来源
+	Filename  string    `json:"filename"          gorm:"column:filename"`      // 文件名
+	Format    string    `json:"format"            gorm:"column:format"`        // 文件格式：csv txt stix
+	Digest    string    `json:"digest"            gorm:"column:digest"`        // 文件 SHA-1，用于跳过目录中已导入或导入失败的文件
+	Total     int       `json:"total"             gorm:"column:total"`         // 有效指标数
+	Invalid   int       `json:"invalid"           gorm:"column:invalid"`       // 无法识别的条目数
+	Error     string    `json:"error"             gorm:"column:error"`         // 导入失败的原因
+	CreatedID int64     `json:"created_id,string" gorm:"column:created_id"`    // 导入者 ID，0 代表从目录自动导入
+	CreatedAt time.Time `json:"created_at"        gorm:"column:created_at"`    // 导入时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ThreatImport) TableName() string {
+	return "threat_import"
+}
+
+// ThreatMatch 威胁情报命中记录
+type ThreatMatch struct {
+	ID          int64     `json:"id,string"           gorm:"column:id;primaryKey"` // 记录 ID
+	IndicatorID int64     `json:"indicator_id,string" gorm:"column:indicator_id"`  // 命中的指标
+	Type        string    `json:"type"                gorm:"column:type"`          // 指标类型
+	Value       string    `json:"value"               gorm:"column:value"`         // 指标值
+	Target      string    `json:"target"              gorm:"column:target"`        // 命中的数据：risk pass_ip pass_dns
+	TargetID    int64     `json:"target_id,string"    gorm:"column:target_id"`     // 命中数据的 ID
+	Matched     string    `json:"matched"             gorm:"column:matched"`       // 命中数据的值
+	Acked       bool      `json:"acked"               gorm:"column:acked"`         // 是否已查看，未查看的为新命中
+	CreatedAt   time.Time `json:"created_at"          gorm:"column:created_at"`    // 命中时间
+}
+
+// TableName implement gorm schema.Tabler
+func (ThreatMatch) TableName() string {
+	return "threat_match"
+}
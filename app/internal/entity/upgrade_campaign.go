@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+)
+
+type CampaignStatus uint8
+
+const (
+	// CSRunning 升级中
+	CSRunning CampaignStatus = iota + 1
+	// CSFinished 已完成
+	CSFinished
+	// CSCancelled 已取消
+	CSCancelled
+	// CSInterrupted 中断（manager 重启导致）
+	CSInterrupted
+)
+
+func (cs CampaignStatus) String() string {
+	switch cs {
+	case CSRunning:
+		return "升级中"
+	case CSFinished:
+		return "已完成"
+	case CSCancelled:
+		return "已取消"
+	case CSInterrupted:
+		return "已中断"
+	default:
+		return "未知"
+	}
+}
+
+// UpgradeCampaign 节点批量升级活动
+type UpgradeCampaign struct {
+	ID          int64          `json:"id,string"         gorm:"column:id;primaryKey"` // 活动 ID
+	Name        string         `json:"name"              gorm:"column:name"`          // 活动名称
+	BinaryID    int64          `json:"binary_id,string"  gorm:"column:binary_id"`     // 目标发行版 ID
+	Goos        string         `json:"goos"              gorm:"column:goos"`          // 目标操作系统
+	Arch        string         `json:"arch"              gorm:"column:arch"`          // 目标系统架构
+	Semver      model.Semver   `json:"semver"            gorm:"column:semver"`        // 目标版本
+	Filters     []byte         `json:"filters"           gorm:"column:filters"`       // 选择节点的 dynsql 条件（JSON）
+	Keyword     string         `json:"keyword"           gorm:"column:keyword"`       // 选择节点的关键字
+	BatchSize   int            `json:"batch_size"        gorm:"column:batch_size"`    // 每批次最多升级的节点数
+	BrokerLimit int            `json:"broker_limit"      gorm:"column:broker_limit"`  // 每批次每个 broker 最多升级的节点数
+	Timeout     time.Duration  `json:"timeout"           gorm:"column:timeout"`       // 单批次等待版本变化的超时时间
+	Status      CampaignStatus `json:"status"            gorm:"column:status"`        // 活动状态
+	Total       int            `json:"total"             gorm:"column:total"`         // 节点总数
+	Succeed     int            `json:"succeed"           gorm:"column:succeed"`       // 升级成功数
+	Failed      int            `json:"failed"            gorm:"column:failed"`        // 升级失败数
+	Timedout    int            `json:"timedout"          gorm:"column:timedout"`      // 升级超时数
+	CreatedID   int64          `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	FinishedAt  sql.NullTime   `json:"finished_at"       gorm:"column:finished_at"`   // 结束时间
+	CreatedAt   time.Time      `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt   time.Time      `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (UpgradeCampaign) TableName() string {
+	return "upgrade_campaign"
+}
+
+type CampaignItemStatus uint8
+
+const (
+	// CISPending 等待升级
+	CISPending CampaignItemStatus = iota + 1
+	// CISPushed 已下发升级指令，等待版本变化
+	CISPushed
+	// CISSucceed 升级成功
+	CISSucceed
+	// CISFailed 升级失败
+	CISFailed
+	// CISTimeout 升级超时
+	CISTimeout
+	// CISSkipped 跳过（已取消或已是目标版本）
+	CISSkipped
+)
+
+func (cis CampaignItemStatus) String() string {
+	switch cis {
+	case CISPending:
+		return "等待升级"
+	case CISPushed:
+		return "升级中"
+	case CISSucceed:
+		return "升级成功"
+	case CISFailed:
+		return "升级失败"
+	case CISTimeout:
+		return "升级超时"
+	case CISSkipped:
+		return "已跳过"
+	default:
+		return "未知"
+	}
+}
+
+// UpgradeCampaignItem 升级活动中每个节点的升级记录
+type UpgradeCampaignItem struct {
+	ID          int64              `json:"id,string"          gorm:"column:id;primaryKey"` // ID
+	CampaignID  int64              `json:"campaign_id,string" gorm:"column:campaign_id"`   // 活动 ID
+	MinionID    int64              `json:"minion_id,string"   gorm:"column:minion_id"`     // 节点 ID
+	Inet        string             `json:"inet"               gorm:"column:inet"`          // 节点 IP
+	BrokerID    int64              `json:"broker_id,string"   gorm:"column:broker_id"`     // broker ID
+	BrokerName  string             `json:"broker_name"        gorm:"column:broker_name"`   // broker 名字
+	FromEdition string             `json:"from_edition"       gorm:"column:from_edition"`  // 升级前的版本
+	ToEdition   string             `json:"to_edition"         gorm:"column:to_edition"`    // 升级后观察到的版本
+	Status      CampaignItemStatus `json:"status"             gorm:"column:status"`        // 升级状态
+	Reason      string             `json:"reason"             gorm:"column:reason"`        // 失败原因
+	PushedAt    sql.NullTime       `json:"pushed_at"          gorm:"column:pushed_at"`     // 下发升级指令时间
+	FinishedAt  sql.NullTime       `json:"finished_at"        gorm:"column:finished_at"`   // 结束时间
+	CreatedAt   time.Time          `json:"created_at"         gorm:"column:created_at"`    // 创建时间
+}
+
+// TableName implement gorm schema.Tabler
+func (UpgradeCampaignItem) TableName() string {
+	return "upgrade_campaign_item"
+}
+
+type UpgradeCampaignItems []*UpgradeCampaignItem
+
+// BrokerMap 整理为 key: brokerID; value: 升级记录
+func (items UpgradeCampaignItems) BrokerMap() map[int64]UpgradeCampaignItems {
+	ret := make(map[int64]UpgradeCampaignItems, 16)
+	for _, item := range items {
+		bid := item.BrokerID
+		ret[bid] = append(ret[bid], item)
+	}
+	return ret
+}
@@ -0,0 +1,97 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Webhook 订阅的事件主题
+const (
+	WebhookEvent  = "event"  // 新的安全事件
+	WebhookRisk   = "risk"   // 新的风险事件
+	WebhookTask   = "task"   // 配置下发任务执行完毕
+	WebhookMinion = "minion" // 节点状态变化
+	WebhookBroker = "broker" // broker 节点断开连接
+	WebhookTest   = "test"   // 测试推送
+)
+
+type WebhookStatus uint8
+
+const (
+	// WhsPending 等待推送
+	WhsPending WebhookStatus = iota + 1
+	// WhsSucceed 推送成功
+	WhsSucceed
+	// WhsFailed 超过最大重试次数后推送失败
+	WhsFailed
+)
+
+func (ws WebhookStatus) String() string {
+	switch ws {
+	case WhsPending:
+		return "等待推送"
+	case WhsSucceed:
+		return "推送成功"
+	case WhsFailed:
+		return "推送失败"
+	default:
+		return "未知"
+	}
+}
+
+// Webhook 外部系统的 webhook 订阅，推送内容由 Template 渲染，并使用 Secret 做 HMAC-SHA256 签名。
+type Webhook struct {
+	ID         int64     `json:"id,string"         gorm:"column:id;primaryKey"` // 订阅 ID
+	Name       string    `json:"name"              gorm:"column:name"`          // 名称
+	URL        string    `json:"url"               gorm:"column:url"`           // 推送地址
+	Secret     string    `json:"-"                 gorm:"column:secret"`        // 签名密钥，不返回给前端
+	Signed     bool      `json:"signed"            gorm:"-"`                    // 是否设置了签名密钥
+	Topics     Strings   `json:"topics"            gorm:"column:topics;json"`   // 订阅的事件主题
+	Template   string    `json:"template"          gorm:"column:template"`      // Go 模板，为空时推送默认的 JSON 格式
+	MaxRetries int       `json:"max_retries"       gorm:"column:max_retries"`   // 最大重试次数
+	Enabled    bool      `json:"enabled"           gorm:"column:enabled"`       // 是否启用
+	CreatedID  int64     `json:"created_id,string" gorm:"column:created_id"`    // 创建者 ID
+	CreatedAt  time.Time `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (Webhook) TableName() string {
+	return "webhook"
+}
+
+// WebhookCursor 按 ID 递增收集的事件主题（安全事件、风险事件）的收集进度，重启后从游标处继续收集。
+type WebhookCursor struct {
+	ID        int64     `json:"id,string"       gorm:"column:id;primaryKey"` // ID
+	Topic     string    `json:"topic"           gorm:"column:topic"`         // 事件主题
+	FloorID   int64     `json:"floor_id,string" gorm:"column:floor_id"`      // 重新开始收集时的数据 ID，回扫不早于此 ID
+	LastID    int64     `json:"last_id,string"  gorm:"column:last_id"`       // 已收集的最大数据 ID
+	UpdatedAt time.Time `json:"updated_at"      gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (WebhookCursor) TableName() string {
+	return "webhook_cursor"
+}
+
+// WebhookDelivery webhook 推送记录
+type WebhookDelivery struct {
+	ID         int64         `json:"id,string"         gorm:"column:id;primaryKey"` // 推送 ID
+	WebhookID  int64         `json:"webhook_id,string" gorm:"column:webhook_id"`    // 订阅 ID
+	Topic      string        `json:"topic"             gorm:"column:topic"`         // 事件主题
+	Payload    string        `json:"payload"           gorm:"column:payload"`       // 渲染后的推送内容
+	Status     WebhookStatus `json:"status"            gorm:"column:status"`        // 推送状态
+	Attempts   int           `json:"attempts"          gorm:"column:attempts"`      // 已推送次数
+	StatusCode int           `json:"status_code"       gorm:"column:status_code"`   // 最近一次推送的响应码
+	Response   string        `json:"response"          gorm:"column:response"`      // 最近一次推送的响应内容（截断）
+	Error      string        `json:"error"             gorm:"column:error"`         // 最近一次推送失败的原因
+	NextAt     sql.NullTime  `json:"next_at"           gorm:"column:next_at"`       // 下次推送时间
+	DoneAt     sql.NullTime  `json:"done_at"           gorm:"column:done_at"`       // 推送成功或最终失败的时间
+	CreatedAt  time.Time     `json:"created_at"        gorm:"column:created_at"`    // 创建时间
+	UpdatedAt  time.Time     `json:"updated_at"        gorm:"column:updated_at"`    // 更新时间
+}
+
+// TableName implement gorm schema.Tabler
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
@@ -0,0 +1,307 @@
+package intel
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatText = "txt"
+	FormatSTIX = "stix"
+)
+
+const (
+	TypeIP     = "ip"
+	TypeCIDR   = "cidr"
+	TypeDomain = "domain"
+	TypeURL    = "url"
+	TypeMD5    = "md5"
+	TypeSHA1   = "sha1"
+	TypeSHA256 = "sha256"
+)
+
+var ErrFormat = errors.New("不支持的情报文件格式")
+
+// Indicator 从情报文件中解析出的威胁指标，Confidence 与 ValidUntil 为零值时使用导入参数中的默认值。
+type Indicator struct {
+	Type        string
+	Value       string
+	Kind        string
+	Confidence  int
+	Description string
+	ValidUntil  time.Time
+}
+
+// Result 解析结果，Invalid 为无法识别的条目数量
+type Result struct {
+	Indicators []*Indicator
+	Invalid    int
+}
+
+// Parse 解析情报文件，不依赖任何网络资源。
+func Parse(format string, r io.Reader) (*Result, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatText:
+		return parseText(r)
+	case FormatSTIX:
+		return parseSTIX(r)
+	default:
+		return nil, ErrFormat
+	}
+}
+
+// Detect 根据文件扩展名猜测情报文件格式
+func Detect(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return FormatCSV
+	case strings.HasSuffix(lower, ".json"):
+		return FormatSTIX
+	case strings.HasSuffix(lower, ".txt"), strings.HasSuffix(lower, ".list"):
+		return FormatText
+	default:
+		return ""
+	}
+}
+
+var (
+	hexRegex    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	domainRegex = regexp.MustCompile(`^(?i:[a-z0-9_](?:[a-z0-9_-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+// Normalize 识别指标类型并规范化指标值，无法识别时返回空字符串。
+func Normalize(typ, value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", ""
+	}
+	if typ == "" {
+		typ = guess(value)
+	}
+
+	switch typ {
+	case TypeIP:
+		if ip := net.ParseIP(value); ip != nil {
+			return typ, ip.String()
+		}
+	case TypeCIDR:
+		if _, ipnet, err := net.ParseCIDR(value); err == nil {
+			return typ, ipnet.String()
+		}
+	case TypeDomain:
+		value = strings.TrimSuffix(strings.ToLower(value), ".")
+		if domainRegex.MatchString(value) {
+			return typ, value
+		}
+	case TypeURL:
+		if strings.Contains(value, "://") {
+			return typ, value
+		}
+	case TypeMD5, TypeSHA1, TypeSHA256:
+		sizes := map[string]int{TypeMD5: 32, TypeSHA1: 40, TypeSHA256: 64}
+		if len(value) == sizes[typ] && hexRegex.MatchString(value) {
+			return typ, strings.ToLower(value)
+		}
+	}
+
+	return "", ""
+}
+
+func guess(value string) string {
+	if net.ParseIP(value) != nil {
+		return TypeIP
+	}
+	if _, _, err := net.ParseCIDR(value); err == nil {
+		return TypeCIDR
+	}
+	if strings.Contains(value, "://") {
+		return TypeURL
+	}
+	if hexRegex.MatchString(value) {
+		switch len(value) {
+		case 32:
+			return TypeMD5
+		case 40:
+			return TypeSHA1
+		case 64:
+			return TypeSHA256
+		}
+	}
+	return TypeDomain
+}
+
+// parseText 每行一个指标，# 开头的行为注释
+func parseText(r io.Reader) (*Result, error) {
+	ret := new(Result)
+	scan := bufio.NewScanner(r)
+	scan.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scan.Scan() {
+		line := strings.TrimSpace(scan.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if typ, val := Normalize("", line); typ != "" {
+			ret.Indicators = append(ret.Indicators, &Indicator{Type: typ, Value: val})
+		} else {
+			ret.Invalid++
+		}
+	}
+
+	return ret, scan.Err()
+}
+
+// parseCSV 解析 CSV 情报文件，第一行必须是表头，value 列必须存在，
+// 可选列：type kind confidence description valid_until。
+func parseCSV(r io.Reader) (*Result, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		index[h] = i
+	}
+	if _, ok := index["value"]; !ok {
+		return nil, ErrFormat
+	}
+	column := func(record []string, name string) string {
+		if i, ok := index[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	ret := new(Result)
+	for {
+		record, exx := cr.Read()
+		if exx == io.EOF {
+			break
+		} else if exx != nil {
+			return nil, exx
+		}
+
+		typ, val := Normalize(strings.ToLower(column(record, "type")), column(record, "value"))
+		if typ == "" {
+			ret.Invalid++
+			continue
+		}
+		ind := &Indicator{
+			Type:        typ,
+			Value:       val,
+			Kind:        column(record, "kind"),
+			Description: column(record, "description"),
+		}
+		ind.Confidence, _ = strconv.Atoi(column(record, "confidence"))
+		if s := column(record, "valid_until"); s != "" {
+			ind.ValidUntil, _ = time.Parse(time.RFC3339, s)
+		}
+		ret.Indicators = append(ret.Indicators, ind)
+	}
+
+	return ret, nil
+}
+
+// stixPatternRegex 只支持 STIX 模式中简单的等值比较，如：[ipv4-addr:value = '1.2.3.4']
+var stixPatternRegex = regexp.MustCompile(`([a-z0-9-]+):((?i)[a-z0-9_.'\- ]+?)\s*=\s*'((?:[^'\\]|\\.)*)'`)
+
+var stixTypes = map[string]string{
+	"ipv4-addr:value":    TypeIP,
+	"ipv6-addr:value":    TypeIP,
+	"domain-name:value":  TypeDomain,
+	"url:value":          TypeURL,
+	"file:hashes.md5":    TypeMD5,
+	"file:hashes.sha1":   TypeSHA1,
+	"file:hashes.sha256": TypeSHA256,
+}
+
+// parseSTIX 解析 STIX 2.1 bundle 中的 indicator 对象
+func parseSTIX(r io.Reader) (*Result, error) {
+	var bundle struct {
+		Type    string `json:"type"`
+		Objects []struct {
+			Type        string    `json:"type"`
+			Name        string    `json:"name"`
+			Description string    `json:"description"`
+			Pattern     string    `json:"pattern"`
+			PatternType string    `json:"pattern_type"`
+			Confidence  int       `json:"confidence"`
+			Labels      []string  `json:"labels"`
+			Types       []string  `json:"indicator_types"`
+			ValidUntil  time.Time `json:"valid_until"`
+			Revoked     bool      `json:"revoked"`
+		} `json:"objects"`
+	}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Type != "bundle" {
+		return nil, ErrFormat
+	}
+
+	ret := new(Result)
+	for _, obj := range bundle.Objects {
+		if obj.Type != "indicator" || obj.Revoked {
+			continue
+		}
+		if obj.PatternType != "" && obj.PatternType != "stix" {
+			ret.Invalid++
+			continue
+		}
+		kind := strings.Join(obj.Types, ",")
+		if kind == "" {
+			kind = strings.Join(obj.Labels, ",")
+		}
+		desc := obj.Description
+		if desc == "" {
+			desc = obj.Name
+		}
+
+		matches := stixPatternRegex.FindAllStringSubmatch(obj.Pattern, -1)
+		if len(matches) == 0 {
+			ret.Invalid++
+			continue
+		}
+		for _, m := range matches {
+			// file:hashes.'SHA-256' 统一为 file:hashes.sha256
+			prop := strings.NewReplacer("'", "", "-", "", " ", "").Replace(strings.ToLower(m[2]))
+			path := m[1] + ":" + prop
+			// 不支持的属性（如 file:name process:name）不能交给 Normalize 猜测类型
+			want, ok := stixTypes[path]
+			if !ok {
+				ret.Invalid++
+				continue
+			}
+			typ, val := Normalize(want, strings.ReplaceAll(m[3], `\'`, `'`))
+			if typ == "" {
+				ret.Invalid++
+				continue
+			}
+			ret.Indicators = append(ret.Indicators, &Indicator{
+				Type:        typ,
+				Value:       val,
+				Kind:        kind,
+				Confidence:  obj.Confidence,
+				Description: desc,
+				ValidUntil:  obj.ValidUntil,
+			})
+		}
+	}
+
+	return ret, nil
+}
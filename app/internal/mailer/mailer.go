@@ -0,0 +1,136 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TLSNone     = "none"     // 不加密
+	TLSStartTLS = "starttls" // 明文连接后升级为 TLS，一般为 587 端口
+	TLSImplicit = "tls"      // 直接建立 TLS 连接，一般为 465 端口
+)
+
+// Server SMTP 服务器配置
+type Server struct {
+	Host     string
+	Port     int
+	TLSMode  string
+	Username string
+	Password string
+	Sender   string // 发件人地址，为空时使用 Username
+}
+
+// Message 邮件内容
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+	HTML    bool
+}
+
+// Send 通过 SMTP 服务器发送邮件
+func Send(ctx context.Context, srv Server, msg *Message) error {
+	addr := net.JoinHostPort(srv.Host, strconv.Itoa(srv.Port))
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	tlsCfg := &tls.Config{ServerName: srv.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if srv.TLSMode == TLSImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsCfg}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	cli, err := smtp.NewClient(conn, srv.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer cli.Close()
+
+	if srv.TLSMode == TLSStartTLS {
+		if err = cli.StartTLS(tlsCfg); err != nil {
+			return err
+		}
+	}
+	if srv.Username != "" {
+		if ok, _ := cli.Extension("AUTH"); ok {
+			auth := smtp.PlainAuth("", srv.Username, srv.Password, srv.Host)
+			if err = cli.Auth(auth); err != nil {
+				return err
+			}
+		}
+	}
+
+	sender := srv.Sender
+	if sender == "" {
+		sender = srv.Username
+	}
+	if err = cli.Mail(sender); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err = cli.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := cli.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(build(sender, msg)); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return cli.Quit()
+}
+
+func build(sender string, msg *Message) []byte {
+	contentType := "text/plain; charset=UTF-8"
+	if msg.HTML {
+		contentType = "text/html; charset=UTF-8"
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("From: " + (&mail.Address{Address: sender}).String() + "\r\n")
+	buf.WriteString("To: " + strings.Join(msg.To, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: " + contentType + "\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	// base64 每行最多 76 个字符
+	enc := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(enc) > 76 {
+		buf.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	buf.WriteString(enc + "\r\n")
+
+	return buf.Bytes()
+}
@@ -0,0 +1,26 @@
+package param
+
+import "github.com/vela-ssoc/vela-common-mb/dynsql"
+
+// Aggregate 通用的分组聚合查询，过滤条件与分组字段复用数据表的 dynsql 规范。
+type Aggregate struct {
+	dynsql.Input
+	Func     string `json:"func"     query:"func"     validate:"omitempty,oneof=count distinct"`      // 聚合方式，默认 count
+	Distinct string `json:"distinct" query:"distinct" validate:"required_if=Func distinct,lte=50"`    // 去重计数的字段，必须是可分组的字段
+	Bucket   string `json:"bucket"   query:"bucket"   validate:"omitempty,oneof=hour day week month"` // 时间分桶
+	Time     string `json:"time"     query:"time"     validate:"required_with=Bucket,lte=50"`         // 分桶使用的时间字段，必须是时间类型的过滤字段
+	Top      int    `json:"top"      query:"top"      validate:"gte=0,lte=100"`                       // 分组时只返回数量最多的前几项，默认 10
+}
+
+// AggregateResult 分组聚合结果
+type AggregateResult struct {
+	Total int64            `json:"total"` // 符合条件的总数，去重计数时为去重后的数量
+	Other int64            `json:"other"` // 计数且分组时，前几项以外的数量
+	Items []*AggregateItem `json:"items"`
+}
+
+type AggregateItem struct {
+	Bucket string `json:"bucket,omitempty" gorm:"column:bucket"` // 时间分桶
+	Name   string `json:"name,omitempty"   gorm:"column:name"`   // 分组的值
+	Value  int64  `json:"value"            gorm:"column:value"`  // 聚合结果
+}
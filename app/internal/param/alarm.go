@@ -0,0 +1,43 @@
+package param
+
+import "github.com/vela-ssoc/vela-manager/app/internal/entity"
+
+type AlertRuleFilter struct {
+	Field    string   `json:"field"    validate:"required,lte=50"`
+	Operator string   `json:"operator" validate:"oneof=eq ne contains prefix"`
+	Values   []string `json:"values"   validate:"gte=1,lte=100,dive,required,lte=255"`
+}
+
+type AlertRuleCreate struct {
+	Name        string                          `json:"name"         validate:"required,lte=50"`
+	Source      string                          `json:"source"       validate:"oneof=event risk logon minion"`
+	Filters     []*AlertRuleFilter              `json:"filters"      validate:"lte=20,dive"`
+	Condition   string                          `json:"condition"    validate:"oneof=threshold rate absence"`
+	Threshold   int                             `json:"threshold"    validate:"gte=0,lte=1000000"`
+	Ratio       int                             `json:"ratio"        validate:"gte=0,lte=100000"`
+	Window      int                             `json:"window"       validate:"gte=60,lte=604800"`
+	GroupBy     []string                        `json:"group_by"     validate:"lte=5,unique,dive,required"`
+	Dedupe      int                             `json:"dedupe"       validate:"gte=0,lte=604800"`
+	Severity    entity.AlertSeverity            `json:"severity"     validate:"oneof=1 2 3 4"`
+	SeverityMap map[string]entity.AlertSeverity `json:"severity_map" validate:"lte=20,dive,oneof=1 2 3 4"`
+	NotifierIDs entity.Int64s                   `json:"notifier_ids" validate:"lte=100,unique"`
+	Enabled     bool                            `json:"enabled"`
+	Comment     string                          `json:"comment"      validate:"lte=255"`
+}
+
+type AlertRuleUpdate struct {
+	IntID
+	AlertRuleCreate
+}
+
+type AlertPage struct {
+	Page
+	RuleID   int64                `json:"rule_id"  query:"rule_id"`
+	Status   entity.AlertStatus   `json:"status"   query:"status"   validate:"omitempty,oneof=1 2 3"`
+	Severity entity.AlertSeverity `json:"severity" query:"severity" validate:"omitempty,oneof=1 2 3 4"`
+}
+
+type AlertHandle struct {
+	IntID
+	Remark string `json:"remark" validate:"lte=255"`
+}
@@ -0,0 +1,67 @@
+package param
+
+import (
+	"mime/multipart"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+)
+
+type AttackImport struct {
+	File *multipart.FileHeader `json:"file" query:"file" form:"file" validate:"required"`
+}
+
+type AttackImportResult struct {
+	Tactics    int `json:"tactics"`    // 导入的战术数
+	Techniques int `json:"techniques"` // 导入的技术数
+}
+
+type AttackTechniquePage struct {
+	Page
+	Tactic string `json:"tactic" query:"tactic"` // 战术短名称
+}
+
+type AttackMappingPage struct {
+	Page
+	Field string `json:"field" query:"field" validate:"omitempty,oneof=risk_type from_code"`
+}
+
+type AttackMappingCreate struct {
+	Field      string   `json:"field"      validate:"oneof=risk_type from_code"`
+	Value      string   `json:"value"      validate:"required,lte=100"`
+	Techniques []string `json:"techniques" validate:"gte=1,lte=50,unique,dive,required,lte=20"`
+	Remark     string   `json:"remark"     validate:"lte=255"`
+}
+
+type AttackMappingUpdate struct {
+	ID int64 `json:"id,string" validate:"required"`
+	AttackMappingCreate
+}
+
+type AttackCoverage struct {
+	Days int `json:"days" query:"days" validate:"omitempty,gte=1,lte=90"` // 统计最近多少天，默认 30 天
+}
+
+// AttackCoverageResult ATT&CK 覆盖度报告
+type AttackCoverageResult struct {
+	Dates      []string             `json:"dates"`      // 日期
+	Hits       []*AttackHit         `json:"hits"`       // 命中的技术，按命中次数倒序
+	Undetected []*AttackTechniqueID `json:"undetected"` // 没有任何配置能检测到的技术
+}
+
+type AttackTechniqueID struct {
+	TechniqueID string   `json:"technique_id"`
+	Name        string   `json:"name"`
+	Tactics     []string `json:"tactics"`
+}
+
+type AttackHit struct {
+	AttackTechniqueID
+	Total  int64   `json:"total"`  // 命中总数
+	Counts []int64 `json:"counts"` // 每天的命中数，与 Dates 一一对应
+}
+
+// RiskItem 风险事件及其映射的 ATT&CK 技术
+type RiskItem struct {
+	model.Risk
+	Techniques []string `json:"techniques"`
+}
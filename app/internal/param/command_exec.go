@@ -0,0 +1,40 @@
+package param
+
+import (
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+)
+
+type CommandExecCreate struct {
+	dynsql.Input
+	Keyword string `json:"keyword" query:"keyword"`
+	Cmd     string `json:"cmd"     validate:"required,lte=255"`
+}
+
+func (ce CommandExecCreate) Like() string {
+	if ce.Keyword == "" {
+		return ""
+	}
+	return "%" + ce.Keyword + "%"
+}
+
+type CommandExecItemPage struct {
+	Page
+	IntID
+	Status entity.ExecItemStatus `json:"status" query:"status" validate:"omitempty,oneof=1 2 3"`
+	Digest string                `json:"digest" query:"digest"` // 按照输出聚合后查看节点
+}
+
+// CommandOutputGroup 相同输出的节点聚合
+type CommandOutputGroup struct {
+	Digest string `json:"digest" gorm:"column:digest"`
+	Output string `json:"output" gorm:"column:output"`
+	Count  int    `json:"count"  gorm:"column:count"`
+}
+
+// CommandExecReport 指令执行报告
+type CommandExecReport struct {
+	*entity.CommandExec
+	Pending int                   `json:"pending"` // 等待执行的节点数
+	Outputs []*CommandOutputGroup `json:"outputs"` // 执行成功的节点按照输出聚合
+}
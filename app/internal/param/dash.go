@@ -37,3 +37,23 @@ type DashRiskstsResp struct {
 	Processed   int `json:"processed"`   // 已处理
 	Ignore      int `json:"ignore"`      // 忽略
 }
+
+type DashTrend struct {
+	Metric    string `json:"metric"    query:"metric"    validate:"oneof=risk event logon online"`
+	Interval  string `json:"interval"  query:"interval"  validate:"omitempty,oneof=hour day week"`            // 默认按天统计
+	Dimension string `json:"dimension" query:"dimension" validate:"omitempty,oneof=broker idc ibu risk_type"` // 为空只统计总数
+	Size      int    `json:"size"      query:"size"      validate:"gte=0,lte=366"`                            // 最近多少个时间段，默认 hour-24 day-30 week-12
+	Top       int    `json:"top"       query:"top"       validate:"gte=0,lte=50"`                             // 细分时只展示数量最多的几项，其余合并为“其他”，默认 10
+}
+
+// DashTrendResp 趋势统计结果
+type DashTrendResp struct {
+	Times  []string           `json:"times"`  // 每个时间段的开始时间
+	Series []*DashTrendSeries `json:"series"` // 不细分时只有一条名为 total 的数据
+}
+
+type DashTrendSeries struct {
+	Name   string  `json:"name"`
+	Total  int64   `json:"total"`
+	Values []int64 `json:"values"` // 与 Times 一一对应
+}
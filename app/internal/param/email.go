@@ -0,0 +1,31 @@
+package param
+
+type EmailCreate struct {
+	Host     string `json:"host"     validate:"required,hostname|ip,lte=100"` // 邮箱服务器
+	Port     int    `json:"port"     validate:"gte=1,lte=65535"`              // 端口
+	TLSMode  string `json:"tls_mode" validate:"oneof=none starttls tls"`      // 加密方式
+	Username string `json:"username" validate:"required,lte=100"`             // 邮箱账号
+	Password string `json:"password" validate:"lte=200"`                      // 密码，修改时为空代表不修改
+	Sender   string `json:"sender"   validate:"omitempty,email,lte=100"`      // 发件人地址
+	Enable   bool   `json:"enable"`                                           // 是否启用
+}
+
+type EmailUpdate struct {
+	ID int64 `json:"id,string" validate:"required"`
+	EmailCreate
+}
+
+// EmailServer broker 发送邮件使用的邮件服务器配置，密码为解密后的明文。
+type EmailServer struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	TLSMode  string `json:"tls_mode"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Sender   string `json:"sender"`
+}
+
+type EmailTest struct {
+	ID int64  `json:"id,string" validate:"required"`
+	To string `json:"to"        validate:"required,email"`
+}
@@ -0,0 +1,42 @@
+package param
+
+import "github.com/vela-ssoc/vela-manager/app/internal/entity"
+
+// IncidentSetting 风险自动关联设置，Keys 相同且发生时间在 Window 秒之内的风险归入同一个事件。
+type IncidentSetting struct {
+	Enabled bool     `json:"enabled"`
+	Keys    []string `json:"keys"    validate:"gte=1,lte=4,unique,dive,oneof=remote_ip minion_id risk_type subject"`
+	Window  int      `json:"window"  validate:"gte=60,lte=604800"`
+}
+
+type IncidentPage struct {
+	Page
+	Status entity.IncidentStatus `json:"status" query:"status" validate:"omitempty,oneof=1 2 3"`
+}
+
+type IncidentStatus struct {
+	IntID
+	Status entity.IncidentStatus `json:"status" validate:"oneof=1 2 3"`
+	Reason string                `json:"reason" validate:"lte=255"`
+}
+
+type IncidentMerge struct {
+	IDs   Int64s `json:"ids"   validate:"gte=2,lte=100,unique"`
+	Title string `json:"title" validate:"lte=255"`
+}
+
+type IncidentSplit struct {
+	IntID
+	RiskIDs Int64s `json:"risk_ids" validate:"gte=1,lte=1000,unique"`
+	Title   string `json:"title"    validate:"required,lte=255"`
+}
+
+type IncidentRiskPage struct {
+	Page
+	IntID
+}
+
+type IncidentDetail struct {
+	Incident *entity.Incident           `json:"incident"`
+	Timeline []*entity.IncidentTimeline `json:"timeline"`
+}
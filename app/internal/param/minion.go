@@ -112,3 +112,8 @@ type MinionUpgradeRequest struct {
 	IntID
 	Semver model.Semver `json:"semver" validate:"omitempty,semver"`
 }
+
+type MinionExport struct {
+	MinionDeleteRequest
+	Format string `json:"format" query:"format" validate:"omitempty,oneof=csv xlsx ndjson"`
+}
@@ -0,0 +1,36 @@
+package param
+
+import "mime/multipart"
+
+type MinionImport struct {
+	File   *multipart.FileHeader `json:"file"    form:"file"    validate:"required"`
+	DryRun bool                  `json:"dry_run" form:"dry_run"` // 只校验不导入
+}
+
+// MinionImportRow 导入文件中的一行节点数据
+type MinionImportRow struct {
+	Row      int      `json:"row"`
+	Inet     string   `json:"inet"     validate:"ipv4"`
+	Goos     string   `json:"goos"     validate:"oneof=linux windows darwin"`
+	Arch     string   `json:"arch"     validate:"oneof=amd64 386 arm64 arm"`
+	IDC      string   `json:"idc"      validate:"lte=100"`
+	IBu      string   `json:"ibu"      validate:"lte=100"`
+	Category string   `json:"category" validate:"lte=100"`
+	Comment  string   `json:"comment"  validate:"lte=255"`
+	Tags     []string `json:"tags"     validate:"lte=20,unique,dive,tag"`
+	Reason   string   `json:"reason,omitempty"` // 校验失败原因，为空说明校验通过
+}
+
+// MinionImportReport 导入校验报告
+type MinionImportReport struct {
+	ID      int64              `json:"id,string,omitempty"` // 导入任务 ID，dry-run 时为空
+	Total   int                `json:"total"`               // 数据总行数
+	Valid   int                `json:"valid"`               // 校验通过的行数
+	Invalid int                `json:"invalid"`             // 校验失败的行数
+	Rows    []*MinionImportRow `json:"rows"`                // 每一行的校验结果
+}
+
+type MinionImportItemPage struct {
+	Page
+	IntID
+}
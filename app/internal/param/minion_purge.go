@@ -0,0 +1,13 @@
+package param
+
+import "github.com/vela-ssoc/vela-manager/app/internal/entity"
+
+type MinionDrop struct {
+	IDs Int64s `json:"ids" validate:"gte=1,lte=1000,unique"`
+}
+
+// MinionPurgeReport 清理任务报告
+type MinionPurgeReport struct {
+	*entity.MinionPurge
+	Steps []*entity.MinionPurgeStep `json:"steps"` // 每张数据表的清理结果
+}
@@ -1,6 +1,11 @@
 package param
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+)
 
 type NotifierCreate struct {
 	Name      string   `json:"name"       validate:"required,lte=20"`
@@ -39,3 +44,30 @@ type NotifierUpdate struct {
 	NotifierCreate
 	IntID
 }
+
+type NotifierTest struct {
+	IntID
+	Source string `json:"source" validate:"oneof=event risk"`
+}
+
+// NotifierDeliveryReport broker 发送告警通知后上报的发送结果
+type NotifierDeliveryReport struct {
+	Deliveries []*NotifierDeliveryResult `json:"deliveries" validate:"lte=1000,dive"`
+}
+
+type NotifierDeliveryResult struct {
+	NotifierID int64     `json:"notifier_id,string" validate:"required"`
+	Channel    string    `json:"channel"            validate:"oneof=dong email wechat sms call"`
+	Source     string    `json:"source"             validate:"required,lte=10"`
+	Subject    string    `json:"subject"`
+	Error      string    `json:"error"` // 发送失败的原因，为空代表发送成功
+	SentAt     time.Time `json:"sent_at"`
+}
+
+type NotifierDeliveryPage struct {
+	Page
+	NotifierID int64               `json:"notifier_id" query:"notifier_id"`
+	Channel    string              `json:"channel"     query:"channel"`
+	Performer  string              `json:"performer"   query:"performer"   validate:"omitempty,oneof=manager broker"`
+	Status     entity.NotifyStatus `json:"status"      query:"status"      validate:"omitempty,oneof=1 2"`
+}
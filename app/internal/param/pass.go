@@ -0,0 +1,55 @@
+package param
+
+import "time"
+
+type PassIPCreate struct {
+	IP       []string   `json:"ip"     validate:"gte=1,lte=100,unique,dive,ip"` // IP 地址
+	Kind     string     `json:"kind"   validate:"required,lte=20"`              // 数据维度
+	Reason   string     `json:"reason" validate:"lte=255"`                      // 加白原因
+	BeforeAt *time.Time `json:"before_at"`                                      // 有效期，为空代表永久有效
+}
+
+type PassIPUpdate struct {
+	ID       int64      `json:"id,string" validate:"required"`
+	IP       string     `json:"ip"        validate:"ip"`              // IP 地址
+	Kind     string     `json:"kind"      validate:"required,lte=20"` // 数据维度
+	Reason   string     `json:"reason"    validate:"lte=255"`         // 加白原因
+	BeforeAt *time.Time `json:"before_at"`                            // 有效期，为空代表永久有效
+}
+
+type PassDNSCreate struct {
+	Domain   []string   `json:"domain" validate:"gte=1,lte=100,unique,dive,required,lte=255"` // 域名
+	Kind     string     `json:"kind"   validate:"required,lte=20"`                            // 数据维度
+	Reason   string     `json:"reason" validate:"lte=255"`                                    // 加白原因
+	BeforeAt *time.Time `json:"before_at"`                                                    // 有效期，为空代表永久有效
+}
+
+type PassDNSUpdate struct {
+	ID       int64      `json:"id,string" validate:"required"`
+	Domain   string     `json:"domain"    validate:"required,lte=255"` // 域名
+	Kind     string     `json:"kind"      validate:"required,lte=20"`  // 数据维度
+	Reason   string     `json:"reason"    validate:"lte=255"`          // 加白原因
+	BeforeAt *time.Time `json:"before_at"`                             // 有效期，为空代表永久有效
+}
+
+// PassImport 文本批量导入白名单，每行一个 IP 或域名，# 开头的行为注释。
+type PassImport struct {
+	Kind     string     `json:"kind"   validate:"required,lte=20"`
+	Reason   string     `json:"reason" validate:"lte=255"`
+	BeforeAt *time.Time `json:"before_at"`
+	Text     string     `json:"text" validate:"required,lte=1048576"`
+	Update   bool       `json:"update"` // 已存在时更新原因与有效期，否则跳过
+}
+
+type PassImportResult struct {
+	Created int      `json:"created"` // 新增条数
+	Updated int      `json:"updated"` // 更新条数
+	Skipped int      `json:"skipped"` // 已存在跳过的条数
+	Invalid []string `json:"invalid"` // 无法识别的行（最多返回 100 条）
+}
+
+type PassChangelogPage struct {
+	Page
+	RuleID int64  `json:"rule_id" query:"rule_id"`
+	Action string `json:"action"  query:"action" validate:"omitempty,oneof=create update delete expire import"`
+}
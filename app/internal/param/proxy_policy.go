@@ -0,0 +1,51 @@
+package param
+
+import (
+	"time"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+)
+
+type ProxyPolicyCreate struct {
+	Name      string        `json:"name" validate:"required,lte=50"`
+	UserID    int64         `json:"user_id,string"`
+	Allow     bool          `json:"allow"`
+	Kinds     []string      `json:"kinds"      validate:"lte=4,unique,dive,oneof=brr arr bws aws"`
+	Tags      []string      `json:"tags"       validate:"lte=100,unique,dive,tag"`
+	IDCs      []string      `json:"idcs"       validate:"lte=100,unique,dive,required,lte=50"`
+	BrokerIDs entity.Int64s `json:"broker_ids" validate:"lte=100,unique"`
+	Paths     []string      `json:"paths"      validate:"lte=100,unique,dive,required,startswith=/,lte=255"`
+	Methods   []string      `json:"methods"    validate:"lte=20,unique,dive,required,uppercase,lte=20"`
+	Enabled   bool          `json:"enabled"`
+	ExpiredAt *time.Time    `json:"expired_at"`
+	Comment   string        `json:"comment" validate:"lte=255"`
+}
+
+type ProxyPolicyUpdate struct {
+	IntID
+	ProxyPolicyCreate
+}
+
+type ProxyDenialPage struct {
+	Page
+	UserID int64  `json:"user_id" query:"user_id"`
+	Kind   string `json:"kind"    query:"kind" validate:"omitempty,oneof=brr arr bws aws"`
+}
+
+// ProxyAccess 一次代理请求的访问信息
+type ProxyAccess struct {
+	Kind     string // 通道类型：brr arr bws aws
+	UserID   int64  // 用户 ID
+	MinionID int64  // agent 节点 ID，访问 broker 时为 0
+	BrokerID int64  // broker ID
+	Inet     string // agent 节点 IP
+	Method   string // 请求方法
+	Path     string // 去掉通道前缀后的请求路径
+	ClientIP string // 客户端 IP
+}
+
+// ProxyAuditSetting 代理审计设置
+type ProxyAuditSetting struct {
+	Enabled    bool `json:"enabled"`                                // 是否开启审计
+	SampleSize int  `json:"sample_size" validate:"gte=0,lte=65535"` // 响应报文采样字节数，0 代表不采样，最大为 TEXT 字段长度
+}
@@ -0,0 +1,18 @@
+package param
+
+type RetentionPolicyUpsert struct {
+	Table   string `json:"table"    validate:"oneof=risk event oplog minion_logon pass_dns pass_ip substance_task"`
+	MaxDays int    `json:"max_days" validate:"gte=0,lte=3650"`
+	MaxRows int64  `json:"max_rows" validate:"gte=0,lte=1000000000"`
+	Archive bool   `json:"archive"`
+	Enabled bool   `json:"enabled"`
+}
+
+type RetentionExecute struct {
+	Table string `json:"table" query:"table" validate:"oneof=risk event oplog minion_logon pass_dns pass_ip substance_task"`
+}
+
+type RetentionRunPage struct {
+	Page
+	Table string `json:"table" query:"table"`
+}
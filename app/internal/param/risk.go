@@ -1,6 +1,14 @@
 package param
 
-import "time"
+import (
+	"database/sql"
+	"mime/multipart"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+)
 
 type RiskAttack struct {
 	Subject  string `json:"subject"   gorm:"column:subject"`
@@ -65,3 +73,53 @@ type PieTopN struct {
 	TopN  []*NameCount `json:"topn"`
 	Other int          `json:"other"`
 }
+
+type RiskExport struct {
+	dynsql.Input
+	Format string `json:"format" query:"format" validate:"omitempty,oneof=csv xlsx"`
+}
+
+// RiskHandle 批量处理或忽略风险事件
+type RiskHandle struct {
+	dynsql.Input
+	Reason string `json:"reason" validate:"lte=255"`
+}
+
+// RiskCaseStatus 修改单个风险事件的状态，可以将已处理或已忽略的事件重新打开
+type RiskCaseStatus struct {
+	IntID
+	Status model.RiskStatus `json:"status" validate:"oneof=1 2 3"`
+	Reason string           `json:"reason" validate:"lte=255"`
+}
+
+type RiskAssign struct {
+	IDs        Int64s     `json:"ids"         validate:"gte=1,lte=1000,unique"`
+	AssigneeID int64      `json:"assignee_id" validate:"required"`
+	DueAt      *time.Time `json:"due_at"`
+}
+
+type RiskCommentCreate struct {
+	RiskID  int64                 `json:"risk_id" query:"risk_id" form:"risk_id" validate:"required"`
+	Content string                `json:"content" query:"content" form:"content" validate:"required,lte=2048"`
+	File    *multipart.FileHeader `json:"file"    query:"file"    form:"file"`
+}
+
+type RiskCaseDetail struct {
+	Risk        *model.Risk              `json:"risk"`
+	Case        *entity.RiskCase         `json:"case"`
+	Comments    []*entity.RiskComment    `json:"comments"`
+	Transitions []*entity.RiskTransition `json:"transitions"`
+}
+
+type RiskCasePage struct {
+	Page
+	Status   model.RiskStatus `json:"status"   query:"status"   validate:"omitempty,oneof=1 2 3"`
+	Breached bool             `json:"breached" query:"breached"`
+}
+
+type RiskCaseItem struct {
+	model.Risk
+	AssigneeID int64        `json:"assignee_id,string" gorm:"column:assignee_id"`
+	DueAt      sql.NullTime `json:"due_at"             gorm:"column:due_at"`
+	Breached   bool         `json:"breached"           gorm:"column:breached"`
+}
@@ -0,0 +1,15 @@
+package param
+
+// SessionRetention 会话录像保留设置
+type SessionRetention struct {
+	Enabled bool `json:"enabled"`                            // 是否开启录像
+	Days    int  `json:"days"     validate:"gte=1,lte=3650"` // 录像保留天数
+	MaxSize int  `json:"max_size" validate:"gte=1,lte=1024"` // 单个录像最大 MiB，超过后不再录制
+}
+
+type SessionRecordPage struct {
+	Page
+	Kind     string `json:"kind"      query:"kind" validate:"omitempty,oneof=aws bws"`
+	UserID   int64  `json:"user_id"   query:"user_id"`
+	MinionID int64  `json:"minion_id" query:"minion_id"`
+}
@@ -0,0 +1,24 @@
+package param
+
+import "github.com/vela-ssoc/vela-manager/app/internal/entity"
+
+type SiemForwarderCreate struct {
+	Name     string   `json:"name"     validate:"required,lte=50"`
+	Network  string   `json:"network"  validate:"oneof=udp tcp tls"`
+	Address  string   `json:"address"  validate:"required,hostname_port"`
+	Insecure bool     `json:"insecure"`
+	Format   string   `json:"format"   validate:"oneof=cef leef json"`
+	Sources  []string `json:"sources"  validate:"gte=1,unique,dive,oneof=risk event oplog"`
+	Facility int      `json:"facility" validate:"gte=0,lte=23"`
+	Enabled  bool     `json:"enabled"`
+}
+
+type SiemForwarderUpdate struct {
+	IntID
+	SiemForwarderCreate
+}
+
+type SiemForwarderItem struct {
+	*entity.SiemForwarder
+	Cursors []*entity.SiemCursor `json:"cursors"`
+}
@@ -4,3 +4,19 @@ type TagUpdate struct {
 	ID   int64    `json:"id,string" validate:"required"`
 	Tags []string `json:"tags"      validate:"gt=0,unique,dive,tag"`
 }
+
+type TagRename struct {
+	From string `json:"from" validate:"tag"`
+	To   string `json:"to"   validate:"tag,nefield=From"`
+}
+
+type TagDelete struct {
+	Tag string `json:"tag" query:"tag" validate:"tag"`
+}
+
+// TagAdminResult 标签管理操作结果
+type TagAdminResult struct {
+	TaskID  int64 `json:"task_id,string"` // 配置下发任务 ID，为 0 说明不需要重新下发配置
+	Minions int   `json:"minions"`        // 受影响的节点数
+	Effects int   `json:"effects"`        // 受影响的发布配置数
+}
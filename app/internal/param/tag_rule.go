@@ -0,0 +1,26 @@
+package param
+
+type TagRuleCondition struct {
+	Field    string   `json:"field"    validate:"required,lte=50"`
+	Operator string   `json:"operator" validate:"oneof=eq ne contains prefix cidr"`
+	Values   []string `json:"values"   validate:"gte=1,lte=100,dive,required,lte=255"`
+}
+
+type TagRuleCreate struct {
+	Name       string              `json:"name"       validate:"required,lte=50"`
+	Tag        string              `json:"tag"        validate:"tag"`
+	Conditions []*TagRuleCondition `json:"conditions" validate:"gte=1,lte=20,dive"`
+	Enabled    bool                `json:"enabled"`
+	Comment    string              `json:"comment"    validate:"lte=255"`
+}
+
+type TagRuleUpdate struct {
+	IntID
+	TagRuleCreate
+}
+
+// TagRuleApply 自动标签执行结果
+type TagRuleApply struct {
+	Scanned  int `json:"scanned"`  // 检查的节点数
+	Affected int `json:"affected"` // 标签发生变化的节点数
+}
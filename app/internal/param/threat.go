@@ -0,0 +1,31 @@
+package param
+
+import "mime/multipart"
+
+type ThreatImport struct {
+	Source     string                `json:"source"     query:"source"     form:"source"     validate:"required,lte=50"`
+	Format     string                `json:"format"     query:"format"     form:"format"     validate:"omitempty,oneof=csv txt stix"`
+	Kind       string                `json:"kind"       query:"kind"       form:"kind"       validate:"lte=50"`
+	Confidence int                   `json:"confidence" query:"confidence" form:"confidence" validate:"gte=0,lte=100"`
+	TTL        int                   `json:"ttl"        query:"ttl"        form:"ttl"        validate:"gte=0,lte=87600"` // 有效期（小时），0 代表永久有效
+	File       *multipart.FileHeader `json:"file"       query:"file"       form:"file"       validate:"required"`
+}
+
+// ThreatFeed 情报目录设置，定时导入目录中新增的情报文件
+type ThreatFeed struct {
+	Enabled    bool   `json:"enabled"`
+	Dir        string `json:"dir"        validate:"required_if=Enabled true,lte=255"`
+	Source     string `json:"source"     validate:"lte=50"`
+	Confidence int    `json:"confidence" validate:"gte=0,lte=100"`
+	TTL        int    `json:"ttl"        validate:"gte=0,lte=87600"`
+}
+
+type ThreatMatchPage struct {
+	Page
+	Target string `json:"target" query:"target" validate:"omitempty,oneof=risk pass_ip pass_dns"`
+	Unread bool   `json:"unread" query:"unread"`
+}
+
+type ThreatMatchAck struct {
+	IDs Int64s `json:"ids" validate:"lte=1000,unique"` // 为空代表全部标记为已查看
+}
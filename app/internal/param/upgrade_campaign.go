@@ -0,0 +1,44 @@
+package param
+
+import (
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+)
+
+type UpgradeCampaignCreate struct {
+	dynsql.Input
+	Keyword     string `json:"keyword"          query:"keyword"`
+	Name        string `json:"name"             validate:"required,lte=50"`
+	BinaryID    int64  `json:"binary_id,string" validate:"required,gt=0"`
+	BatchSize   int    `json:"batch_size"       validate:"gte=1,lte=1000"`  // 每批次最多升级的节点数
+	BrokerLimit int    `json:"broker_limit"     validate:"gte=1,lte=1000"`  // 每批次每个 broker 最多升级的节点数
+	Timeout     int    `json:"timeout"          validate:"gte=30,lte=3600"` // 每批次等待版本变化的超时秒数
+}
+
+func (uc UpgradeCampaignCreate) Like() string {
+	if uc.Keyword == "" {
+		return ""
+	}
+	return "%" + uc.Keyword + "%"
+}
+
+type UpgradeCampaignItemPage struct {
+	Page
+	IntID
+}
+
+type CampaignBrokerStat struct {
+	BrokerID   int64                     `json:"broker_id,string" gorm:"column:broker_id"`
+	BrokerName string                    `json:"broker_name"      gorm:"column:broker_name"`
+	Status     entity.CampaignItemStatus `json:"status"           gorm:"column:status"`
+	Count      int                       `json:"count"            gorm:"column:count"`
+}
+
+// UpgradeCampaignReport 升级活动报告
+type UpgradeCampaignReport struct {
+	*entity.UpgradeCampaign
+	Pending int                   `json:"pending"` // 等待升级的节点数
+	Pushed  int                   `json:"pushed"`  // 已下发指令等待确认的节点数
+	Skipped int                   `json:"skipped"` // 跳过的节点数
+	Brokers []*CampaignBrokerStat `json:"brokers"` // 按照 broker 分组统计
+}
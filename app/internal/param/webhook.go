@@ -0,0 +1,25 @@
+package param
+
+import "github.com/vela-ssoc/vela-manager/app/internal/entity"
+
+type WebhookCreate struct {
+	Name       string   `json:"name"        validate:"required,lte=50"`
+	URL        string   `json:"url"         validate:"required,http_url,lte=255"`
+	Secret     string   `json:"secret"      validate:"lte=100"` // 签名密钥，修改时为空代表不修改
+	Topics     []string `json:"topics"      validate:"gte=1,unique,dive,oneof=event risk task minion broker"`
+	Template   string   `json:"template"    validate:"lte=10000"`
+	MaxRetries int      `json:"max_retries" validate:"gte=0,lte=10"`
+	Enabled    bool     `json:"enabled"`
+}
+
+type WebhookUpdate struct {
+	IntID
+	WebhookCreate
+}
+
+type WebhookDeliveryPage struct {
+	Page
+	WebhookID int64                `json:"webhook_id" query:"webhook_id"`
+	Topic     string               `json:"topic"      query:"topic"`
+	Status    entity.WebhookStatus `json:"status"     query:"status"     validate:"omitempty,oneof=1 2 3"`
+}
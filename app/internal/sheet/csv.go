@@ -1,12 +1,41 @@
 package sheet
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"io"
 	"mime"
 )
 
+var ErrCSVTooLarge = errors.New("csv 文件过大")
+
+// ReadCSV 逐行读取 csv 文件，跳过 UTF-8 BOM，最多读取 maxRows 行，
+// 调用方可以根据返回的行数判断是否超出限制。
+func ReadCSV(r io.Reader, maxRows int) ([][]string, error) {
+	br := bufio.NewReader(newLimitReader(r, maxReadSize, ErrCSVTooLarge))
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+		_, _ = br.Discard(3)
+	}
+	rd := csv.NewReader(br)
+	rd.FieldsPerRecord = -1
+
+	records := make([][]string, 0, 64)
+	for len(records) < maxRows {
+		record, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
 type CSVReader interface {
 	UTF8BOM() bool
 	Filename() string
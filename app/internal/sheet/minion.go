@@ -5,25 +5,30 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"gorm.io/gorm"
 )
 
-func MinionCSV(ctx context.Context, limit int, bom bool) CSVReader {
+// MinionCSV 导出节点信息，db 为筛选节点 ID 的查询语句，查询结果需要按照节点 ID 升序排列。
+func MinionCSV(ctx context.Context, db *gorm.DB, limit int, bom bool) CSVReader {
 	return &minionCSVReader{
 		ctx:   ctx,
+		db:    db,
 		limit: limit,
 		bom:   bom,
 	}
 }
 
 type minionCSVReader struct {
-	ctx     context.Context
-	current int
-	limit   int
-	bom     bool
+	ctx    context.Context
+	db     *gorm.DB
+	lastID int64
+	limit  int
+	bom    bool
 }
 
 func (r *minionCSVReader) UTF8BOM() bool {
@@ -38,33 +43,63 @@ func (r *minionCSVReader) Filename() string {
 func (r *minionCSVReader) Header() []string {
 	return []string{
 		"ID", "IPv4", "操作系统", "系统架构", "版本", "状态", "代理节点",
-		"IDC", "部门", "业务类型", "备注", "运维负责人", "可登录帐号",
+		"IDC", "部门", "业务类型", "备注", "运维负责人", "可登录帐号", "标签",
+		"主机名", "发行版", "内核版本", "CPU型号", "CPU核数", "内存总量", "虚拟化",
 	}
 }
 
 func (r *minionCSVReader) Next() ([][]string, error) {
-	offset := r.current * r.limit
-	r.current++
+	// 按照 ID 游标分页，避免深度分页时 offset 越来越慢
+	var mids []int64
+	if err := r.db.WithContext(r.ctx).
+		Where("minion.id > ?", r.lastID).
+		Limit(r.limit).
+		Scan(&mids).Error; err != nil {
+		return nil, err
+	}
+	if len(mids) == 0 {
+		return nil, io.EOF
+	}
+	r.lastID = mids[len(mids)-1]
 
 	tbl := query.Minion
-	mons, _, err := tbl.WithContext(r.ctx).FindByPage(offset, r.limit)
+	mons, err := tbl.WithContext(r.ctx).
+		Where(tbl.ID.In(mids...)).
+		Order(tbl.ID).
+		Find()
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, io.EOF
-		}
 		return nil, err
 	}
-	if len(mons) == 0 {
-		return nil, io.EOF
+
+	tagMap := make(map[int64][]string, len(mids))
+	tagTbl := query.MinionTag
+	if tags, _ := tagTbl.WithContext(r.ctx).
+		Where(tagTbl.MinionID.In(mids...)).
+		Find(); len(tags) != 0 {
+		tagMap = model.MinionTags(tags).ToMap()
+	}
+	infoMap := make(map[int64]*model.SysInfo, len(mids))
+	infoTbl := query.SysInfo
+	if infos, _ := infoTbl.WithContext(r.ctx).
+		Where(infoTbl.ID.In(mids...)).
+		Find(); len(infos) != 0 {
+		infoMap = model.SysInfos(infos).ToMap()
 	}
 
 	records := make([][]string, 0, len(mons))
 	for _, mon := range mons {
 		id := strconv.FormatInt(mon.ID, 10)
 		status := mon.Status.String()
+		tags := strings.Join(tagMap[mon.ID], ",")
 		record := []string{
 			id, mon.Inet, mon.Goos, mon.Arch, mon.Edition, status, mon.BrokerName,
-			mon.IDC, mon.IBu, mon.Category, mon.Comment, mon.OpDuty, mon.Identity,
+			mon.IDC, mon.IBu, mon.Category, mon.Comment, mon.OpDuty, mon.Identity, tags,
+		}
+		if info := infoMap[mon.ID]; info != nil {
+			record = append(record, info.Hostname, info.Release, info.KernelVersion, info.CPUModel,
+				strconv.Itoa(info.CPUCore), strconv.Itoa(info.MemTotal), info.Virtual)
+		} else {
+			record = append(record, "", "", "", "", "", "", "")
 		}
 		records = append(records, record)
 	}
@@ -0,0 +1,97 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+)
+
+// NewNDJSON 每行输出一个 JSON 对象，对象的 key 为表头，顺序与表头一致。
+func NewNDJSON(rd CSVReader) CSVStreamer {
+	return &ndjsonStream{
+		read: rd,
+		buf:  new(bytes.Buffer),
+	}
+}
+
+type ndjsonStream struct {
+	read   CSVReader
+	eof    bool
+	keys   [][]byte
+	buf    *bytes.Buffer
+	header []string
+}
+
+func (ns *ndjsonStream) Read(p []byte) (int, error) {
+	if ns.read == nil || ns.eof {
+		return 0, io.EOF
+	}
+	if ns.keys == nil {
+		ns.header = ns.read.Header()
+		ns.keys = make([][]byte, 0, len(ns.header))
+		for _, h := range ns.header {
+			key, _ := json.Marshal(h)
+			ns.keys = append(ns.keys, key)
+		}
+	}
+
+	psz, wsz := len(p), 0
+	for wsz < psz {
+		if ns.buf.Len() == 0 {
+			next, err := ns.read.Next()
+			if err != nil {
+				ns.eof = true
+				if wsz != 0 {
+					return wsz, nil
+				}
+				return 0, err
+			}
+			for _, record := range next {
+				ns.writeRecord(record)
+			}
+			if ns.buf.Len() == 0 {
+				continue
+			}
+		}
+		n, err := ns.buf.Read(p[wsz:])
+		if err != nil {
+			return wsz, err
+		}
+		wsz += n
+	}
+
+	return wsz, nil
+}
+
+func (ns *ndjsonStream) writeRecord(record []string) {
+	ns.buf.WriteByte('{')
+	for i, key := range ns.keys {
+		if i != 0 {
+			ns.buf.WriteByte(',')
+		}
+		var val string
+		if i < len(record) {
+			val = record[i]
+		}
+		ns.buf.Write(key)
+		ns.buf.WriteByte(':')
+		raw, _ := json.Marshal(val)
+		ns.buf.Write(raw)
+	}
+	ns.buf.WriteString("}\n")
+}
+
+func (ns *ndjsonStream) MIME() string {
+	return "application/x-ndjson; charset=utf-8"
+}
+
+func (ns *ndjsonStream) Disposition() string {
+	name := "unnamed.ndjson"
+	if r := ns.read; r != nil {
+		if fn := r.Filename(); fn != "" {
+			name = replaceExt(fn, FormatNDJSON)
+		}
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
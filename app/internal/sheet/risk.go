@@ -0,0 +1,114 @@
+package sheet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"gorm.io/gorm"
+)
+
+// RiskCSV 导出风险事件，db 为已经拼接好筛选条件的 risk 表查询语句。
+func RiskCSV(ctx context.Context, db *gorm.DB, limit int, bom bool) CSVReader {
+	return &riskCSVReader{
+		ctx:   ctx,
+		db:    db,
+		limit: limit,
+		bom:   bom,
+	}
+}
+
+type riskCSVReader struct {
+	ctx    context.Context
+	db     *gorm.DB
+	lastID int64
+	limit  int
+	bom    bool
+}
+
+func (r *riskCSVReader) UTF8BOM() bool {
+	return r.bom
+}
+
+func (r *riskCSVReader) Filename() string {
+	at := time.Now().Format(time.RFC3339)
+	return fmt.Sprintf("risk-%s.csv", at)
+}
+
+func (r *riskCSVReader) Header() []string {
+	return []string{
+		"ID", "节点ID", "节点IP", "节点标签", "风险类型", "级别", "主题", "攻击载荷",
+		"本地IP", "本地端口", "外部IP", "外部端口", "归属地", "来源模块", "参考引用",
+		"状态", "产生时间", "入库时间",
+	}
+}
+
+func (r *riskCSVReader) Next() ([][]string, error) {
+	// 按照 ID 游标分页，避免深度分页时 offset 越来越慢
+	var risks []*model.Risk
+	if err := r.db.WithContext(r.ctx).
+		Where("id > ?", r.lastID).
+		Order("id").
+		Limit(r.limit).
+		Find(&risks).Error; err != nil {
+		return nil, err
+	}
+	if len(risks) == 0 {
+		return nil, io.EOF
+	}
+	r.lastID = risks[len(risks)-1].ID
+
+	mids := make([]int64, 0, len(risks))
+	uniq := make(map[int64]struct{}, len(risks))
+	for _, rsk := range risks {
+		if _, ok := uniq[rsk.MinionID]; ok || rsk.MinionID == 0 {
+			continue
+		}
+		uniq[rsk.MinionID] = struct{}{}
+		mids = append(mids, rsk.MinionID)
+	}
+
+	inetMap := make(map[int64]string, len(mids))
+	tagMap := make(map[int64][]string, len(mids))
+	if len(mids) != 0 {
+		monTbl := query.Minion
+		if mons, _ := monTbl.WithContext(r.ctx).
+			Select(monTbl.ID, monTbl.Inet).
+			Where(monTbl.ID.In(mids...)).
+			Find(); len(mons) != 0 {
+			for _, mon := range mons {
+				inetMap[mon.ID] = mon.Inet
+			}
+		}
+		tagTbl := query.MinionTag
+		if tags, _ := tagTbl.WithContext(r.ctx).
+			Where(tagTbl.MinionID.In(mids...)).
+			Find(); len(tags) != 0 {
+			tagMap = model.MinionTags(tags).ToMap()
+		}
+	}
+
+	records := make([][]string, 0, len(risks))
+	for _, rsk := range risks {
+		// 节点 IP 以节点表为准，节点已被删除时使用风险事件中记录的 IP
+		inet := inetMap[rsk.MinionID]
+		if inet == "" {
+			inet = rsk.Inet
+		}
+		record := []string{
+			strconv.FormatInt(rsk.ID, 10), strconv.FormatInt(rsk.MinionID, 10), inet,
+			strings.Join(tagMap[rsk.MinionID], ","), rsk.RiskType, rsk.Level.String(), rsk.Subject,
+			rsk.Payload, rsk.LocalIP, strconv.Itoa(rsk.LocalPort), rsk.RemoteIP,
+			strconv.Itoa(rsk.RemotePort), rsk.Region, rsk.FromCode, rsk.Reference,
+			rsk.Status.String(), rsk.OccurAt.Format(time.DateTime), rsk.CreatedAt.Format(time.DateTime),
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
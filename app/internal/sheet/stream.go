@@ -0,0 +1,29 @@
+package sheet
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+// NewStream 根据导出格式创建对应的流式输出，未知的格式按照 csv 输出。
+func NewStream(format string, rd CSVReader) CSVStreamer {
+	switch format {
+	case FormatXLSX:
+		return NewXLSX(rd)
+	case FormatNDJSON:
+		return NewNDJSON(rd)
+	default:
+		return NewCSV(rd)
+	}
+}
+
+// replaceExt 替换文件扩展名
+func replaceExt(name, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "." + ext
+}
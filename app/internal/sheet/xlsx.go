@@ -0,0 +1,252 @@
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrXLSXSheet    = errors.New("xlsx 文件中没有工作表")
+	ErrXLSXTooLarge = errors.New("xlsx 文件解压后过大")
+)
+
+// maxReadSize 读取的 csv 文件或 xlsx 单个压缩条目解压后的最大字节数，防止耗尽内存。
+const maxReadSize = 64 << 20
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string `xml:"r,attr"`
+		Type   string `xml:"t,attr"`
+		Value  string `xml:"v"`
+		Inline struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"is"`
+	} `xml:"c"`
+}
+
+// ReadXLSX 读取 xlsx 文件第一个工作表的内容，只支持文本与数字单元格。
+// 最多读取 maxRows 行，调用方可以根据返回的行数判断是否超出限制。
+func ReadXLSX(r io.ReaderAt, size int64, maxRows int) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheetName, err := xlsxFirstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := xlsxSharedStrings(files)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := files[sheetName]
+	if sheet == nil {
+		return nil, ErrXLSXSheet
+	}
+	rc, err := xlsxOpen(sheet)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer rc.Close()
+
+	// 逐行解析，达到行数限制后不再继续解压
+	records := make([][]string, 0, 64)
+	dec := xml.NewDecoder(rc)
+	for len(records) < maxRows {
+		tok, exx := dec.Token()
+		if exx == io.EOF {
+			break
+		} else if exx != nil {
+			return nil, exx
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if exx = dec.DecodeElement(&row, &se); exx != nil {
+			return nil, exx
+		}
+		records = append(records, xlsxRecord(row, shared))
+	}
+
+	return records, nil
+}
+
+func xlsxRecord(row xlsxRow, shared []string) []string {
+	record := make([]string, 0, len(row.Cells))
+	for i, cell := range row.Cells {
+		idx := i
+		if cell.Ref != "" {
+			idx = xlsxColumn(cell.Ref)
+		}
+		for len(record) < idx {
+			record = append(record, "")
+		}
+
+		var val string
+		switch cell.Type {
+		case "s":
+			n, _ := strconv.Atoi(cell.Value)
+			if n >= 0 && n < len(shared) {
+				val = shared[n]
+			}
+		case "inlineStr":
+			val = cell.Inline.Text
+			for _, run := range cell.Inline.Runs {
+				val += run.Text
+			}
+		default:
+			val = cell.Value
+		}
+		record = append(record, val)
+	}
+
+	return record
+}
+
+// xlsxFirstSheet 查找工作簿中第一个工作表的文件路径
+func xlsxFirstSheet(files map[string]*zip.File) (string, error) {
+	var wb struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relations []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	wf, rf := files["xl/workbook.xml"], files["xl/_rels/workbook.xml.rels"]
+	if wf == nil || rf == nil {
+		return "", ErrXLSXSheet
+	}
+	if err := xlsxDecode(wf, &wb); err != nil {
+		return "", err
+	}
+	if err := xlsxDecode(rf, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", ErrXLSXSheet
+	}
+
+	rid := wb.Sheets[0].RID
+	for _, rel := range rels.Relations {
+		if rel.ID != rid {
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			return strings.TrimPrefix(target, "/"), nil
+		}
+		return path.Join("xl", target), nil
+	}
+
+	return "", ErrXLSXSheet
+}
+
+func xlsxSharedStrings(files map[string]*zip.File) ([]string, error) {
+	f := files["xl/sharedStrings.xml"]
+	if f == nil {
+		return nil, nil
+	}
+	var sst struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xlsxDecode(f, &sst); err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, 0, len(sst.Items))
+	for _, item := range sst.Items {
+		text := item.Text
+		for _, run := range item.Runs {
+			text += run.Text
+		}
+		ret = append(ret, text)
+	}
+
+	return ret, nil
+}
+
+func xlsxDecode(f *zip.File, v any) error {
+	rc, err := xlsxOpen(f)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer rc.Close()
+
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// xlsxOpen 打开压缩条目，解压后超过 maxReadSize 字节时读取报错。
+func xlsxOpen(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > maxReadSize {
+		return nil, ErrXLSXTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	lr := newLimitReader(rc, maxReadSize, ErrXLSXTooLarge)
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: lr, Closer: rc}, nil
+}
+
+// limitReader 与 io.LimitReader 配合使用，超出限制时返回 err 而不是 io.EOF，
+// 避免截断的内容被当作完整的文件解析。
+type limitReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func newLimitReader(r io.Reader, n int64, err error) *limitReader {
+	return &limitReader{r: io.LimitReader(r, n+1), n: n, err: err}
+}
+
+func (lr *limitReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if lr.n -= int64(n); lr.n < 0 {
+		return 0, lr.err
+	}
+	return n, err
+}
+
+// xlsxColumn 将单元格引用（如 AB12）转换为从 0 开始的列序号
+func xlsxColumn(ref string) int {
+	var col int
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,156 @@
+package sheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"mime"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetBegin = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// NewXLSX 流式输出只有一个工作表的 xlsx 文件，单元格均使用内联字符串，
+// 无需在内存中维护共享字符串表。
+func NewXLSX(rd CSVReader) CSVStreamer {
+	buf := new(bytes.Buffer)
+	return &xlsxStream{
+		read: rd,
+		buf:  buf,
+		zw:   zip.NewWriter(buf),
+	}
+}
+
+type xlsxStream struct {
+	read  CSVReader
+	wrote bool
+	eof   bool
+	buf   *bytes.Buffer
+	zw    *zip.Writer
+	sheet io.Writer
+}
+
+func (xs *xlsxStream) Read(p []byte) (int, error) {
+	if xs.read == nil {
+		return 0, io.EOF
+	}
+	if !xs.wrote {
+		xs.wrote = true
+		if err := xs.begin(); err != nil {
+			return 0, err
+		}
+	}
+
+	psz, wsz := len(p), 0
+	for wsz < psz {
+		if xs.buf.Len() == 0 {
+			if xs.eof {
+				if wsz != 0 {
+					return wsz, nil
+				}
+				return 0, io.EOF
+			}
+			next, err := xs.read.Next()
+			if err != nil {
+				// 数据读取完毕，写入工作表结尾与 zip 目录
+				xs.eof = true
+				if _, exx := io.WriteString(xs.sheet, xlsxSheetEnd); exx != nil {
+					return wsz, exx
+				}
+				if exx := xs.zw.Close(); exx != nil {
+					return wsz, exx
+				}
+				if err != io.EOF {
+					return wsz, err
+				}
+				continue
+			}
+			for _, record := range next {
+				if err = xs.writeRow(record); err != nil {
+					return wsz, err
+				}
+			}
+			if xs.buf.Len() == 0 {
+				continue
+			}
+		}
+		n, err := xs.buf.Read(p[wsz:])
+		if err != nil {
+			return wsz, err
+		}
+		wsz += n
+	}
+
+	return wsz, nil
+}
+
+func (xs *xlsxStream) begin() error {
+	statics := [][2]string{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, st := range statics {
+		w, err := xs.zw.Create(st[0])
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, st[1]); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := xs.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	xs.sheet = sheet
+	if _, err = io.WriteString(sheet, xlsxSheetBegin); err != nil {
+		return err
+	}
+
+	return xs.writeRow(xs.read.Header())
+}
+
+func (xs *xlsxStream) writeRow(record []string) error {
+	row := new(bytes.Buffer)
+	row.WriteString("<row>")
+	for _, cell := range record {
+		row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(row, []byte(cell)); err != nil {
+			return err
+		}
+		row.WriteString("</t></is></c>")
+	}
+	row.WriteString("</row>")
+	_, err := xs.sheet.Write(row.Bytes())
+
+	return err
+}
+
+func (xs *xlsxStream) MIME() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (xs *xlsxStream) Disposition() string {
+	name := "unnamed.xlsx"
+	if r := xs.read; r != nil {
+		if fn := r.Filename(); fn != "" {
+			name = replaceExt(fn, FormatXLSX)
+		}
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name})
+}
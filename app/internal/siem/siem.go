@@ -0,0 +1,223 @@
+package siem
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCEF  = "cef"
+	FormatLEEF = "leef"
+	FormatJSON = "json"
+)
+
+const (
+	NetworkUDP = "udp"
+	NetworkTCP = "tcp"
+	NetworkTLS = "tls"
+)
+
+const (
+	vendor  = "vela-ssoc"
+	product = "manager"
+	version = "1.0"
+)
+
+var ErrFormat = errors.New("不支持的日志格式")
+
+// Field 扩展字段，保持字段顺序
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Record 待转发的一条数据
+type Record struct {
+	Source    string    // 数据来源：risk event oplog
+	ID        int64     // 数据 ID
+	Signature string    // 事件分类，如风险类型
+	Name      string    // 事件名称
+	Severity  int       // 严重程度 0-10
+	Time      time.Time // 事件发生时间
+	Fields    []Field   // 扩展字段
+}
+
+// Format 将数据格式化为 CEF LEEF 或 JSON 格式的消息
+func Format(format string, r *Record) (string, error) {
+	switch format {
+	case FormatCEF:
+		return cef(r), nil
+	case FormatLEEF:
+		return leef(r), nil
+	case FormatJSON:
+		return jsonl(r)
+	default:
+		return "", ErrFormat
+	}
+}
+
+var (
+	cefHeaderEscape = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefValueEscape  = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+	leefValueEscape = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+)
+
+// cef ArcSight Common Event Format：
+//
+//	CEF:0|Vendor|Product|Version|SignatureID|Name|Severity|Extension
+func cef(r *Record) string {
+	var sb strings.Builder
+	sb.WriteString("CEF:0|")
+	for _, s := range []string{vendor, product, version, r.Source + ":" + r.Signature, r.Name} {
+		sb.WriteString(cefHeaderEscape.Replace(s))
+		sb.WriteByte('|')
+	}
+	sb.WriteString(strconv.Itoa(r.Severity))
+	sb.WriteByte('|')
+	sb.WriteString("externalId=")
+	sb.WriteString(strconv.FormatInt(r.ID, 10))
+	sb.WriteString(" rt=")
+	sb.WriteString(strconv.FormatInt(r.Time.UnixMilli(), 10))
+	for _, f := range r.Fields {
+		if f.Value == "" {
+			continue
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(f.Key)
+		sb.WriteByte('=')
+		sb.WriteString(cefValueEscape.Replace(f.Value))
+	}
+
+	return sb.String()
+}
+
+// leef IBM QRadar Log Event Extended Format 1.0，扩展字段使用 TAB 分隔：
+//
+//	LEEF:1.0|Vendor|Product|Version|EventID|key=value	key=value
+func leef(r *Record) string {
+	var sb strings.Builder
+	sb.WriteString("LEEF:1.0|")
+	for _, s := range []string{vendor, product, version, r.Source + ":" + r.Signature} {
+		sb.WriteString(cefHeaderEscape.Replace(s))
+		sb.WriteByte('|')
+	}
+	sb.WriteString("devTime=")
+	sb.WriteString(r.Time.Format("Jan 02 2006 15:04:05.000 -07:00"))
+	sb.WriteString("\tdevTimeFormat=MMM dd yyyy HH:mm:ss.SSS z")
+	sb.WriteString("\tsev=")
+	sb.WriteString(strconv.Itoa(r.Severity))
+	sb.WriteString("\tname=")
+	sb.WriteString(leefValueEscape.Replace(r.Name))
+	sb.WriteString("\texternalId=")
+	sb.WriteString(strconv.FormatInt(r.ID, 10))
+	for _, f := range r.Fields {
+		if f.Value == "" {
+			continue
+		}
+		sb.WriteByte('\t')
+		sb.WriteString(f.Key)
+		sb.WriteByte('=')
+		sb.WriteString(leefValueEscape.Replace(f.Value))
+	}
+
+	return sb.String()
+}
+
+func jsonl(r *Record) (string, error) {
+	m := make(map[string]any, len(r.Fields)+6)
+	for _, f := range r.Fields {
+		m[f.Key] = f.Value
+	}
+	m["source"] = r.Source
+	m["id"] = strconv.FormatInt(r.ID, 10)
+	m["signature"] = r.Signature
+	m["name"] = r.Name
+	m["severity"] = r.Severity
+	m["time"] = r.Time
+	raw, err := json.Marshal(m)
+
+	return string(raw), err
+}
+
+// Syslog 按照 RFC 5424 封装消息，facility 默认为 local0。
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func Syslog(facility, severity int, hostname, msgID, msg string, at time.Time) string {
+	if hostname == "" {
+		hostname = "-"
+	}
+	if msgID == "" {
+		msgID = "-"
+	}
+	pri := facility*8 + Level(severity)
+
+	return "<" + strconv.Itoa(pri) + ">1 " + at.Format("2006-01-02T15:04:05.000000Z07:00") + " " + hostname + " " +
+		"ssoc-manager - " + msgID + " - " + msg
+}
+
+// Level 将 0-10 的严重程度转换为 syslog 的日志级别
+func Level(severity int) int {
+	switch {
+	case severity >= 9:
+		return 2 // critical
+	case severity >= 7:
+		return 3 // error
+	case severity >= 5:
+		return 4 // warning
+	case severity >= 3:
+		return 5 // notice
+	default:
+		return 6 // informational
+	}
+}
+
+// Writer syslog 发送端，TCP 与 TLS 使用 RFC 6587 octet-counting 分帧。
+type Writer struct {
+	network string
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// Dial 连接 syslog 接收端
+func Dial(network, address string, insecure bool, timeout time.Duration) (*Writer, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	switch network {
+	case NetworkUDP, NetworkTCP:
+		conn, err = dialer.Dial(network, address)
+	case NetworkTLS:
+		host, _, _ := net.SplitHostPort(address)
+		cfg := &tls.Config{ServerName: host, InsecureSkipVerify: insecure, MinVersion: tls.VersionTLS12}
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, cfg)
+	default:
+		return nil, errors.New("不支持的传输协议 " + network)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{network: network, conn: conn, timeout: timeout}, nil
+}
+
+// Write 发送一条 syslog 消息
+func (w *Writer) Write(msg string) error {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout))
+	if w.network == NetworkUDP {
+		_, err := w.conn.Write([]byte(msg))
+		return err
+	}
+
+	frame := strconv.Itoa(len(msg)) + " " + msg
+	_, err := w.conn.Write([]byte(frame))
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
@@ -18,6 +18,7 @@ func EffectTaskTx(_ context.Context, taskID int64, tags []string) (brokerIDs []i
 		tags:   tags,
 		limit:  100,
 		bids:   make([]int64, 0, 16),
+		bmap:   make(map[int64]struct{}, 16),
 	}
 
 	if err = query.Q.Transaction(et.Func); err != nil {
@@ -33,6 +34,7 @@ type effectTaskTx struct {
 	tags   []string
 	limit  int
 	bids   []int64
+	bmap   map[int64]struct{}
 }
 
 func (et *effectTaskTx) Func(tx *query.Query) error {
@@ -40,8 +42,6 @@ func (et *effectTaskTx) Func(tx *query.Query) error {
 	ctx := et.ctx
 	limit, offset := et.limit, 0
 	tagTbl := query.MinionTag
-	monTbl := query.Minion
-	bmap := make(map[int64]struct{}, 16)
 
 	for {
 		minionIDs := make([]int64, 0, limit)
@@ -62,45 +62,80 @@ func (et *effectTaskTx) Func(tx *query.Query) error {
 		}
 		offset += size
 
-		// 查询 broker_id 与 broker_name
-		minions, err := tx.Minion.WithContext(ctx).
-			Select(monTbl.ID, monTbl.Inet, monTbl.BrokerID, monTbl.BrokerName).
-			Where(monTbl.BrokerID.Neq(0)).
-			Where(monTbl.ID.In(minionIDs...)).
-			Find()
-		if err != nil {
+		if err = et.createTasks(tx, minionIDs, now); err != nil {
 			return err
 		}
+	}
 
-		num := len(minions)
-		if num == 0 {
-			break
-		}
+	return nil
+}
+
+// MinionTaskTx 为指定的节点创建配置下发任务，返回节点所在的 broker
+func MinionTaskTx(_ context.Context, taskID int64, minionIDs []int64) (brokerIDs []int64, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	et := &effectTaskTx{
+		ctx:    ctx,
+		taskID: taskID,
+		limit:  100,
+		bids:   make([]int64, 0, 16),
+		bmap:   make(map[int64]struct{}, 16),
+	}
 
-		tasks := make([]*model.SubstanceTask, 0, limit)
-		for _, mon := range minions {
-			bid := mon.BrokerID
-			if _, ok := bmap[bid]; !ok {
-				bmap[bid] = struct{}{}
-				et.bids = append(et.bids, bid)
+	now := time.Now()
+	err = query.Q.Transaction(func(tx *query.Query) error {
+		for len(minionIDs) != 0 {
+			size := et.limit
+			if size > len(minionIDs) {
+				size = len(minionIDs)
 			}
-			task := &model.SubstanceTask{
-				TaskID:     et.taskID,
-				MinionID:   mon.ID,
-				Inet:       mon.Inet,
-				BrokerID:   bid,
-				BrokerName: mon.BrokerName,
-				CreatedAt:  now,
-				UpdatedAt:  now,
+			if exx := et.createTasks(tx, minionIDs[:size], now); exx != nil {
+				return exx
 			}
-			tasks = append(tasks, task)
+			minionIDs = minionIDs[size:]
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if err = tx.WithContext(ctx).SubstanceTask.
-			CreateInBatches(tasks, limit); err != nil {
-			return err
+	return et.bids, nil
+}
+
+func (et *effectTaskTx) createTasks(tx *query.Query, minionIDs []int64, now time.Time) error {
+	ctx := et.ctx
+	monTbl := query.Minion
+	// 查询 broker_id 与 broker_name
+	minions, err := tx.Minion.WithContext(ctx).
+		Select(monTbl.ID, monTbl.Inet, monTbl.BrokerID, monTbl.BrokerName).
+		Where(monTbl.BrokerID.Neq(0)).
+		Where(monTbl.ID.In(minionIDs...)).
+		Find()
+	if err != nil || len(minions) == 0 {
+		return err
+	}
+
+	tasks := make([]*model.SubstanceTask, 0, len(minions))
+	for _, mon := range minions {
+		bid := mon.BrokerID
+		if _, ok := et.bmap[bid]; !ok {
+			et.bmap[bid] = struct{}{}
+			et.bids = append(et.bids, bid)
+		}
+		task := &model.SubstanceTask{
+			TaskID:     et.taskID,
+			MinionID:   mon.ID,
+			Inet:       mon.Inet,
+			BrokerID:   bid,
+			BrokerName: mon.BrokerName,
+			CreatedAt:  now,
+			UpdatedAt:  now,
 		}
+		tasks = append(tasks, task)
 	}
 
-	return nil
+	return tx.WithContext(ctx).SubstanceTask.
+		CreateInBatches(tasks, et.limit)
 }
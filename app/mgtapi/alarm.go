@@ -0,0 +1,124 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Alarm(svc service.AlarmService) route.Router {
+	return &alarmREST{
+		svc: svc,
+	}
+}
+
+type alarmREST struct {
+	svc service.AlarmService
+}
+
+func (rest *alarmREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/alarm/rules").Data(route.Ignore()).GET(rest.Rules)
+	bearer.Route("/alarm/rule/fields").Data(route.Ignore()).GET(rest.Fields)
+	bearer.Route("/alarm/rule").
+		Data(route.Named("新增告警规则")).POST(rest.CreateRule).
+		Data(route.Named("修改告警规则")).PUT(rest.UpdateRule).
+		Data(route.Named("删除告警规则")).DELETE(rest.DeleteRule)
+	bearer.Route("/alarms").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/alarm/ack").Data(route.Named("确认告警")).PATCH(rest.Ack)
+	bearer.Route("/alarm/resolve").Data(route.Named("解决告警")).PATCH(rest.Resolve)
+}
+
+func (rest *alarmREST) Rules(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Rules(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *alarmREST) Fields(c *ship.Context) error {
+	res := rest.svc.Fields()
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *alarmREST) CreateRule(c *ship.Context) error {
+	var req param.AlertRuleCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.CreateRule(ctx, &req, cu.ID)
+}
+
+func (rest *alarmREST) UpdateRule(c *ship.Context) error {
+	var req param.AlertRuleUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.UpdateRule(ctx, &req)
+}
+
+func (rest *alarmREST) DeleteRule(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.DeleteRule(ctx, req.ID)
+}
+
+func (rest *alarmREST) Page(c *ship.Context) error {
+	var req param.AlertPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *alarmREST) Ack(c *ship.Context) error {
+	var req param.AlertHandle
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Ack(ctx, &req, cu.ID)
+}
+
+func (rest *alarmREST) Resolve(c *ship.Context) error {
+	var req param.AlertHandle
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Resolve(ctx, &req, cu.ID)
+}
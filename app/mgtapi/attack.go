@@ -0,0 +1,141 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Attack(svc service.AttackService) route.Router {
+	return &attackREST{
+		svc: svc,
+	}
+}
+
+type attackREST struct {
+	svc service.AttackService
+}
+
+func (rest *attackREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/attack/tactics").Data(route.Ignore()).GET(rest.Tactics)
+	bearer.Route("/attack/techniques").Data(route.Ignore()).GET(rest.Techniques)
+	bearer.Route("/attack/import").Data(route.Named("导入 ATT&CK 数据")).POST(rest.Import)
+	bearer.Route("/attack/mappings").Data(route.Ignore()).GET(rest.Mappings)
+	bearer.Route("/attack/mapping").
+		Data(route.Named("新增 ATT&CK 映射")).POST(rest.CreateMapping).
+		Data(route.Named("修改 ATT&CK 映射")).PUT(rest.UpdateMapping).
+		Data(route.Named("删除 ATT&CK 映射")).DELETE(rest.DeleteMapping)
+	bearer.Route("/attack/coverage").Data(route.Ignore()).GET(rest.Coverage)
+}
+
+func (rest *attackREST) Tactics(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Tactics(ctx)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *attackREST) Techniques(c *ship.Context) error {
+	var req param.AttackTechniquePage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Techniques(ctx, page, &req)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *attackREST) Import(c *ship.Context) error {
+	var req param.AttackImport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	file, err := req.File.Open()
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Import(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *attackREST) Mappings(c *ship.Context) error {
+	var req param.AttackMappingPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Mappings(ctx, page, &req)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *attackREST) CreateMapping(c *ship.Context) error {
+	var req param.AttackMappingCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.CreateMapping(ctx, &req, cu.ID)
+}
+
+func (rest *attackREST) UpdateMapping(c *ship.Context) error {
+	var req param.AttackMappingUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.UpdateMapping(ctx, &req, cu.ID)
+}
+
+func (rest *attackREST) DeleteMapping(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.DeleteMapping(ctx, req.ID)
+}
+
+func (rest *attackREST) Coverage(c *ship.Context) error {
+	var req param.AttackCoverage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	days := req.Days
+	if days == 0 {
+		days = 30
+	}
+
+	ctx := c.Request().Context()
+	res := rest.svc.Coverage(ctx, days)
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -19,6 +19,7 @@ type cmdbREST struct {
 
 func (rest *cmdbREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/cmdb").Data(route.Ignore()).GET(rest.Detail)
+	bearer.Route("/cmdb/refresh").Data(route.Named("刷新节点 CMDB 信息")).PATCH(rest.Refresh)
 }
 
 func (rest *cmdbREST) Detail(c *ship.Context) error {
@@ -32,3 +33,14 @@ func (rest *cmdbREST) Detail(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *cmdbREST) Refresh(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Refresh(ctx, req.ID)
+}
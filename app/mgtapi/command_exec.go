@@ -0,0 +1,95 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func CommandExec(svc service.CommandExecService) route.Router {
+	return &commandExecREST{
+		svc:    svc,
+		filter: newMinionFilter(),
+	}
+}
+
+type commandExecREST struct {
+	svc    service.CommandExecService
+	filter *minionFilter
+}
+
+func (rest *commandExecREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/command/execs").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/command/exec").
+		Data(route.Ignore()).GET(rest.Report).
+		Data(route.Named("批量执行节点指令")).POST(rest.Create)
+	bearer.Route("/command/exec/items").Data(route.Ignore()).GET(rest.Items)
+}
+
+func (rest *commandExecREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *commandExecREST) Create(c *ship.Context) error {
+	var req param.CommandExecCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	scope, likes, err := rest.filter.inter(req.Input, req.Like())
+	if err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	id, err := rest.svc.Create(ctx, &req, scope, likes, cu.ID)
+	if err != nil {
+		return err
+	}
+	res := &param.IntID{ID: id}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *commandExecREST) Report(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Report(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *commandExecREST) Items(c *ship.Context) error {
+	var req param.CommandExecItemPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Items(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -26,6 +26,7 @@ func (rest *dashREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/dash/evtlvl").Data(route.Ignore()).GET(rest.Evtlvl)
 	bearer.Route("/dash/risklvl").Data(route.Ignore()).GET(rest.Risklvl)
 	bearer.Route("/dash/risksts").Data(route.Ignore()).GET(rest.Risksts)
+	bearer.Route("/dash/trend").Data(route.Ignore()).GET(rest.Trend)
 }
 
 func (rest *dashREST) Status(c *ship.Context) error {
@@ -67,3 +68,18 @@ func (rest *dashREST) Risksts(c *ship.Context) error {
 	res := rest.svc.Risksts(ctx)
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *dashREST) Trend(c *ship.Context) error {
+	var req param.DashTrend
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Trend(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -21,6 +21,11 @@ type emailREST struct {
 
 func (rest *emailREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/emails").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/email").
+		Data(route.Named("新增邮件服务器")).POST(rest.Create).
+		Data(route.Named("修改邮件服务器")).PUT(rest.Update).
+		Data(route.Named("删除邮件服务器")).DELETE(rest.Delete)
+	bearer.Route("/email/test").Data(route.Named("发送测试邮件")).POST(rest.Test)
 }
 
 func (rest *emailREST) Page(c *ship.Context) error {
@@ -36,3 +41,47 @@ func (rest *emailREST) Page(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *emailREST) Create(c *ship.Context) error {
+	var req param.EmailCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req)
+}
+
+func (rest *emailREST) Update(c *ship.Context) error {
+	var req param.EmailUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req)
+}
+
+func (rest *emailREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+func (rest *emailREST) Test(c *ship.Context) error {
+	var req param.EmailTest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Test(ctx, &req)
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/xgfone/ship/v5"
 )
 
-func Event(svc service.EventService) route.Router {
+func Event(svc service.EventService, agg service.AggregateService) route.Router {
 	levels := []string{
 		model.ELvlCritical.String(),
 		model.ELvlMajor.String(),
@@ -21,21 +21,29 @@ func Event(svc service.EventService) route.Router {
 		model.ELvlNote.String(),
 	}
 	levelEnums := dynsql.StringEnum().Sames(levels)
+	levelCol := dynsql.StringColumn("level", "级别").Enums(levelEnums).Build()
+	inetCol := dynsql.StringColumn("inet", "终端 IP").Build()
+	fromCodeCol := dynsql.StringColumn("from_code", "来源").Build()
+	subjectCol := dynsql.StringColumn("subject", "主题").Build()
+	typeofCol := dynsql.StringColumn("typeof", "类型").Build()
+	remoteAddrCol := dynsql.StringColumn("remote_addr", "远端地址").Build()
+	userCol := dynsql.StringColumn("user", "用户").Build()
+	regionCol := dynsql.StringColumn("region", "IP归属地").Build()
 	filters := []dynsql.Column{
-		dynsql.StringColumn("level", "级别").Enums(levelEnums).Build(),
-		dynsql.StringColumn("inet", "终端 IP").Build(),
+		levelCol,
+		inetCol,
 		dynsql.IntColumn("minion_id", "终端 ID").Build(),
-		dynsql.StringColumn("from_code", "来源").Build(),
+		fromCodeCol,
 		dynsql.TimeColumn("occur_at", "时间").Build(),
-		dynsql.StringColumn("subject", "主题").Build(),
-		dynsql.StringColumn("typeof", "类型").Build(),
-		dynsql.StringColumn("remote_addr", "远端地址").Build(),
+		subjectCol,
+		typeofCol,
+		remoteAddrCol,
 		dynsql.IntColumn("remote_port", "远端端口").Build(),
-		dynsql.StringColumn("user", "用户").Build(),
+		userCol,
 		dynsql.StringColumn("auth", "授权信息").Build(),
 		dynsql.StringColumn("msg", "信息").Build(),
 		dynsql.StringColumn("error", "错误信息").Build(),
-		dynsql.StringColumn("region", "IP归属地").Build(),
+		regionCol,
 		dynsql.BoolColumn("send_alert", "是否发送告警").
 			Enums(dynsql.BoolEnum().True("是").False("否")).Build(),
 		dynsql.TimeColumn("created_at", "创建时间").Build(),
@@ -44,21 +52,25 @@ func Event(svc service.EventService) route.Router {
 
 	table := dynsql.Builder().
 		Filters(filters...).
+		Groups(levelCol, inetCol, fromCodeCol, subjectCol, typeofCol, remoteAddrCol, userCol, regionCol).
 		Build()
 	return &eventREST{
 		svc:   svc,
+		agg:   agg,
 		table: table,
 	}
 }
 
 type eventREST struct {
 	svc   service.EventService
+	agg   service.AggregateService
 	table dynsql.Table
 }
 
 func (rest *eventREST) Route(nona, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/event/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/events").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/event/aggregate").Data(route.Ignore()).GET(rest.Aggregate)
 	bearer.Route("/event/confirm").
 		Data(route.Named("忽略事件")).DELETE(rest.Confirm)
 	bearer.Route("/event").
@@ -129,3 +141,22 @@ func (rest *eventREST) HTML(c *ship.Context) error {
 
 	return c.Stream(http.StatusOK, ship.MIMETextHTMLCharsetUTF8, buf)
 }
+
+func (rest *eventREST) Aggregate(c *ship.Context) error {
+	var req param.Aggregate
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.agg.Aggregate(ctx, "event", rest.table.Schema(), scope, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
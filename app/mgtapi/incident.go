@@ -0,0 +1,130 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Incident(svc service.IncidentService) route.Router {
+	return &incidentREST{
+		svc: svc,
+	}
+}
+
+type incidentREST struct {
+	svc service.IncidentService
+}
+
+func (rest *incidentREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/incidents").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/incident").Data(route.Ignore()).GET(rest.Detail)
+	bearer.Route("/incident/risks").Data(route.Ignore()).GET(rest.Risks)
+	bearer.Route("/incident/status").Data(route.Named("修改安全事件状态")).PATCH(rest.Status)
+	bearer.Route("/incident/merge").Data(route.Named("合并安全事件")).PATCH(rest.Merge)
+	bearer.Route("/incident/split").Data(route.Named("拆分安全事件")).PATCH(rest.Split)
+	bearer.Route("/incident/setting").
+		Data(route.Ignore()).GET(rest.Setting).
+		Data(route.Named("修改风险关联设置")).PUT(rest.SetSetting)
+}
+
+func (rest *incidentREST) Page(c *ship.Context) error {
+	var req param.IncidentPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *incidentREST) Detail(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Detail(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *incidentREST) Risks(c *ship.Context) error {
+	var req param.IncidentRiskPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Risks(ctx, req.ID, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *incidentREST) Status(c *ship.Context) error {
+	var req param.IncidentStatus
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Status(ctx, &req, cu.ID)
+}
+
+func (rest *incidentREST) Merge(c *ship.Context) error {
+	var req param.IncidentMerge
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Merge(ctx, &req, cu.ID)
+}
+
+func (rest *incidentREST) Split(c *ship.Context) error {
+	var req param.IncidentSplit
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Split(ctx, &req, cu.ID)
+}
+
+func (rest *incidentREST) Setting(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Setting(ctx)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *incidentREST) SetSetting(c *ship.Context) error {
+	var req param.IncidentSetting
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.SetSetting(ctx, &req)
+}
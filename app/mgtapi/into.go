@@ -2,9 +2,13 @@ package mgtapi
 
 import (
 	"net/http"
+	"path"
+	"strings"
 
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/app/route"
 	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
 	"github.com/vela-ssoc/vela-manager/bridge/linkhub"
 	"github.com/vela-ssoc/vela-manager/errcode"
 	"github.com/xgfone/ship/v5"
@@ -47,11 +51,15 @@ func (ito *intoREST) BRR(c *ship.Context) error {
 		return errcode.ErrRequiredNode
 	}
 
+	acc, err := ito.access(c, "brr")
+	if err != nil {
+		return err
+	}
 	w, r := c.Response(), c.Request()
 	ctx := r.Context()
 	ito.desensitization(r)
 
-	return ito.svc.BRR(ctx, w, r, node)
+	return ito.svc.BRR(ctx, w, r, node, acc)
 }
 
 func (ito *intoREST) BWS(c *ship.Context) error {
@@ -64,10 +72,14 @@ func (ito *intoREST) BWS(c *ship.Context) error {
 		return errcode.ErrRequiredNode
 	}
 
+	acc, err := ito.access(c, "bws")
+	if err != nil {
+		return err
+	}
 	w, r := c.Response(), c.Request()
 	ctx := r.Context()
 
-	return ito.svc.BWS(ctx, w, r, node)
+	return ito.svc.BWS(ctx, w, r, node, acc)
 }
 
 func (ito *intoREST) ARR(c *ship.Context) error {
@@ -80,11 +92,15 @@ func (ito *intoREST) ARR(c *ship.Context) error {
 		return errcode.ErrRequiredNode
 	}
 
+	acc, err := ito.access(c, "arr")
+	if err != nil {
+		return err
+	}
 	w, r := c.Response(), c.Request()
 	ctx := r.Context()
 	ito.desensitization(r)
 
-	return ito.svc.ARR(ctx, w, r, node)
+	return ito.svc.ARR(ctx, w, r, node, acc)
 }
 
 func (ito *intoREST) AWS(c *ship.Context) error {
@@ -97,10 +113,60 @@ func (ito *intoREST) AWS(c *ship.Context) error {
 		return errcode.ErrRequiredNode
 	}
 
+	acc, err := ito.access(c, "aws")
+	if err != nil {
+		return err
+	}
 	w, r := c.Response(), c.Request()
 	ctx := r.Context()
 
-	return ito.svc.AWS(ctx, w, r, node)
+	return ito.svc.AWS(ctx, w, r, node, acc)
+}
+
+// access 整理代理请求的访问信息，用于检查访问策略
+func (ito *intoREST) access(c *ship.Context, kind string) (*param.ProxyAccess, error) {
+	pth, err := ito.cleanPath(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cu := session.Cast(c.Any)
+	acc := &param.ProxyAccess{
+		Kind:     kind,
+		UserID:   cu.ID,
+		Method:   c.Request().Method,
+		Path:     pth,
+		ClientIP: c.ClientIP(),
+	}
+
+	return acc, nil
+}
+
+// cleanPath 清理代理路径，防止通过 /allowed/../denied 或 //denied 绕过访问策略的前缀匹配。
+// 转发的请求路径也替换为清理后的路径，保证检查与转发的路径一致。
+func (ito *intoREST) cleanPath(c *ship.Context) (string, error) {
+	r := c.Request()
+	raw := c.Param("path")
+	if !strings.HasSuffix(r.URL.Path, raw) {
+		return "", errcode.ErrProxyPath
+	}
+	pth := path.Clean(strings.TrimLeft(raw, "/"))
+	if pth == "." {
+		pth = ""
+	}
+	if strings.Contains(pth, "..") {
+		return "", errcode.ErrProxyPath
+	}
+	pth = "/" + pth
+	if pth != "/" && strings.HasSuffix(raw, "/") {
+		pth += "/"
+	}
+
+	prefix := strings.TrimSuffix(strings.TrimSuffix(r.URL.Path, raw), "/")
+	r.URL.Path = prefix + pth
+	r.URL.RawPath = ""
+
+	return pth, nil
 }
 
 func (ito *intoREST) lookupNode(c *ship.Context) (node string) {
@@ -16,89 +16,17 @@ import (
 )
 
 func Minion(hub linkhub.Huber, svc service.MinionService) route.Router {
-	const (
-		idKey         = "minion.id"
-		tagKey        = "minion_tag.tag"
-		inetKey       = "minion.inet"
-		editionKey    = "minion.edition"
-		idcKey        = "minion.idc"
-		ibuKey        = "minion.ibu"
-		commentKey    = "minion.`comment`"
-		statusKey     = "minion.status"
-		unloadKey     = "minion.unload"
-		goosKey       = "minion.goos"
-		archKey       = "minion.arch"
-		brokerNameKey = "minion.broker_name"
-		opDutyKey     = "minion.op_duty"
-		createdAtKey  = "minion.created_at"
-		uptimeKey     = "minion.uptime"
-	)
-
-	idCol := dynsql.IntColumn(idKey, "ID").Build()
-	tagCol := dynsql.StringColumn(tagKey, "标签").
-		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Like, dynsql.In}).
-		Build()
-	inetCol := dynsql.StringColumn(inetKey, "终端IP").Build()
-	verCol := dynsql.StringColumn(editionKey, "版本").Build()
-	idcCol := dynsql.StringColumn(idcKey, "机房").Build()
-	ibuCol := dynsql.StringColumn(ibuKey, "部门").Build()
-	commentCol := dynsql.StringColumn(commentKey, "描述").Build()
-	statusEnums := dynsql.IntEnum().Set(1, "未激活").Set(2, "离线").
-		Set(3, "在线").Set(4, "已删除")
-	statusCol := dynsql.IntColumn(statusKey, "状态").
-		Enums(statusEnums).
-		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
-		Build()
-	unloadCol := dynsql.BoolColumn(unloadKey, "静默模式").
-		Enums(dynsql.BoolEnum().True("开").False("关")).
-		Build()
-	goosEnums := dynsql.StringEnum().Sames([]string{"linux", "windows", "darwin"})
-	goosCol := dynsql.StringColumn(goosKey, "操作系统").
-		Enums(goosEnums).
-		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
-		Build()
-	archEnums := dynsql.StringEnum().Sames([]string{"amd64", "386", "arm64", "arm"})
-	archCol := dynsql.StringColumn(archKey, "系统架构").
-		Enums(archEnums).
-		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
-		Build()
-	brkCol := dynsql.StringColumn(brokerNameKey, "代理节点").Build()
-	dutyCol := dynsql.StringColumn(opDutyKey, "运维负责人").Build()
-	catCol := dynsql.TimeColumn(createdAtKey, "创建时间").Build()
-	upCol := dynsql.TimeColumn(uptimeKey, "上线时间").Build()
-
-	table := dynsql.Builder().
-		Filters(tagCol, inetCol, goosCol, archCol, statusCol, unloadCol, verCol,
-			idcCol, ibuCol, commentCol, brkCol, dutyCol, catCol, upCol, idCol).
-		Build()
-
-	tbl := query.Minion
-	likes := map[string]field.String{
-		tagKey:        query.MinionTag.Tag,
-		inetKey:       tbl.Inet,
-		editionKey:    tbl.Edition,
-		idcKey:        tbl.IDC,
-		ibuKey:        tbl.IBu,
-		commentKey:    tbl.Comment,
-		goosKey:       tbl.Goos,
-		archKey:       tbl.Arch,
-		brokerNameKey: tbl.BrokerName,
-		opDutyKey:     tbl.OpDuty,
-	}
-
 	return &minionREST{
-		hub:   hub,
-		svc:   svc,
-		table: table,
-		likes: likes,
+		hub:    hub,
+		svc:    svc,
+		filter: newMinionFilter(),
 	}
 }
 
 type minionREST struct {
-	hub   linkhub.Huber
-	svc   service.MinionService
-	table dynsql.Table
-	likes map[string]field.String
+	hub    linkhub.Huber
+	svc    service.MinionService
+	filter *minionFilter
 }
 
 func (rest *minionREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
@@ -108,15 +36,14 @@ func (rest *minionREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 		Data(route.Ignore()).GET(rest.Detail).
 		Data(route.Named("新增 agent 节点")).POST(rest.Create).
 		Data(route.Named("逻辑删除 agent 节点")).DELETE(rest.Delete)
-	bearer.Route("/minion/drop").Data(route.Named("物理删除 agent 节点")).DELETE(rest.Drop)
-	bearer.Route("/sheet/minion").Data(route.Ignore()).GET(rest.CSV)
+	bearer.Route("/sheet/minion").Data(route.Ignore()).GET(rest.Export)
 	bearer.Route("/minion/upgrade").Data(route.Named("节点检查更新")).PATCH(rest.Upgrade)
 	bearer.Route("/minion/batch").Data(route.Named("批量操作")).PATCH(rest.Batch)
 	bearer.Route("/minion/unload").Data(route.Named("静默模式开关")).PATCH(rest.Unload)
 }
 
 func (rest *minionREST) Cond(c *ship.Context) error {
-	res := rest.table.Schema()
+	res := rest.filter.table.Schema()
 	return c.JSON(http.StatusOK, res)
 }
 
@@ -125,14 +52,14 @@ func (rest *minionREST) Page(c *ship.Context) error {
 	if err := c.BindQuery(&req); err != nil {
 		return err
 	}
-	scope, err := rest.table.Inter(req.Input)
+	scope, err := rest.filter.table.Inter(req.Input)
 	if err != nil {
 		return ship.ErrBadRequest.New(err)
 	}
 
 	page := req.Pager()
 	ctx := c.Request().Context()
-	likes := rest.keywordSQL(req.Input, page.Keyword())
+	likes := rest.filter.keywordSQL(req.Input, page.Keyword())
 	count, dats := rest.svc.Page(ctx, page, scope, likes)
 	res := page.Result(count, dats)
 
@@ -154,18 +81,6 @@ func (rest *minionREST) Detail(c *ship.Context) error {
 	return c.JSON(http.StatusOK, res)
 }
 
-func (rest *minionREST) Drop(c *ship.Context) error {
-	var req param.IntID
-	if err := c.Bind(&req); err != nil {
-		return err
-	}
-
-	ctx := c.Request().Context()
-	err := rest.svc.Drop(ctx, req.ID)
-
-	return err
-}
-
 func (rest *minionREST) Create(c *ship.Context) error {
 	var req param.MinionCreate
 	if err := c.Bind(&req); err != nil {
@@ -178,9 +93,18 @@ func (rest *minionREST) Create(c *ship.Context) error {
 	return err
 }
 
-func (rest *minionREST) CSV(c *ship.Context) error {
+func (rest *minionREST) Export(c *ship.Context) error {
+	var req param.MinionExport
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, likes, err := rest.filter.inter(req.Input, req.Like())
+	if err != nil {
+		return err
+	}
+
 	ctx := c.Request().Context()
-	stm := rest.svc.CSV(ctx)
+	stm := rest.svc.Export(ctx, req.Format, scope, likes)
 
 	c.SetRespHeader(ship.HeaderContentDisposition, stm.Disposition())
 
@@ -214,7 +138,7 @@ func (rest *minionREST) Batch(c *ship.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return err
 	}
-	//scope, err := rest.table.Inter(req.Input)
+	//scope, err := rest.filter.table.Inter(req.Input)
 	//if err != nil {
 	//	return err
 	//}
@@ -234,17 +158,112 @@ func (rest *minionREST) Delete(c *ship.Context) error {
 		return errcode.ErrRequiredFilter
 	}
 
-	scope, err := rest.table.Inter(req.Input)
+	scope, err := rest.filter.table.Inter(req.Input)
 	if err != nil {
 		return ship.ErrBadRequest.New(err)
 	}
 
-	likes := rest.keywordSQL(req.Input, req.Like())
+	likes := rest.filter.keywordSQL(req.Input, req.Like())
 
 	return rest.svc.Delete(ctx, scope, likes)
 }
 
-func (rest *minionREST) keywordSQL(input dynsql.Input, keyword string) []gen.Condition {
+// minionFilter 节点列表的 dynsql 过滤条件与关键字模糊查询字段，
+// 按条件批量操作节点时要复用该过滤器，保证与列表页筛选出的节点一致。
+type minionFilter struct {
+	table dynsql.Table
+	likes map[string]field.String
+}
+
+func newMinionFilter() *minionFilter {
+	const (
+		idKey         = "minion.id"
+		tagKey        = "minion_tag.tag"
+		inetKey       = "minion.inet"
+		editionKey    = "minion.edition"
+		idcKey        = "minion.idc"
+		ibuKey        = "minion.ibu"
+		commentKey    = "minion.`comment`"
+		statusKey     = "minion.status"
+		unloadKey     = "minion.unload"
+		goosKey       = "minion.goos"
+		archKey       = "minion.arch"
+		brokerNameKey = "minion.broker_name"
+		opDutyKey     = "minion.op_duty"
+		createdAtKey  = "minion.created_at"
+		uptimeKey     = "minion.uptime"
+	)
+
+	idCol := dynsql.IntColumn(idKey, "ID").Build()
+	tagCol := dynsql.StringColumn(tagKey, "标签").
+		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Like, dynsql.In}).
+		Build()
+	inetCol := dynsql.StringColumn(inetKey, "终端IP").Build()
+	verCol := dynsql.StringColumn(editionKey, "版本").Build()
+	idcCol := dynsql.StringColumn(idcKey, "机房").Build()
+	ibuCol := dynsql.StringColumn(ibuKey, "部门").Build()
+	commentCol := dynsql.StringColumn(commentKey, "描述").Build()
+	statusEnums := dynsql.IntEnum().Set(1, "未激活").Set(2, "离线").
+		Set(3, "在线").Set(4, "已删除")
+	statusCol := dynsql.IntColumn(statusKey, "状态").
+		Enums(statusEnums).
+		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
+		Build()
+	unloadCol := dynsql.BoolColumn(unloadKey, "静默模式").
+		Enums(dynsql.BoolEnum().True("开").False("关")).
+		Build()
+	goosEnums := dynsql.StringEnum().Sames([]string{"linux", "windows", "darwin"})
+	goosCol := dynsql.StringColumn(goosKey, "操作系统").
+		Enums(goosEnums).
+		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
+		Build()
+	archEnums := dynsql.StringEnum().Sames([]string{"amd64", "386", "arm64", "arm"})
+	archCol := dynsql.StringColumn(archKey, "系统架构").
+		Enums(archEnums).
+		Operators([]dynsql.Operator{dynsql.Eq, dynsql.Ne, dynsql.In, dynsql.NotIn}).
+		Build()
+	brkCol := dynsql.StringColumn(brokerNameKey, "代理节点").Build()
+	dutyCol := dynsql.StringColumn(opDutyKey, "运维负责人").Build()
+	catCol := dynsql.TimeColumn(createdAtKey, "创建时间").Build()
+	upCol := dynsql.TimeColumn(uptimeKey, "上线时间").Build()
+
+	table := dynsql.Builder().
+		Filters(tagCol, inetCol, goosCol, archCol, statusCol, unloadCol, verCol,
+			idcCol, ibuCol, commentCol, brkCol, dutyCol, catCol, upCol, idCol).
+		Build()
+
+	tbl := query.Minion
+	likes := map[string]field.String{
+		tagKey:        query.MinionTag.Tag,
+		inetKey:       tbl.Inet,
+		editionKey:    tbl.Edition,
+		idcKey:        tbl.IDC,
+		ibuKey:        tbl.IBu,
+		commentKey:    tbl.Comment,
+		goosKey:       tbl.Goos,
+		archKey:       tbl.Arch,
+		brokerNameKey: tbl.BrokerName,
+		opDutyKey:     tbl.OpDuty,
+	}
+
+	return &minionFilter{
+		table: table,
+		likes: likes,
+	}
+}
+
+// inter 将前端传入的条件转为 dynsql.Scope 与关键字模糊查询条件
+func (mf *minionFilter) inter(input dynsql.Input, keyword string) (dynsql.Scope, []gen.Condition, error) {
+	scope, err := mf.table.Inter(input)
+	if err != nil {
+		return nil, nil, ship.ErrBadRequest.New(err)
+	}
+	likes := mf.keywordSQL(input, keyword)
+
+	return scope, likes, nil
+}
+
+func (mf *minionFilter) keywordSQL(input dynsql.Input, keyword string) []gen.Condition {
 	if keyword == "" {
 		return nil
 	}
@@ -254,8 +273,8 @@ func (rest *minionREST) keywordSQL(input dynsql.Input, keyword string) []gen.Con
 		hm[fl.Col] = struct{}{}
 	}
 
-	ret := make([]gen.Condition, 0, len(rest.likes))
-	for k, f := range rest.likes {
+	ret := make([]gen.Condition, 0, len(mf.likes))
+	for k, f := range mf.likes {
 		if _, ok := hm[k]; ok {
 			continue
 		}
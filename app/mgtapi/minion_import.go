@@ -0,0 +1,71 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func MinionImport(svc service.MinionImportService) route.Router {
+	return &minionImportREST{
+		svc: svc,
+	}
+}
+
+type minionImportREST struct {
+	svc service.MinionImportService
+}
+
+func (rest *minionImportREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/minion/imports").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/minion/import").Data(route.Named("批量导入 agent 节点")).POST(rest.Import)
+	bearer.Route("/minion/import/items").Data(route.Ignore()).GET(rest.Items)
+}
+
+func (rest *minionImportREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *minionImportREST) Items(c *ship.Context) error {
+	var req param.MinionImportItemPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Items(ctx, req.ID, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *minionImportREST) Import(c *ship.Context) error {
+	var req param.MinionImport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	res, err := rest.svc.Import(ctx, &req, cu.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
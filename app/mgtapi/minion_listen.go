@@ -10,7 +10,7 @@ import (
 	"github.com/xgfone/ship/v5"
 )
 
-func MinionListen(svc service.MinionListenService) route.Router {
+func MinionListen(svc service.MinionListenService, agg service.AggregateService) route.Router {
 	inetCol := dynsql.StringColumn("inet", "终端IP").Build()
 	protoCol := dynsql.IntColumn("protocol", "协议").Build()
 	lportCol := dynsql.IntColumn("local_port", "本地端口").Build()
@@ -21,25 +21,30 @@ func MinionListen(svc service.MinionListenService) route.Router {
 	lipCol := dynsql.StringColumn("local_ip", "本地IP").Build()
 	pathCol := dynsql.StringColumn("path", "路径").Build()
 	midCol := dynsql.StringColumn("minion_id", "节点 ID").Build()
+	updatedAtCol := dynsql.TimeColumn("updated_at", "更新时间").Build()
 
 	table := dynsql.Builder().
-		Filters(inetCol, protoCol, lportCol, pidCol, procCol, unameCol, familyCol, lipCol, pathCol, midCol).
+		Filters(inetCol, protoCol, lportCol, pidCol, procCol, unameCol, familyCol, lipCol, pathCol, midCol, updatedAtCol).
+		Groups(inetCol, protoCol, lportCol, procCol, unameCol, lipCol).
 		Build()
 
 	return &minionListenREST{
 		svc:   svc,
+		agg:   agg,
 		table: table,
 	}
 }
 
 type minionListenREST struct {
 	svc   service.MinionListenService
+	agg   service.AggregateService
 	table dynsql.Table
 }
 
 func (rest *minionListenREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/listen/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/listens").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/listen/aggregate").Data(route.Ignore()).GET(rest.Aggregate)
 }
 
 func (rest *minionListenREST) Cond(c *ship.Context) error {
@@ -64,3 +69,22 @@ func (rest *minionListenREST) Page(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *minionListenREST) Aggregate(c *ship.Context) error {
+	var req param.Aggregate
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.agg.Aggregate(ctx, "minion_listen", rest.table.Schema(), scope, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
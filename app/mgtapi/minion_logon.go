@@ -11,7 +11,7 @@ import (
 	"github.com/xgfone/ship/v5"
 )
 
-func MinionLogon(svc service.MinionLogonService) route.Router {
+func MinionLogon(svc service.MinionLogonService, agg service.AggregateService) route.Router {
 	msgEnums := []string{"登录成功", "登录失败", "用户注销"}
 	inetCol := dynsql.StringColumn("inet", "终端IP").Build()
 	userCol := dynsql.StringColumn("user", "账户").Build()
@@ -23,22 +23,26 @@ func MinionLogon(svc service.MinionLogonService) route.Router {
 	devCol := dynsql.StringColumn("device", "登录设备").Build()
 	table := dynsql.Builder().
 		Filters(inetCol, userCol, msgCol, addrCol, logonAtCol, typeCol, midCol, devCol).
+		Groups(inetCol, userCol, msgCol, addrCol, typeCol, devCol).
 		Build()
 
 	return &minionLogonREST{
 		svc:   svc,
+		agg:   agg,
 		table: table,
 	}
 }
 
 type minionLogonREST struct {
 	svc   service.MinionLogonService
+	agg   service.AggregateService
 	table dynsql.Table
 }
 
 func (rest *minionLogonREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/logon/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/logons").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/logon/aggregate").Data(route.Ignore()).GET(rest.Aggregate)
 	bearer.Route("/logon/attack").Data(route.Ignore()).POST(rest.Attack)
 	bearer.Route("/logon/recent").Data(route.Ignore()).GET(rest.Recent)
 	bearer.Route("/logon/history").Data(route.Ignore()).GET(rest.History)
@@ -111,3 +115,22 @@ func (rest *minionLogonREST) History(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *minionLogonREST) Aggregate(c *ship.Context) error {
+	var req param.Aggregate
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.agg.Aggregate(ctx, "minion_logon", rest.table.Schema(), scope, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
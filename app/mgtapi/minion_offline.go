@@ -0,0 +1,50 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/xgfone/ship/v5"
+)
+
+func MinionOffline(svc service.MinionOfflineService) route.Router {
+	return &minionOfflineREST{
+		svc: svc,
+	}
+}
+
+type minionOfflineREST struct {
+	svc service.MinionOfflineService
+}
+
+func (rest *minionOfflineREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/minion/offlines").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/minion/offline/retry").Data(route.Named("重试通知节点下线")).PATCH(rest.Retry)
+}
+
+func (rest *minionOfflineREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *minionOfflineREST) Retry(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Retry(ctx, req.ID)
+}
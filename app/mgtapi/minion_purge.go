@@ -0,0 +1,87 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func MinionPurge(svc service.MinionPurgeService) route.Router {
+	return &minionPurgeREST{
+		svc: svc,
+	}
+}
+
+type minionPurgeREST struct {
+	svc service.MinionPurgeService
+}
+
+func (rest *minionPurgeREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/minion/drop").Data(route.Named("物理删除 agent 节点")).DELETE(rest.Drop)
+	bearer.Route("/minion/drops").Data(route.Named("批量物理删除 agent 节点")).DELETE(rest.Drops)
+	bearer.Route("/minion/purges").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/minion/purge").Data(route.Ignore()).GET(rest.Report)
+}
+
+func (rest *minionPurgeREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *minionPurgeREST) Report(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Report(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *minionPurgeREST) Drop(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	return rest.drop(c, []int64{req.ID})
+}
+
+func (rest *minionPurgeREST) Drops(c *ship.Context) error {
+	var req param.MinionDrop
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	return rest.drop(c, req.IDs)
+}
+
+func (rest *minionPurgeREST) drop(c *ship.Context, mids []int64) error {
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	id, err := rest.svc.Drop(ctx, mids, cu.ID)
+	if err != nil {
+		return err
+	}
+	res := &param.IntID{ID: id}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -25,6 +25,8 @@ func (rest *notifierREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 		Data(route.Named("添加告警人")).POST(rest.Create).
 		Data(route.Named("修改告警人")).PUT(rest.Update).
 		Data(route.Named("删除告警人")).DELETE(rest.Delete)
+	bearer.Route("/notifier/test").Data(route.Named("测试告警人通知")).POST(rest.Test)
+	bearer.Route("/notifier/deliveries").Data(route.Ignore()).GET(rest.Deliveries)
 }
 
 func (rest *notifierREST) Page(c *ship.Context) error {
@@ -83,3 +85,32 @@ func (rest *notifierREST) Delete(c *ship.Context) error {
 
 	return err
 }
+
+func (rest *notifierREST) Test(c *ship.Context) error {
+	var req param.NotifierTest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Test(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *notifierREST) Deliveries(c *ship.Context) error {
+	var req param.NotifierDeliveryPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Deliveries(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
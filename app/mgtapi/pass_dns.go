@@ -7,6 +7,7 @@ import (
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/app/route"
 	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
 	"github.com/xgfone/ship/v5"
 )
 
@@ -14,6 +15,7 @@ func PassDNS(svc service.PassDNSService) route.Router {
 	tbl := dynsql.Builder().Filters(
 		dynsql.StringColumn("domain", "域名").Build(),
 		dynsql.StringColumn("kind", "数据维度").Build(),
+		dynsql.StringColumn("reason", "加白原因").Build(),
 		dynsql.TimeColumn("before_at", "有效期").Build(),
 	).Build()
 	return &passDNSREST{
@@ -30,6 +32,13 @@ type passDNSREST struct {
 func (rest *passDNSREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/passdns/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/passdnss").Data(route.Ignore()).POST(rest.Page)
+	bearer.Route("/passdns").
+		Data(route.Named("新建 DNS 白名单")).POST(rest.Create).
+		Data(route.Named("修改 DNS 白名单")).PATCH(rest.Update).
+		Data(route.Named("导入 DNS 白名单")).PUT(rest.Import).
+		Data(route.Named("删除 DNS 白名单")).DELETE(rest.Delete)
+	bearer.Route("/passdns/expire").Data(route.Named("失效 DNS 白名单")).PATCH(rest.Expire)
+	bearer.Route("/passdns/changelogs").Data(route.Ignore()).GET(rest.Changelog)
 }
 
 func (rest *passDNSREST) Cond(c *ship.Context) error {
@@ -54,3 +63,81 @@ func (rest *passDNSREST) Page(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *passDNSREST) Create(c *ship.Context) error {
+	var req param.PassDNSCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *passDNSREST) Update(c *ship.Context) error {
+	var req param.PassDNSUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req, cu.ID)
+}
+
+func (rest *passDNSREST) Import(c *ship.Context) error {
+	var req param.PassImport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	res, err := rest.svc.Import(ctx, &req, cu.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *passDNSREST) Delete(c *ship.Context) error {
+	var req param.OptionalIDs
+	if err := c.Bind(&req); err != nil || len(req.ID) == 0 {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID, cu.ID)
+}
+
+func (rest *passDNSREST) Expire(c *ship.Context) error {
+	var req param.OptionalIDs
+	if err := c.Bind(&req); err != nil || len(req.ID) == 0 {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Expire(ctx, req.ID, cu.ID)
+}
+
+func (rest *passDNSREST) Changelog(c *ship.Context) error {
+	var req param.PassChangelogPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	page := req.Pager()
+	ctx := c.Request().Context()
+	count, dats := rest.svc.Changelog(ctx, page, &req)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
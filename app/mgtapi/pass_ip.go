@@ -7,13 +7,15 @@ import (
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/app/route"
 	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
 	"github.com/xgfone/ship/v5"
 )
 
 func PassIP(svc service.PassIPService) route.Router {
 	tbl := dynsql.Builder().Filters(
-		dynsql.StringColumn("domain", "域名").Build(),
+		dynsql.StringColumn("ip", "IP").Build(),
 		dynsql.StringColumn("kind", "数据维度").Build(),
+		dynsql.StringColumn("reason", "加白原因").Build(),
 		dynsql.TimeColumn("before_at", "有效期").Build(),
 	).Build()
 	return &passIPREST{
@@ -30,6 +32,13 @@ type passIPREST struct {
 func (rest *passIPREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/passip/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/passips").Data(route.Ignore()).POST(rest.Page)
+	bearer.Route("/passip").
+		Data(route.Named("新建 IP 白名单")).POST(rest.Create).
+		Data(route.Named("修改 IP 白名单")).PATCH(rest.Update).
+		Data(route.Named("导入 IP 白名单")).PUT(rest.Import).
+		Data(route.Named("删除 IP 白名单")).DELETE(rest.Delete)
+	bearer.Route("/passip/expire").Data(route.Named("失效 IP 白名单")).PATCH(rest.Expire)
+	bearer.Route("/passip/changelogs").Data(route.Ignore()).GET(rest.Changelog)
 }
 
 func (rest *passIPREST) Cond(c *ship.Context) error {
@@ -54,3 +63,81 @@ func (rest *passIPREST) Page(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *passIPREST) Create(c *ship.Context) error {
+	var req param.PassIPCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *passIPREST) Update(c *ship.Context) error {
+	var req param.PassIPUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req, cu.ID)
+}
+
+func (rest *passIPREST) Import(c *ship.Context) error {
+	var req param.PassImport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	res, err := rest.svc.Import(ctx, &req, cu.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *passIPREST) Delete(c *ship.Context) error {
+	var req param.OptionalIDs
+	if err := c.Bind(&req); err != nil || len(req.ID) == 0 {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID, cu.ID)
+}
+
+func (rest *passIPREST) Expire(c *ship.Context) error {
+	var req param.OptionalIDs
+	if err := c.Bind(&req); err != nil || len(req.ID) == 0 {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Expire(ctx, req.ID, cu.ID)
+}
+
+func (rest *passIPREST) Changelog(c *ship.Context) error {
+	var req param.PassChangelogPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	page := req.Pager()
+	ctx := c.Request().Context()
+	count, dats := rest.svc.Changelog(ctx, page, &req)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
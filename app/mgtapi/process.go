@@ -10,7 +10,7 @@ import (
 	"github.com/xgfone/ship/v5"
 )
 
-func Process(svc service.ProcessService) route.Router {
+func Process(svc service.ProcessService, agg service.AggregateService) route.Router {
 	inetCol := dynsql.StringColumn("inet", "终端IP").Build()
 	pidCol := dynsql.IntColumn("pid", "PID").Build()
 	nameCol := dynsql.StringColumn("name", "进程名称").Build()
@@ -23,21 +23,25 @@ func Process(svc service.ProcessService) route.Router {
 
 	table := dynsql.Builder().
 		Filters(inetCol, pidCol, nameCol, unameCol, execCol, stateCol, cmdlineCol, minionIDCol, updateAtCol).
+		Groups(inetCol, nameCol, unameCol, execCol, stateCol).
 		Build()
 	return &processREST{
 		svc:   svc,
+		agg:   agg,
 		table: table,
 	}
 }
 
 type processREST struct {
 	svc   service.ProcessService
+	agg   service.AggregateService
 	table dynsql.Table
 }
 
 func (rest *processREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/process/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/processes").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/process/aggregate").Data(route.Ignore()).GET(rest.Aggregate)
 }
 
 func (rest *processREST) Cond(c *ship.Context) error {
@@ -62,3 +66,22 @@ func (rest *processREST) Page(c *ship.Context) error {
 
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *processREST) Aggregate(c *ship.Context) error {
+	var req param.Aggregate
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.agg.Aggregate(ctx, "minion_process", rest.table.Schema(), scope, &req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
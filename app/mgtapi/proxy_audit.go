@@ -0,0 +1,96 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/xgfone/ship/v5"
+)
+
+func ProxyAudit(svc service.ProxyAuditService) route.Router {
+	kindEnums := dynsql.StringEnum().Sames([]string{"brr", "arr"})
+	methods := []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions,
+	}
+	methodEnums := dynsql.StringEnum().Sames(methods)
+
+	kindCol := dynsql.StringColumn("kind", "通道类型").Enums(kindEnums).Build()
+	userCol := dynsql.IntColumn("user_id", "用户 ID").Build()
+	minionCol := dynsql.IntColumn("minion_id", "节点 ID").Build()
+	brokerCol := dynsql.IntColumn("broker_id", "代理节点 ID").Build()
+	inetCol := dynsql.StringColumn("inet", "终端IP").Build()
+	methodCol := dynsql.StringColumn("method", "请求方法").Enums(methodEnums).Build()
+	pathCol := dynsql.StringColumn("path", "请求路径").Build()
+	statusCol := dynsql.IntColumn("status", "响应状态码").Build()
+	respCol := dynsql.IntColumn("resp_size", "响应大小").Build()
+	latencyCol := dynsql.IntColumn("latency", "耗时（毫秒）").Build()
+	clientCol := dynsql.StringColumn("client_ip", "客户端IP").Build()
+	createdCol := dynsql.TimeColumn("created_at", "请求时间").Build()
+
+	table := dynsql.Builder().
+		Filters(kindCol, userCol, minionCol, brokerCol, inetCol, methodCol, pathCol,
+			statusCol, respCol, latencyCol, clientCol, createdCol).
+		Build()
+
+	return &proxyAuditREST{
+		svc:   svc,
+		table: table,
+	}
+}
+
+type proxyAuditREST struct {
+	svc   service.ProxyAuditService
+	table dynsql.Table
+}
+
+func (rest *proxyAuditREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/proxy/audit/cond").Data(route.Ignore()).GET(rest.Cond)
+	bearer.Route("/proxy/audits").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/proxy/audit/setting").
+		Data(route.Ignore()).GET(rest.Setting).
+		Data(route.Named("修改节点代理审计设置")).PUT(rest.SetSetting)
+}
+
+func (rest *proxyAuditREST) Cond(c *ship.Context) error {
+	res := rest.table.Schema()
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *proxyAuditREST) Page(c *ship.Context) error {
+	var req param.PageSQL
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page, scope)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *proxyAuditREST) Setting(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Setting(ctx)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *proxyAuditREST) SetSetting(c *ship.Context) error {
+	var req param.ProxyAuditSetting
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.SetSetting(ctx, &req)
+}
@@ -0,0 +1,92 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func ProxyPolicy(svc service.ProxyPolicyService) route.Router {
+	return &proxyPolicyREST{
+		svc: svc,
+	}
+}
+
+type proxyPolicyREST struct {
+	svc service.ProxyPolicyService
+}
+
+func (rest *proxyPolicyREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/proxy/policies").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/proxy/policy").
+		Data(route.Named("新增节点代理访问策略")).POST(rest.Create).
+		Data(route.Named("修改节点代理访问策略")).PUT(rest.Update).
+		Data(route.Named("删除节点代理访问策略")).DELETE(rest.Delete)
+	bearer.Route("/proxy/denials").Data(route.Ignore()).GET(rest.Denials)
+}
+
+func (rest *proxyPolicyREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *proxyPolicyREST) Create(c *ship.Context) error {
+	var req param.ProxyPolicyCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *proxyPolicyREST) Update(c *ship.Context) error {
+	var req param.ProxyPolicyUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req)
+}
+
+func (rest *proxyPolicyREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+func (rest *proxyPolicyREST) Denials(c *ship.Context) error {
+	var req param.ProxyDenialPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Denials(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
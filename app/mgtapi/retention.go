@@ -0,0 +1,97 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Retention(svc service.RetentionService) route.Router {
+	return &retentionREST{
+		svc: svc,
+	}
+}
+
+type retentionREST struct {
+	svc service.RetentionService
+}
+
+func (rest *retentionREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/retention/policies").Data(route.Ignore()).GET(rest.Policies)
+	bearer.Route("/retention/policy").Data(route.Named("修改数据保留策略")).PUT(rest.Upsert)
+	bearer.Route("/retention/runs").Data(route.Ignore()).GET(rest.Runs)
+	bearer.Route("/retention/execute").Data(route.Named("立即清理数据表")).POST(rest.Execute)
+	bearer.Route("/retention/archive").Data(route.Ignore()).GET(rest.Archive)
+}
+
+func (rest *retentionREST) Policies(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Policies(ctx)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *retentionREST) Upsert(c *ship.Context) error {
+	var req param.RetentionPolicyUpsert
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Upsert(ctx, &req, cu.ID)
+}
+
+func (rest *retentionREST) Runs(c *ship.Context) error {
+	var req param.RetentionRunPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Runs(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *retentionREST) Execute(c *ship.Context) error {
+	var req param.RetentionExecute
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Execute(ctx, req.Table)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *retentionREST) Archive(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	file, err := rest.svc.Archive(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	c.SetRespHeader(ship.HeaderContentDisposition, file.Disposition())
+	c.SetRespHeader(ship.HeaderContentLength, file.ContentLength())
+
+	return c.Stream(http.StatusOK, file.ContentType(), file)
+}
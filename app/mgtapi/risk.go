@@ -1,19 +1,20 @@
 package mgtapi
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
-	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"github.com/vela-ssoc/vela-common-mb/dynsql"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/app/route"
 	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
 	"github.com/vela-ssoc/vela-manager/errcode"
 	"github.com/xgfone/ship/v5"
 )
 
-func Risk(svc service.RiskService) route.Router {
+func Risk(svc service.RiskService, agg service.AggregateService) route.Router {
 	riskTypeCol := dynsql.StringColumn("risk_type", "风险类型").Build()
 	subjectCol := dynsql.StringColumn("subject", "主题").Build()
 	inetCol := dynsql.StringColumn("inet", "终端 IP").Build()
@@ -37,18 +38,21 @@ func Risk(svc service.RiskService) route.Router {
 		Build()
 	return &riskREST{
 		svc:   svc,
+		agg:   agg,
 		table: table,
 	}
 }
 
 type riskREST struct {
 	svc   service.RiskService
+	agg   service.AggregateService
 	table dynsql.Table
 }
 
 func (rest *riskREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/risk/cond").Data(route.Ignore()).GET(rest.Cond)
 	bearer.Route("/risk/attack").Data(route.Ignore()).GET(rest.Attack)
+	bearer.Route("/risk/aggregate").Data(route.Ignore()).GET(rest.Aggregate)
 	bearer.Route("/risk/group").Data(route.Ignore()).GET(rest.Group)
 	bearer.Route("/risk/recent").Data(route.Ignore()).GET(rest.Recent)
 	bearer.Route("/risks").Data(route.Ignore()).GET(rest.Page)
@@ -137,7 +141,24 @@ func (rest *riskREST) Recent(c *ship.Context) error {
 }
 
 func (rest *riskREST) CSV(c *ship.Context) error {
-	return c.JSON(http.StatusOK, nil)
+	var req param.RiskExport
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	ctx := c.Request().Context()
+	stm, err := rest.svc.Export(ctx, req.Format, scope)
+	if err != nil {
+		return err
+	}
+
+	c.SetRespHeader(ship.HeaderContentDisposition, stm.Disposition())
+
+	return c.Stream(http.StatusOK, stm.MIME(), stm)
 }
 
 func (rest *riskREST) Pie(c *ship.Context) error {
@@ -148,28 +169,32 @@ func (rest *riskREST) Pie(c *ship.Context) error {
 		topN = 10
 	}
 
-	ctx := c.Request().Context()
-	tx := query.Risk.WithContext(ctx).UnderlyingDB()
+	// 兼容旧的查询参数，分组字段与风险类型都转为 dynsql 条件经过白名单校验
+	cond := map[string]any{"group": group}
 	if rtype != "" {
-		tx.Where("risk_type = ?", rtype)
+		cond["filters"] = []map[string]string{{"key": "risk_type", "operator": "eq", "value": rtype}}
+	}
+	raw, _ := json.Marshal(cond)
+	var input dynsql.Input
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+	scope, err := rest.table.Inter(input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
 	}
 
-	res := &param.PieTopN{TopN: make([]*param.NameCount, 0, topN)}
-	var count int64
-	if tx.Count(&count); count == 0 {
-		return c.JSON(http.StatusOK, res)
+	ctx := c.Request().Context()
+	req := &param.Aggregate{Input: input, Func: "count", Top: topN}
+	agg, err := rest.agg.Aggregate(ctx, "risk", rest.table.Schema(), scope, req)
+	if err != nil {
+		return err
 	}
-	tx.Select("COUNT(*) count", group+" name").
-		Group(group).
-		Order("count DESC").
-		Limit(topN).
-		Scan(&res.TopN)
 
-	var num int
-	for _, tn := range res.TopN {
-		num += tn.Count
+	res := &param.PieTopN{TopN: make([]*param.NameCount, 0, len(agg.Items)), Other: int(agg.Other)}
+	for _, item := range agg.Items {
+		res.TopN = append(res.TopN, &param.NameCount{Name: item.Name, Count: int(item.Value)})
 	}
-	res.Other = int(count) - num
 
 	return c.JSON(http.StatusOK, res)
 }
@@ -193,37 +218,58 @@ func (rest *riskREST) Delete(c *ship.Context) error {
 }
 
 func (rest *riskREST) Ignore(c *ship.Context) error {
-	var req dynsql.Input
+	var req param.RiskHandle
 	if err := c.Bind(&req); err != nil {
 		return err
 	}
 	if len(req.Filters) == 0 {
 		return errcode.ErrRequiredFilter
 	}
-	scope, err := rest.table.Inter(req)
+	scope, err := rest.table.Inter(req.Input)
 	if err != nil {
 		return ship.ErrBadRequest.New(err)
 	}
 
+	cu := session.Cast(c.Any)
 	ctx := c.Request().Context()
 
-	return rest.svc.Ignore(ctx, scope)
+	return rest.svc.Ignore(ctx, scope, req.Reason, cu.ID)
 }
 
 func (rest *riskREST) Process(c *ship.Context) error {
-	var req dynsql.Input
+	var req param.RiskHandle
 	if err := c.Bind(&req); err != nil {
 		return err
 	}
 	if len(req.Filters) == 0 {
 		return errcode.ErrRequiredFilter
 	}
-	scope, err := rest.table.Inter(req)
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Process(ctx, scope, req.Reason, cu.ID)
+}
+
+func (rest *riskREST) Aggregate(c *ship.Context) error {
+	var req param.Aggregate
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
 	if err != nil {
 		return ship.ErrBadRequest.New(err)
 	}
 
 	ctx := c.Request().Context()
+	res, err := rest.agg.Aggregate(ctx, "risk", rest.table.Schema(), scope, &req)
+	if err != nil {
+		return err
+	}
 
-	return rest.svc.Process(ctx, scope)
+	return c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,126 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func RiskCase(svc service.RiskCaseService) route.Router {
+	return &riskCaseREST{
+		svc: svc,
+	}
+}
+
+type riskCaseREST struct {
+	svc service.RiskCaseService
+}
+
+func (rest *riskCaseREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/risk/case").Data(route.Ignore()).GET(rest.Detail)
+	bearer.Route("/risk/case/mine").Data(route.Ignore()).GET(rest.Mine)
+	bearer.Route("/risk/case/status").Data(route.Named("修改风险事件状态")).PATCH(rest.Status)
+	bearer.Route("/risk/case/assign").Data(route.Named("分派风险事件")).PATCH(rest.Assign)
+	bearer.Route("/risk/case/comment").Data(route.Named("评论风险事件")).POST(rest.Comment)
+	bearer.Route("/risk/case/attachment").Data(route.Ignore()).GET(rest.Attachment)
+}
+
+func (rest *riskCaseREST) Detail(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Detail(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *riskCaseREST) Mine(c *ship.Context) error {
+	var req param.RiskCasePage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Mine(ctx, &req, page, cu.ID)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *riskCaseREST) Status(c *ship.Context) error {
+	var req param.RiskCaseStatus
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Status(ctx, &req, cu.ID)
+}
+
+func (rest *riskCaseREST) Assign(c *ship.Context) error {
+	var req param.RiskAssign
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Assign(ctx, &req, cu.ID)
+}
+
+func (rest *riskCaseREST) Comment(c *ship.Context) error {
+	var req param.RiskCommentCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	if req.File == nil {
+		return rest.svc.Comment(ctx, req.RiskID, req.Content, "", nil, cu.ID)
+	}
+
+	file, err := req.File.Open()
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	return rest.svc.Comment(ctx, req.RiskID, req.Content, req.File.Filename, file, cu.ID)
+}
+
+func (rest *riskCaseREST) Attachment(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	file, err := rest.svc.Attachment(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	c.SetRespHeader(ship.HeaderContentDisposition, file.Disposition())
+	c.SetRespHeader(ship.HeaderContentLength, file.ContentLength())
+
+	return c.Stream(http.StatusOK, file.ContentType(), file)
+}
@@ -0,0 +1,89 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/xgfone/ship/v5"
+)
+
+func SessionRecord(svc service.SessionRecordService) route.Router {
+	return &sessionRecordREST{
+		svc: svc,
+	}
+}
+
+type sessionRecordREST struct {
+	svc service.SessionRecordService
+}
+
+func (rest *sessionRecordREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/session/records").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/session/record").Data(route.Named("删除会话录像")).DELETE(rest.Delete)
+	bearer.Route("/session/record/replay").Data(route.Named("回放会话录像")).GET(rest.Replay)
+	bearer.Route("/session/record/retention").
+		Data(route.Ignore()).GET(rest.Retention).
+		Data(route.Named("修改会话录像保留设置")).PUT(rest.SetRetention)
+}
+
+func (rest *sessionRecordREST) Page(c *ship.Context) error {
+	var req param.SessionRecordPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *sessionRecordREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+// Replay 按照 NDJSON 格式输出录像中的每一帧，由前端按照时间偏移回放
+func (rest *sessionRecordREST) Replay(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	file, err := rest.svc.Replay(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	return c.Stream(http.StatusOK, "application/x-ndjson", file)
+}
+
+func (rest *sessionRecordREST) Retention(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Retention(ctx)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *sessionRecordREST) SetRetention(c *ship.Context) error {
+	var req param.SessionRetention
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.SetRetention(ctx, &req)
+}
@@ -0,0 +1,89 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Siem(svc service.SiemService) route.Router {
+	return &siemREST{
+		svc: svc,
+	}
+}
+
+type siemREST struct {
+	svc service.SiemService
+}
+
+func (rest *siemREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/siem/forwarders").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/siem/forwarder").
+		Data(route.Named("新增 SIEM 转发器")).POST(rest.Create).
+		Data(route.Named("修改 SIEM 转发器")).PUT(rest.Update).
+		Data(route.Named("删除 SIEM 转发器")).DELETE(rest.Delete)
+	bearer.Route("/siem/forwarder/test").Data(route.Named("测试 SIEM 转发器")).POST(rest.Test)
+}
+
+func (rest *siemREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *siemREST) Create(c *ship.Context) error {
+	var req param.SiemForwarderCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *siemREST) Update(c *ship.Context) error {
+	var req param.SiemForwarderUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req)
+}
+
+func (rest *siemREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+func (rest *siemREST) Test(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Test(ctx, req.ID)
+}
@@ -138,10 +138,15 @@ func (rest *substanceREST) Command(c *ship.Context) error {
 	mid := req.ID
 	ctx := c.Request().Context()
 
-	switch req.Cmd {
-	case "resync":
+	if req.Cmd == "resync" {
 		return rest.svc.Resync(ctx, mid)
-	default:
-		return rest.svc.Command(ctx, mid, req.Cmd)
 	}
+
+	cu := session.Cast(c.Any)
+	res, err := rest.svc.Command(ctx, mid, req.Cmd, cu.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
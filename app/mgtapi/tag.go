@@ -23,6 +23,9 @@ func (rest *tagREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
 	bearer.Route("/tag/indices").Data(route.Ignore()).GET(rest.Indices)
 	bearer.Route("/tag/sidebar").Data(route.Ignore()).GET(rest.Sidebar)
 	bearer.Route("/minion/tag").Data(route.Named("修改节点标签")).PATCH(rest.Update)
+	bearer.Route("/tag/rename").Data(route.Named("全局重命名标签")).PATCH(rest.Rename)
+	bearer.Route("/tag/merge").Data(route.Named("全局合并标签")).PATCH(rest.Merge)
+	bearer.Route("/tag").Data(route.Named("全局删除标签")).DELETE(rest.Delete)
 }
 
 func (rest *tagREST) Indices(c *ship.Context) error {
@@ -55,3 +58,48 @@ func (rest *tagREST) Sidebar(c *ship.Context) error {
 	res := rest.svc.Sidebar(ctx)
 	return c.JSON(http.StatusOK, res)
 }
+
+func (rest *tagREST) Rename(c *ship.Context) error {
+	var req param.TagRename
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Rename(ctx, req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *tagREST) Merge(c *ship.Context) error {
+	var req param.TagRename
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Merge(ctx, req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *tagREST) Delete(c *ship.Context) error {
+	var req param.TagDelete
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Delete(ctx, req.Tag)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,94 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func TagRule(svc service.TagRuleService) route.Router {
+	return &tagRuleREST{
+		svc: svc,
+	}
+}
+
+type tagRuleREST struct {
+	svc service.TagRuleService
+}
+
+func (rest *tagRuleREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/tag/rules").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/tag/rule/fields").Data(route.Ignore()).GET(rest.Fields)
+	bearer.Route("/tag/rule").
+		Data(route.Named("新增自动标签规则")).POST(rest.Create).
+		Data(route.Named("修改自动标签规则")).PUT(rest.Update).
+		Data(route.Named("删除自动标签规则")).DELETE(rest.Delete)
+	bearer.Route("/tag/rule/apply").Data(route.Named("执行自动标签规则")).PATCH(rest.Apply)
+}
+
+func (rest *tagRuleREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *tagRuleREST) Fields(c *ship.Context) error {
+	res := rest.svc.Fields()
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *tagRuleREST) Create(c *ship.Context) error {
+	var req param.TagRuleCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *tagRuleREST) Update(c *ship.Context) error {
+	var req param.TagRuleUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req)
+}
+
+func (rest *tagRuleREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+func (rest *tagRuleREST) Apply(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res, err := rest.svc.Apply(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,151 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Threat(svc service.ThreatService) route.Router {
+	typeEnums := dynsql.StringEnum().Sames([]string{"ip", "cidr", "domain", "url", "md5", "sha1", "sha256"})
+	table := dynsql.Builder().
+		Filters(
+			dynsql.StringColumn("type", "类型").Enums(typeEnums).Build(),
+			dynsql.StringColumn("value", "值").Build(),
+			dynsql.StringColumn("kind", "威胁分类").Build(),
+			dynsql.StringColumn("source", "情报来源").Build(),
+			dynsql.IntColumn("confidence", "置信度").Build(),
+			dynsql.TimeColumn("expired_at", "过期时间").Build(),
+			dynsql.TimeColumn("updated_at", "导入时间").Build(),
+		).Build()
+
+	return &threatREST{
+		svc:   svc,
+		table: table,
+	}
+}
+
+type threatREST struct {
+	svc   service.ThreatService
+	table dynsql.Table
+}
+
+func (rest *threatREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/threat/cond").Data(route.Ignore()).GET(rest.Cond)
+	bearer.Route("/threat/indicators").Data(route.Ignore()).GET(rest.Indicators)
+	bearer.Route("/threat/imports").Data(route.Ignore()).GET(rest.Imports)
+	bearer.Route("/threat/import").Data(route.Named("导入威胁情报")).POST(rest.Import)
+	bearer.Route("/threat/matches").Data(route.Ignore()).GET(rest.Matches)
+	bearer.Route("/threat/match/ack").Data(route.Named("标记威胁情报命中已查看")).PATCH(rest.Ack)
+	bearer.Route("/threat/feed").
+		Data(route.Ignore()).GET(rest.Feed).
+		Data(route.Named("修改威胁情报目录")).PUT(rest.SetFeed)
+}
+
+func (rest *threatREST) Cond(c *ship.Context) error {
+	res := rest.table.Schema()
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) Indicators(c *ship.Context) error {
+	var req param.PageSQL
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	scope, err := rest.table.Inter(req.Input)
+	if err != nil {
+		return ship.ErrBadRequest.New(err)
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Indicators(ctx, page, scope)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) Imports(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Imports(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) Import(c *ship.Context) error {
+	var req param.ThreatImport
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	file, err := req.File.Open()
+	if err != nil {
+		return err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	res, err := rest.svc.Import(ctx, &req, file, cu.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) Matches(c *ship.Context) error {
+	var req param.ThreatMatchPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Matches(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) Ack(c *ship.Context) error {
+	var req param.ThreatMatchAck
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Ack(ctx, &req)
+}
+
+func (rest *threatREST) Feed(c *ship.Context) error {
+	ctx := c.Request().Context()
+	res := rest.svc.Feed(ctx)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *threatREST) SetFeed(c *ship.Context) error {
+	var req param.ThreatFeed
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.SetFeed(ctx, &req)
+}
@@ -0,0 +1,107 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func UpgradeCampaign(svc service.UpgradeCampaignService) route.Router {
+	return &upgradeCampaignREST{
+		svc:    svc,
+		filter: newMinionFilter(),
+	}
+}
+
+type upgradeCampaignREST struct {
+	svc    service.UpgradeCampaignService
+	filter *minionFilter
+}
+
+func (rest *upgradeCampaignREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/upgrade/campaigns").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/upgrade/campaign").
+		Data(route.Ignore()).GET(rest.Report).
+		Data(route.Named("创建节点批量升级活动")).POST(rest.Create)
+	bearer.Route("/upgrade/campaign/items").Data(route.Ignore()).GET(rest.Items)
+	bearer.Route("/upgrade/campaign/cancel").Data(route.Named("取消节点批量升级活动")).PATCH(rest.Cancel)
+}
+
+func (rest *upgradeCampaignREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *upgradeCampaignREST) Create(c *ship.Context) error {
+	var req param.UpgradeCampaignCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	scope, likes, err := rest.filter.inter(req.Input, req.Like())
+	if err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+	id, err := rest.svc.Create(ctx, &req, scope, likes, cu.ID)
+	if err != nil {
+		return err
+	}
+	res := &param.IntID{ID: id}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *upgradeCampaignREST) Report(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Report(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *upgradeCampaignREST) Items(c *ship.Context) error {
+	var req param.UpgradeCampaignItemPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Items(ctx, req.ID, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *upgradeCampaignREST) Cancel(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Cancel(ctx, req.ID)
+}
@@ -0,0 +1,120 @@
+package mgtapi
+
+import (
+	"net/http"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/route"
+	"github.com/vela-ssoc/vela-manager/app/service"
+	"github.com/vela-ssoc/vela-manager/app/session"
+	"github.com/xgfone/ship/v5"
+)
+
+func Webhook(svc service.WebhookService) route.Router {
+	return &webhookREST{
+		svc: svc,
+	}
+}
+
+type webhookREST struct {
+	svc service.WebhookService
+}
+
+func (rest *webhookREST) Route(_, bearer, _ *ship.RouteGroupBuilder) {
+	bearer.Route("/webhooks").Data(route.Ignore()).GET(rest.Page)
+	bearer.Route("/webhook").
+		Data(route.Named("新增 webhook 订阅")).POST(rest.Create).
+		Data(route.Named("修改 webhook 订阅")).PUT(rest.Update).
+		Data(route.Named("删除 webhook 订阅")).DELETE(rest.Delete)
+	bearer.Route("/webhook/test").Data(route.Named("测试 webhook 推送")).POST(rest.Test)
+	bearer.Route("/webhook/deliveries").Data(route.Ignore()).GET(rest.Deliveries)
+	bearer.Route("/webhook/redeliver").Data(route.Named("重新推送 webhook")).POST(rest.Redeliver)
+}
+
+func (rest *webhookREST) Page(c *ship.Context) error {
+	var req param.Page
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Page(ctx, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *webhookREST) Create(c *ship.Context) error {
+	var req param.WebhookCreate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	cu := session.Cast(c.Any)
+	ctx := c.Request().Context()
+
+	return rest.svc.Create(ctx, &req, cu.ID)
+}
+
+func (rest *webhookREST) Update(c *ship.Context) error {
+	var req param.WebhookUpdate
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Update(ctx, &req)
+}
+
+func (rest *webhookREST) Delete(c *ship.Context) error {
+	var req param.IntID
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Delete(ctx, req.ID)
+}
+
+func (rest *webhookREST) Test(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	res, err := rest.svc.Test(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *webhookREST) Deliveries(c *ship.Context) error {
+	var req param.WebhookDeliveryPage
+	if err := c.BindQuery(&req); err != nil {
+		return err
+	}
+	page := req.Pager()
+	ctx := c.Request().Context()
+
+	count, dats := rest.svc.Deliveries(ctx, &req, page)
+	res := page.Result(count, dats)
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (rest *webhookREST) Redeliver(c *ship.Context) error {
+	var req param.IntID
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	return rest.svc.Redeliver(ctx, req.ID)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// AggregateService 基于 dynsql 数据表的通用分组聚合。
+//
+// 分组与去重字段只能是数据表 Groups 中声明的字段，分桶的时间字段只能是时间类型的过滤字段，
+// 拼接到 SQL 中的字段名都经过白名单校验。
+type AggregateService interface {
+	Aggregate(ctx context.Context, table string, schema dynsql.Schema, scope dynsql.Scope, req *param.Aggregate) (*param.AggregateResult, error)
+}
+
+func Aggregate(db *gorm.DB) AggregateService {
+	return &aggregateService{
+		db:      db,
+		maxRows: 5000,
+	}
+}
+
+type aggregateService struct {
+	db      *gorm.DB
+	maxRows int // 分桶查询最多返回的行数
+}
+
+// aggregateBuckets 时间分桶的 SQL 表达式，%s 为时间字段。
+var aggregateBuckets = map[string]string{
+	"hour":  "DATE_FORMAT(%s, '%%Y-%%m-%%d %%H:00')",
+	"day":   "DATE_FORMAT(%s, '%%Y-%%m-%%d')",
+	"week":  "DATE_FORMAT(DATE_SUB(%[1]s, INTERVAL WEEKDAY(%[1]s) DAY), '%%Y-%%m-%%d')",
+	"month": "DATE_FORMAT(%s, '%%Y-%%m')",
+}
+
+func (biz *aggregateService) Aggregate(ctx context.Context, table string, schema dynsql.Schema, scope dynsql.Scope,
+	req *param.Aggregate,
+) (*param.AggregateResult, error) {
+	// 数据表没有声明可分组的字段时 Inter 会忽略分组条件
+	group := scope.GroupColumn()
+	if req.Group != "" && group == "" {
+		return nil, errcode.FmtErrAggregateColumn.Fmt(req.Group)
+	}
+
+	value := "COUNT(*)"
+	if req.Func == "distinct" {
+		if !aggregateGroupable(schema, req.Distinct) {
+			return nil, errcode.FmtErrAggregateColumn.Fmt(req.Distinct)
+		}
+		value = "COUNT(DISTINCT " + aggregateQuote(req.Distinct) + ")"
+	}
+	var bucket string
+	if req.Bucket != "" {
+		if !aggregateTimeColumn(schema, req.Time) {
+			return nil, errcode.FmtErrAggregateColumn.Fmt(req.Time)
+		}
+		bucket = fmt.Sprintf(aggregateBuckets[req.Bucket], aggregateQuote(req.Time))
+	}
+	top := req.Top
+	if top == 0 {
+		top = 10
+	}
+
+	db := biz.db.WithContext(ctx).Table(table).Scopes(scope.Where)
+	res := &param.AggregateResult{Items: make([]*param.AggregateItem, 0, top)}
+	if err := db.Session(&gorm.Session{}).
+		Select(value).
+		Scan(&res.Total).Error; err != nil || res.Total == 0 {
+		return res, err
+	}
+
+	// 只分桶不分组
+	if group == "" {
+		if bucket == "" {
+			return res, nil
+		}
+		err := db.Session(&gorm.Session{}).
+			Select(bucket + " AS bucket, " + value + " AS value").
+			Group(bucket).
+			Order(bucket).
+			Limit(biz.maxRows).
+			Scan(&res.Items).Error
+		return res, err
+	}
+
+	// 分组取前几项
+	column := aggregateQuote(group)
+	name := "COALESCE(" + column + ", '') AS name"
+	var tops []*param.AggregateItem
+	if err := db.Session(&gorm.Session{}).
+		Select(name + ", " + value + " AS value").
+		Group(column).
+		Order("value DESC").
+		Limit(top).
+		Scan(&tops).Error; err != nil {
+		return nil, err
+	}
+	if req.Func != "distinct" {
+		res.Other = res.Total
+		for _, item := range tops {
+			res.Other -= item.Value
+		}
+	}
+	if bucket == "" || len(tops) == 0 {
+		res.Items = tops
+		return res, nil
+	}
+
+	// 分组且分桶时，只统计前几项在每个时间桶中的数量
+	names := make([]string, 0, len(tops))
+	for _, item := range tops {
+		names = append(names, item.Name)
+	}
+	err := db.Session(&gorm.Session{}).
+		Select(bucket+" AS bucket, "+name+", "+value+" AS value").
+		Where(column+" IN ?", names).
+		Group(bucket + ", " + column).
+		Order(bucket).
+		Limit(biz.maxRows).
+		Scan(&res.Items).Error
+
+	return res, err
+}
+
+func aggregateGroupable(schema dynsql.Schema, col string) bool {
+	for _, g := range schema.Groups {
+		if g.Col == col {
+			return true
+		}
+	}
+	return false
+}
+
+func aggregateTimeColumn(schema dynsql.Schema, col string) bool {
+	for _, f := range schema.Filters {
+		if f.Col == col && f.Type == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregateQuote 字段名已经过白名单校验，加上反引号避免与 MySQL 关键字冲突。
+func aggregateQuote(col string) string {
+	return "`" + col + "`"
+}
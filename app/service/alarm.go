@@ -1,13 +1,586 @@
 package service
 
-// AlarmService 告警模块
-type AlarmService interface{}
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-func Alarm() AlarmService {
-	return &alarmService{}
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// AlarmService 告警模块，在 manager 端对 event risk minion_logon 以及节点状态执行告警规则
+type AlarmService interface {
+	Rules(ctx context.Context, page param.Pager) (int64, []*entity.AlertRule)
+	Fields() map[string][]string
+	CreateRule(ctx context.Context, req *param.AlertRuleCreate, userID int64) error
+	UpdateRule(ctx context.Context, req *param.AlertRuleUpdate) error
+	DeleteRule(ctx context.Context, id int64) error
+
+	Page(ctx context.Context, req *param.AlertPage, page param.Pager) (int64, []*entity.Alert)
+	Ack(ctx context.Context, req *param.AlertHandle, userID int64) error
+	Resolve(ctx context.Context, req *param.AlertHandle, userID int64) error
+
+	// Run 定时执行告警规则
+	Run(ctx context.Context)
+}
+
+func Alarm(db *gorm.DB, notifier NotifierService, slog logback.Logger) AlarmService {
+	return &alarmService{
+		db:       db,
+		notifier: notifier,
+		slog:     slog,
+		interval: time.Minute,
+	}
+}
+
+type alarmService struct {
+	db       *gorm.DB
+	notifier NotifierService
+	slog     logback.Logger
+	interval time.Duration
+}
+
+// alertSource 告警数据源，fields 是允许过滤与分组的字段白名单，
+// timeCol 为空说明是快照数据源，只支持 threshold 条件。
+type alertSource struct {
+	table    string
+	timeCol  string
+	levelCol string
+	fields   []string
+	where    func(db *gorm.DB) *gorm.DB
+}
+
+func (src alertSource) has(field string) bool {
+	for _, f := range src.fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+var alertSources = map[string]alertSource{
+	"event": {
+		table:    "event",
+		timeCol:  "occur_at",
+		levelCol: "level",
+		fields: []string{
+			"inet", "minion_id", "subject", "remote_addr", "from_code", "typeof",
+			"user", "region", "level", "msg",
+		},
+	},
+	"risk": {
+		table:    "risk",
+		timeCol:  "occur_at",
+		levelCol: "level",
+		fields: []string{
+			"inet", "minion_id", "risk_type", "level", "subject", "remote_ip",
+			"from_code", "region",
+		},
+	},
+	"logon": {
+		table:   "minion_logon",
+		timeCol: "logon_at",
+		fields:  []string{"inet", "minion_id", "user", "addr", "type", "device", "process", "msg"},
+	},
+	"minion": {
+		table: "minion",
+		fields: []string{
+			"inet", "status", "goos", "arch", "edition", "idc", "ibu", "category", "broker_name",
+		},
+		where: func(db *gorm.DB) *gorm.DB {
+			return db.Where("status <> ?", uint8(model.MSDelete))
+		},
+	},
+}
+
+func (biz *alarmService) Rules(ctx context.Context, page param.Pager) (int64, []*entity.AlertRule) {
+	db := biz.db.WithContext(ctx).Model(&entity.AlertRule{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR comment LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.AlertRule
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *alarmService) Fields() map[string][]string {
+	ret := make(map[string][]string, len(alertSources))
+	for name, src := range alertSources {
+		ret[name] = src.fields
+	}
+	return ret
+}
+
+func (biz *alarmService) CreateRule(ctx context.Context, req *param.AlertRuleCreate, userID int64) error {
+	now := time.Now()
+	dat := &entity.AlertRule{CreatedID: userID, CreatedAt: now}
+	if err := biz.fill(dat, req, now); err != nil {
+		return err
+	}
+
+	return biz.db.WithContext(ctx).Create(dat).Error
+}
+
+func (biz *alarmService) UpdateRule(ctx context.Context, req *param.AlertRuleUpdate) error {
+	dat := new(entity.AlertRule)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(dat).Error; err != nil {
+		return err
+	}
+	if err := biz.fill(dat, &req.AlertRuleCreate, time.Now()); err != nil {
+		return err
+	}
+
+	return biz.db.WithContext(ctx).Save(dat).Error
+}
+
+func (biz *alarmService) DeleteRule(ctx context.Context, id int64) error {
+	ret := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&entity.AlertRule{})
+	if ret.Error == nil && ret.RowsAffected == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	return ret.Error
+}
+
+func (biz *alarmService) Page(ctx context.Context, req *param.AlertPage, page param.Pager) (int64, []*entity.Alert) {
+	db := biz.db.WithContext(ctx).Model(&entity.Alert{})
+	if req.RuleID != 0 {
+		db = db.Where("rule_id = ?", req.RuleID)
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.Severity != 0 {
+		db = db.Where("severity = ?", req.Severity)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("rule_name LIKE ? OR summary LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.Alert
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *alarmService) Ack(ctx context.Context, req *param.AlertHandle, userID int64) error {
+	now := time.Now()
+	ret := biz.db.WithContext(ctx).
+		Model(&entity.Alert{}).
+		Where("id = ? AND status = ?", req.ID, entity.AlsFiring).
+		UpdateColumns(map[string]any{
+			"status":     entity.AlsAcknowledged,
+			"acked_id":   userID,
+			"acked_at":   now,
+			"remark":     req.Remark,
+			"updated_at": now,
+		})
+	if ret.Error == nil && ret.RowsAffected == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	return ret.Error
+}
+
+func (biz *alarmService) Resolve(ctx context.Context, req *param.AlertHandle, userID int64) error {
+	now := time.Now()
+	ret := biz.db.WithContext(ctx).
+		Model(&entity.Alert{}).
+		Where("id = ? AND status <> ?", req.ID, entity.AlsResolved).
+		UpdateColumns(map[string]any{
+			"status":      entity.AlsResolved,
+			"resolved_id": userID,
+			"resolved_at": now,
+			"remark":      req.Remark,
+			"updated_at":  now,
+		})
+	if ret.Error == nil && ret.RowsAffected == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	return ret.Error
+}
+
+func (biz *alarmService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.evaluate(ctx)
+		}
+	}
 }
 
-type alarmService struct{}
+func (biz *alarmService) fill(dat *entity.AlertRule, req *param.AlertRuleCreate, now time.Time) error {
+	src, ok := alertSources[req.Source]
+	if !ok {
+		return errcode.FmtErrAlertField.Fmt(req.Source, "")
+	}
+	if src.timeCol == "" && req.Condition != "threshold" {
+		return errcode.FmtErrAlertCond.Fmt(req.Source, req.Condition)
+	}
+	filters := make(entity.AlertFilters, 0, len(req.Filters))
+	for _, f := range req.Filters {
+		if !src.has(f.Field) {
+			return errcode.FmtErrAlertField.Fmt(req.Source, f.Field)
+		}
+		filters = append(filters, &entity.AlertFilter{Field: f.Field, Operator: f.Operator, Values: f.Values})
+	}
+	for _, g := range req.GroupBy {
+		if !src.has(g) {
+			return errcode.FmtErrAlertField.Fmt(req.Source, g)
+		}
+	}
+
+	dat.Name = req.Name
+	dat.Source = req.Source
+	dat.Filters = filters
+	dat.Condition = req.Condition
+	dat.Threshold = req.Threshold
+	dat.Ratio = req.Ratio
+	dat.Window = req.Window
+	dat.GroupBy = req.GroupBy
+	dat.Dedupe = req.Dedupe
+	dat.Severity = req.Severity
+	dat.SeverityMap = req.SeverityMap
+	dat.NotifierIDs = req.NotifierIDs
+	dat.Enabled = req.Enabled
+	dat.Comment = req.Comment
+	dat.UpdatedAt = now
+
+	return nil
+}
+
+func (biz *alarmService) evaluate(ctx context.Context) {
+	var rules []*entity.AlertRule
+	biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Find(&rules)
+	now := time.Now()
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := biz.evaluateRule(ctx, rule, now); err != nil {
+			biz.slog.Warnf("执行告警规则 %s 出错：%s", rule.Name, err)
+		}
+	}
+}
+
+// alertGroup 一个分组的统计结果
+type alertGroup struct {
+	labels map[string]string
+	count  int
+}
+
+func (ag *alertGroup) key() string {
+	keys := make([]string, 0, len(ag.labels))
+	for k := range ag.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+ag.labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (biz *alarmService) evaluateRule(ctx context.Context, rule *entity.AlertRule, now time.Time) error {
+	src, ok := alertSources[rule.Source]
+	if !ok {
+		return nil
+	}
+	window := time.Duration(rule.Window) * time.Second
+	from := now.Add(-window)
+
+	current, err := biz.count(ctx, rule, src, from, now)
+	if err != nil {
+		return err
+	}
+
+	var fired []*alertGroup
+	switch rule.Condition {
+	case "threshold":
+		for _, g := range current {
+			if g.count >= rule.Threshold {
+				fired = append(fired, g)
+			}
+		}
+	case "rate":
+		previous, exx := biz.count(ctx, rule, src, from.Add(-window), from)
+		if exx != nil {
+			return exx
+		}
+		for key, g := range current {
+			prev := 0
+			if p := previous[key]; p != nil {
+				prev = p.count
+			}
+			if g.count >= rule.Threshold && g.count*100 >= prev*(100+rule.Ratio) {
+				fired = append(fired, g)
+			}
+		}
+	case "absence":
+		if len(rule.GroupBy) == 0 {
+			if len(current) == 0 {
+				fired = append(fired, &alertGroup{labels: map[string]string{}})
+			}
+			break
+		}
+		previous, exx := biz.count(ctx, rule, src, from.Add(-window), from)
+		if exx != nil {
+			return exx
+		}
+		for key, g := range previous {
+			if _, exist := current[key]; !exist {
+				fired = append(fired, &alertGroup{labels: g.labels})
+			}
+		}
+	}
+
+	for _, g := range fired {
+		severity := biz.severity(ctx, rule, src, g, from, now)
+		biz.fire(ctx, rule, g, severity, now)
+	}
+
+	return nil
+}
+
+// scope 拼接规则的过滤条件，字段均已在白名单中校验，值通过参数绑定传递。
+func (biz *alarmService) scope(ctx context.Context, rule *entity.AlertRule, src alertSource, from, to time.Time) *gorm.DB {
+	db := biz.db.WithContext(ctx).Table(src.table)
+	if src.where != nil {
+		db = src.where(db)
+	}
+	if col := src.timeCol; col != "" {
+		db = db.Where("`"+col+"` >= ? AND `"+col+"` < ?", from, to)
+	}
+	for _, f := range rule.Filters {
+		if !src.has(f.Field) || len(f.Values) == 0 {
+			continue
+		}
+		col := "`" + f.Field + "`"
+		switch f.Operator {
+		case "eq":
+			db = db.Where(col+" IN ?", f.Values)
+		case "ne":
+			db = db.Where(col+" NOT IN ?", f.Values)
+		case "contains", "prefix":
+			conds := make([]string, 0, len(f.Values))
+			args := make([]any, 0, len(f.Values))
+			for _, v := range f.Values {
+				v = strings.NewReplacer("%", "\\%", "_", "\\_").Replace(v)
+				if f.Operator == "contains" {
+					v = "%" + v
+				}
+				conds = append(conds, col+" LIKE ?")
+				args = append(args, v+"%")
+			}
+			db = db.Where("("+strings.Join(conds, " OR ")+")", args...)
+		}
+	}
+
+	return db
+}
+
+// count 按照分组统计时间范围内的数据条数
+func (biz *alarmService) count(ctx context.Context, rule *entity.AlertRule, src alertSource, from, to time.Time) (map[string]*alertGroup, error) {
+	cols := make([]string, 0, len(rule.GroupBy))
+	for _, g := range rule.GroupBy {
+		if src.has(g) {
+			cols = append(cols, "`"+g+"`")
+		}
+	}
+
+	db := biz.scope(ctx, rule, src, from, to)
+	selects := append([]string{"COUNT(*) AS `count`"}, cols...)
+	db = db.Select(strings.Join(selects, ", "))
+	if len(cols) != 0 {
+		db = db.Group(strings.Join(cols, ", ")).Limit(1000)
+	}
+
+	var rows []map[string]any
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]*alertGroup, len(rows))
+	for _, row := range rows {
+		g := &alertGroup{labels: make(map[string]string, len(cols))}
+		for k, v := range row {
+			if k == "count" {
+				g.count, _ = strconv.Atoi(biz.stringify(v))
+				continue
+			}
+			g.labels[k] = biz.stringify(v)
+		}
+		// 未分组统计时 COUNT(*) 总有一行结果
+		if len(cols) == 0 && g.count == 0 {
+			continue
+		}
+		ret[g.key()] = g
+	}
+
+	return ret, nil
+}
+
+func (*alarmService) stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// severity 根据分组内数据源的级别映射告警级别，取映射后的最高级别。
+func (biz *alarmService) severity(ctx context.Context, rule *entity.AlertRule, src alertSource, g *alertGroup, from, to time.Time) entity.AlertSeverity {
+	ret := rule.Severity
+	if len(rule.SeverityMap) == 0 || src.levelCol == "" || g.count == 0 {
+		return ret
+	}
+
+	db := biz.scope(ctx, rule, src, from, to)
+	for k, v := range g.labels {
+		db = db.Where("`"+k+"` = ?", v)
+	}
+	var levels []string
+	db.Distinct("`"+src.levelCol+"`").Pluck(src.levelCol, &levels)
+	var mapped entity.AlertSeverity
+	for _, lvl := range levels {
+		if sev := rule.SeverityMap[lvl]; sev > mapped {
+			mapped = sev
+		}
+	}
+	if mapped != 0 {
+		ret = mapped
+	}
+
+	return ret
+}
+
+// fire 去重窗口内存在未解决的同组告警时只累加次数，否则新建告警并发送通知。
+func (biz *alarmService) fire(ctx context.Context, rule *entity.AlertRule, g *alertGroup, severity entity.AlertSeverity, now time.Time) {
+	key := g.key()
+	dedupe := rule.Dedupe
+	if dedupe <= 0 {
+		dedupe = rule.Window
+	}
+	since := now.Add(-time.Duration(dedupe) * time.Second)
+
+	old := new(entity.Alert)
+	biz.db.WithContext(ctx).
+		Where("rule_id = ? AND group_key = ? AND status <> ?", rule.ID, key, entity.AlsResolved).
+		Where("last_at >= ?", since).
+		Order("id DESC").
+		Limit(1).
+		Find(old)
+	if old.ID != 0 {
+		if severity < old.Severity {
+			severity = old.Severity
+		}
+		biz.db.WithContext(ctx).Model(old).UpdateColumns(map[string]any{
+			"count":      gorm.Expr("count + 1"),
+			"severity":   severity,
+			"last_at":    now,
+			"updated_at": now,
+		})
+		return
+	}
+
+	labels, _ := json.Marshal(g.labels)
+	dat := &entity.Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Source:    rule.Source,
+		GroupKey:  key,
+		Labels:    labels,
+		Severity:  severity,
+		Status:    entity.AlsFiring,
+		Summary:   biz.summary(rule, g),
+		Count:     1,
+		FirstAt:   now,
+		LastAt:    now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := biz.db.WithContext(ctx).Create(dat).Error; err != nil {
+		biz.slog.Warnf("保存告警 %s 出错：%s", rule.Name, err)
+		return
+	}
+
+	err := biz.notify(ctx, rule, dat)
+	assigns := map[string]any{"notified": err == nil}
+	if err != nil {
+		assigns["notify_err"] = err.Error()
+	}
+	biz.db.WithContext(ctx).Model(dat).UpdateColumns(assigns)
+}
+
+func (*alarmService) summary(rule *entity.AlertRule, g *alertGroup) string {
+	var cond string
+	window := time.Duration(rule.Window) * time.Second
+	switch rule.Condition {
+	case "threshold":
+		cond = fmt.Sprintf("%s 内出现 %d 次，阈值 %d", window, g.count, rule.Threshold)
+	case "rate":
+		cond = fmt.Sprintf("%s 内出现 %d 次，较上一个窗口增长超过 %d%%", window, g.count, rule.Ratio)
+	case "absence":
+		cond = fmt.Sprintf("%s 内没有数据", window)
+	}
+	if key := g.key(); key != "" {
+		return fmt.Sprintf("[%s] %s", key, cond)
+	}
+
+	return cond
+}
+
+// notify 通过规则配置的通知人发送告警，咚咚直接发送，企业微信、短信、电话走运维平台。
+func (biz *alarmService) notify(ctx context.Context, rule *entity.AlertRule, alt *entity.Alert) error {
+	if len(rule.NotifierIDs) == 0 {
+		return nil
+	}
+	var ntfs []*model.Notifier
+	if err := biz.db.WithContext(ctx).
+		Where("id IN ?", []int64(rule.NotifierIDs)).
+		Find(&ntfs).Error; err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("[%s] %s", alt.Severity, alt.RuleName)
 
-func (alt *alarmService) name() {
+	return biz.notifier.Notify(ctx, ntfs, "alert", title, alt.Summary)
 }
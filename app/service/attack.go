@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-manager/app/internal/attack"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttackService interface {
+	Tactics(ctx context.Context) []*entity.AttackTactic
+	Techniques(ctx context.Context, page param.Pager, req *param.AttackTechniquePage) (int64, []*entity.AttackTechnique)
+
+	// Import 导入 ATT&CK STIX bundle，已存在的战术与技术会被更新。
+	Import(ctx context.Context, r io.Reader) (*param.AttackImportResult, error)
+
+	Mappings(ctx context.Context, page param.Pager, req *param.AttackMappingPage) (int64, []*entity.AttackMapping)
+	CreateMapping(ctx context.Context, req *param.AttackMappingCreate, userID int64) error
+	UpdateMapping(ctx context.Context, req *param.AttackMappingUpdate, userID int64) error
+	DeleteMapping(ctx context.Context, id int64) error
+
+	// Annotate 根据映射关系补充风险事件对应的 ATT&CK 技术
+	Annotate(ctx context.Context, risks []*model.Risk) []*param.RiskItem
+
+	// Coverage 统计最近一段时间各技术的命中次数，以及没有任何配置能检测到的技术。
+	Coverage(ctx context.Context, days int) *param.AttackCoverageResult
+}
+
+func Attack(db *gorm.DB) AttackService {
+	return &attackService{db: db}
+}
+
+type attackService struct {
+	db *gorm.DB
+}
+
+func (biz *attackService) Tactics(ctx context.Context) []*entity.AttackTactic {
+	var dats []*entity.AttackTactic
+	biz.db.WithContext(ctx).Order("tactic_id").Find(&dats)
+	return dats
+}
+
+func (biz *attackService) Techniques(ctx context.Context, page param.Pager, req *param.AttackTechniquePage) (int64, []*entity.AttackTechnique) {
+	db := biz.db.WithContext(ctx).Model(&entity.AttackTechnique{})
+	if req.Tactic != "" {
+		db = db.Where("JSON_CONTAINS(tactics, JSON_QUOTE(?))", req.Tactic)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("technique_id LIKE ? OR name LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.AttackTechnique
+	db.Order("technique_id").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *attackService) Import(ctx context.Context, r io.Reader) (*param.AttackImportResult, error) {
+	bundle, err := attack.Parse(r)
+	if err != nil {
+		return nil, errcode.FmtErrAttackBundle.Fmt(err)
+	}
+
+	tactics := make([]*entity.AttackTactic, 0, len(bundle.Tactics))
+	for _, t := range bundle.Tactics {
+		tactics = append(tactics, &entity.AttackTactic{
+			TacticID:    t.ID,
+			Name:        t.Name,
+			ShortName:   t.ShortName,
+			Description: t.Description,
+			URL:         t.URL,
+		})
+	}
+	techniques := make([]*entity.AttackTechnique, 0, len(bundle.Techniques))
+	for _, t := range bundle.Techniques {
+		techniques = append(techniques, &entity.AttackTechnique{
+			TechniqueID:  t.ID,
+			Name:         t.Name,
+			Tactics:      t.Tactics,
+			Platforms:    t.Platforms,
+			Description:  t.Description,
+			URL:          t.URL,
+			Subtechnique: t.Subtechnique,
+			Deprecated:   t.Deprecated,
+		})
+	}
+
+	if err = biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(tactics) != 0 {
+			if exx := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tactic_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "short_name", "description", "url", "updated_at"}),
+			}).CreateInBatches(tactics, 100).Error; exx != nil {
+				return exx
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "technique_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"name", "tactics", "platforms", "description", "url", "subtechnique", "deprecated", "updated_at",
+			}),
+		}).CreateInBatches(techniques, 100).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	res := &param.AttackImportResult{Tactics: len(tactics), Techniques: len(techniques)}
+
+	return res, nil
+}
+
+func (biz *attackService) Mappings(ctx context.Context, page param.Pager, req *param.AttackMappingPage) (int64, []*entity.AttackMapping) {
+	db := biz.db.WithContext(ctx).Model(&entity.AttackMapping{})
+	if req.Field != "" {
+		db = db.Where("field = ?", req.Field)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("value LIKE ? OR remark LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.AttackMapping
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *attackService) CreateMapping(ctx context.Context, req *param.AttackMappingCreate, userID int64) error {
+	techniques, err := biz.checkTechniques(ctx, req.Techniques)
+	if err != nil {
+		return err
+	}
+	if err = biz.checkMapping(ctx, 0, req.Field, req.Value); err != nil {
+		return err
+	}
+
+	dat := &entity.AttackMapping{
+		Field:      req.Field,
+		Value:      req.Value,
+		Techniques: techniques,
+		Remark:     req.Remark,
+		CreatedID:  userID,
+		UpdatedID:  userID,
+	}
+
+	return biz.db.WithContext(ctx).Create(dat).Error
+}
+
+func (biz *attackService) UpdateMapping(ctx context.Context, req *param.AttackMappingUpdate, userID int64) error {
+	techniques, err := biz.checkTechniques(ctx, req.Techniques)
+	if err != nil {
+		return err
+	}
+	if err = biz.checkMapping(ctx, req.ID, req.Field, req.Value); err != nil {
+		return err
+	}
+
+	ret := biz.db.WithContext(ctx).
+		Model(&entity.AttackMapping{}).
+		Where("id = ?", req.ID).
+		UpdateColumns(map[string]any{
+			"field":      req.Field,
+			"value":      req.Value,
+			"techniques": techniques,
+			"remark":     req.Remark,
+			"updated_id": userID,
+			"updated_at": time.Now(),
+		})
+	if ret.Error != nil || ret.RowsAffected != 0 {
+		return ret.Error
+	}
+
+	return errcode.ErrOperateFailed
+}
+
+func (biz *attackService) DeleteMapping(ctx context.Context, id int64) error {
+	ret := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&entity.AttackMapping{})
+	if ret.Error != nil || ret.RowsAffected != 0 {
+		return ret.Error
+	}
+
+	return errcode.ErrDeleteFailed
+}
+
+func (biz *attackService) Annotate(ctx context.Context, risks []*model.Risk) []*param.RiskItem {
+	mapper := biz.mapper(ctx)
+	ret := make([]*param.RiskItem, 0, len(risks))
+	for _, r := range risks {
+		ret = append(ret, &param.RiskItem{
+			Risk:       *r,
+			Techniques: mapper.lookup(r.RiskType, r.FromCode),
+		})
+	}
+
+	return ret
+}
+
+func (biz *attackService) Coverage(ctx context.Context, days int) *param.AttackCoverageResult {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	dates := make([]string, 0, days)
+	index := make(map[string]int, days)
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format(time.DateOnly)
+		index[date] = i
+		dates = append(dates, date)
+	}
+
+	var techniques []*entity.AttackTechnique
+	biz.db.WithContext(ctx).
+		Select("technique_id", "name", "tactics", "deprecated").
+		Order("technique_id").
+		Find(&techniques)
+	briefs := make(map[string]param.AttackTechniqueID, len(techniques))
+	for _, t := range techniques {
+		briefs[t.TechniqueID] = param.AttackTechniqueID{TechniqueID: t.TechniqueID, Name: t.Name, Tactics: t.Tactics}
+	}
+
+	var rows []*struct {
+		Date     string `gorm:"column:date"`
+		RiskType string `gorm:"column:risk_type"`
+		FromCode string `gorm:"column:from_code"`
+		Count    int64  `gorm:"column:count"`
+	}
+	rawSQL := "SELECT DATE_FORMAT(occur_at, '%Y-%m-%d') AS date, risk_type, from_code, COUNT(*) AS count " +
+		"FROM risk " +
+		"WHERE occur_at >= ? " +
+		"GROUP BY date, risk_type, from_code"
+	biz.db.WithContext(ctx).Raw(rawSQL, start).Scan(&rows)
+
+	mapper := biz.mapper(ctx)
+	hits := make(map[string]*param.AttackHit, 64)
+	for _, row := range rows {
+		idx, ok := index[row.Date]
+		if !ok {
+			continue
+		}
+		for _, tid := range mapper.lookup(row.RiskType, row.FromCode) {
+			hit := hits[tid]
+			if hit == nil {
+				brief, exist := briefs[tid]
+				if !exist {
+					brief = param.AttackTechniqueID{TechniqueID: tid}
+				}
+				hit = &param.AttackHit{AttackTechniqueID: brief, Counts: make([]int64, days)}
+				hits[tid] = hit
+			}
+			hit.Total += row.Count
+			hit.Counts[idx] += row.Count
+		}
+	}
+
+	res := &param.AttackCoverageResult{Dates: dates, Hits: make([]*param.AttackHit, 0, len(hits))}
+	for _, hit := range hits {
+		res.Hits = append(res.Hits, hit)
+	}
+	sort.Slice(res.Hits, func(i, j int) bool {
+		a, b := res.Hits[i], res.Hits[j]
+		return a.Total > b.Total || a.Total == b.Total && a.TechniqueID < b.TechniqueID
+	})
+
+	detected := biz.detected(ctx, mapper)
+	for _, t := range techniques {
+		if !t.Deprecated && !detected[t.TechniqueID] {
+			res.Undetected = append(res.Undetected, &param.AttackTechniqueID{
+				TechniqueID: t.TechniqueID,
+				Name:        t.Name,
+				Tactics:     t.Tactics,
+			})
+		}
+	}
+
+	return res
+}
+
+// detected 能被检测到的技术：映射了风险类型的技术，以及映射了现有配置（from_code）的技术。
+func (biz *attackService) detected(ctx context.Context, mapper attackMapper) map[string]bool {
+	var names []string
+	biz.db.WithContext(ctx).
+		Model(&model.Substance{}).
+		Distinct("name").
+		Pluck("name", &names)
+	substances := make(map[string]bool, len(names))
+	for _, name := range names {
+		substances[name] = true
+	}
+
+	ret := make(map[string]bool, 256)
+	for key, techniques := range mapper {
+		if key.field == entity.AttackFieldFromCode && !substances[key.value] {
+			continue
+		}
+		for _, tid := range techniques {
+			ret[tid] = true
+		}
+	}
+
+	return ret
+}
+
+// checkTechniques 检查技术编号是否都已导入，返回统一为大写的技术编号。
+func (biz *attackService) checkTechniques(ctx context.Context, techniques []string) ([]string, error) {
+	ret := make([]string, 0, len(techniques))
+	for _, tid := range techniques {
+		ret = append(ret, strings.ToUpper(strings.TrimSpace(tid)))
+	}
+
+	var exists []string
+	if err := biz.db.WithContext(ctx).
+		Model(&entity.AttackTechnique{}).
+		Where("technique_id IN ?", ret).
+		Pluck("technique_id", &exists).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(exists))
+	for _, tid := range exists {
+		found[tid] = true
+	}
+	for _, tid := range ret {
+		if !found[tid] {
+			return nil, errcode.FmtErrAttackTechnique.Fmt(tid)
+		}
+	}
+
+	return ret, nil
+}
+
+// checkMapping 同一字段的同一个值只能有一条映射
+func (biz *attackService) checkMapping(ctx context.Context, id int64, field, value string) error {
+	var count int64
+	if biz.db.WithContext(ctx).
+		Model(&entity.AttackMapping{}).
+		Where("id <> ? AND field = ? AND value = ?", id, field, value).
+		Count(&count); count != 0 {
+		return errcode.FmtErrAttackMapping.Fmt(field, value)
+	}
+
+	return nil
+}
+
+func (biz *attackService) mapper(ctx context.Context) attackMapper {
+	var dats []*entity.AttackMapping
+	biz.db.WithContext(ctx).
+		Select("field", "value", "techniques").
+		Find(&dats)
+
+	ret := make(attackMapper, len(dats))
+	for _, dat := range dats {
+		key := attackKey{field: dat.Field, value: dat.Value}
+		ret[key] = append(ret[key], dat.Techniques...)
+	}
+
+	return ret
+}
+
+type attackKey struct {
+	field string
+	value string
+}
+
+// attackMapper 映射字段与值到 ATT&CK 技术编号
+type attackMapper map[attackKey][]string
+
+// lookup 合并风险类型与来源模块映射的技术
+func (am attackMapper) lookup(riskType, fromCode string) []string {
+	a := am[attackKey{field: entity.AttackFieldRiskType, value: riskType}]
+	b := am[attackKey{field: entity.AttackFieldFromCode, value: fromCode}]
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+
+	ret := make([]string, 0, len(a)+len(b))
+	uniq := make(map[string]struct{}, len(a)+len(b))
+	for _, ids := range [][]string{a, b} {
+		for _, tid := range ids {
+			if _, exist := uniq[tid]; !exist {
+				uniq[tid] = struct{}{}
+				ret = append(ret, tid)
+			}
+		}
+	}
+	sort.Strings(ret)
+
+	return ret
+}
@@ -5,20 +5,51 @@ import (
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/integration/cmdb"
+	"github.com/vela-ssoc/vela-manager/errcode"
 )
 
 type CmdbService interface {
 	Detail(ctx context.Context, id int64) *model.Cmdb
+
+	// Refresh 重新从运维中心拉取节点的 CMDB 信息，并执行自动标签规则
+	Refresh(ctx context.Context, id int64) error
 }
 
-func Cmdb() CmdbService {
-	return &cmdbService{}
+func Cmdb(cmdbw cmdb.Client, tagRule TagRuleService) CmdbService {
+	return &cmdbService{
+		cmdbw:   cmdbw,
+		tagRule: tagRule,
+	}
 }
 
-type cmdbService struct{}
+type cmdbService struct {
+	cmdbw   cmdb.Client
+	tagRule TagRuleService
+}
 
 func (biz *cmdbService) Detail(ctx context.Context, id int64) *model.Cmdb {
 	tbl := query.Cmdb
 	dat, _ := tbl.WithContext(ctx).Where(tbl.ID.Eq(id)).First()
 	return dat
 }
+
+func (biz *cmdbService) Refresh(ctx context.Context, id int64) error {
+	tbl := query.Minion
+	mon, err := tbl.WithContext(ctx).
+		Select(tbl.ID, tbl.Inet, tbl.Status).
+		Where(tbl.ID.Eq(id)).
+		First()
+	if err != nil {
+		return err
+	}
+	if mon.Status == model.MSDelete {
+		return errcode.ErrNodeStatus
+	}
+	if err = biz.cmdbw.FetchAndSave(ctx, mon.ID, mon.Inet); err != nil {
+		return err
+	}
+	_, err = biz.tagRule.Evaluate(ctx, []int64{mon.ID})
+
+	return err
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+type CommandExecService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.CommandExec)
+
+	// Exec 在单个节点上执行指令并等待执行结果
+	Exec(ctx context.Context, mid int64, cmd string, userID int64) (*entity.CommandExecItem, error)
+
+	// Create 在 dynsql 筛选出的节点上批量执行指令，后台执行，返回执行 ID
+	Create(ctx context.Context, req *param.CommandExecCreate, scope dynsql.Scope, likes []gen.Condition, userID int64) (int64, error)
+	Report(ctx context.Context, id int64) (*param.CommandExecReport, error)
+	Items(ctx context.Context, req *param.CommandExecItemPage, page param.Pager) (int64, []*entity.CommandExecItem)
+
+	// Reset 将 manager 重启前未执行完毕的记录标记为中断
+	Reset(ctx context.Context) error
+}
+
+func CommandExec(db *gorm.DB, pusher push.Pusher) CommandExecService {
+	return &commandExecService{
+		db:      db,
+		pusher:  pusher,
+		workers: 10,
+		maxsize: 64 * 1024,
+	}
+}
+
+type commandExecService struct {
+	db      *gorm.DB
+	pusher  push.Pusher
+	workers int // 每个 broker 并发执行的节点数
+	maxsize int // 保存的输出最大长度
+}
+
+func (biz *commandExecService) Page(ctx context.Context, page param.Pager) (int64, []*entity.CommandExec) {
+	db := biz.db.WithContext(ctx).Model(&entity.CommandExec{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("cmd LIKE ? OR keyword LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.CommandExec
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *commandExecService) Exec(ctx context.Context, mid int64, cmd string, userID int64) (*entity.CommandExecItem, error) {
+	monTbl := query.Minion
+	mon, err := monTbl.WithContext(ctx).
+		Select(monTbl.ID, monTbl.Inet, monTbl.Status, monTbl.BrokerID, monTbl.BrokerName).
+		Where(monTbl.ID.Eq(mid)).
+		First()
+	if err != nil {
+		return nil, err
+	}
+	if status := mon.Status; status != model.MSOnline && status != model.MSOffline {
+		return nil, errcode.ErrNodeStatus
+	}
+
+	now := time.Now()
+	exe := &entity.CommandExec{
+		Cmd:       cmd,
+		Status:    entity.ESRunning,
+		Total:     1,
+		CreatedID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	item := biz.newItem(mon, now)
+	if err = biz.save(ctx, exe, entity.CommandExecItems{item}); err != nil {
+		return nil, err
+	}
+
+	biz.execute(ctx, exe, item)
+	biz.summary(exe, entity.ESFinished)
+
+	return item, nil
+}
+
+func (biz *commandExecService) Create(ctx context.Context, req *param.CommandExecCreate, scope dynsql.Scope,
+	likes []gen.Condition, userID int64,
+) (int64, error) {
+	items, err := biz.selectMinions(ctx, scope, likes)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, errcode.ErrNoMatchedNode
+	}
+
+	filters, _ := json.Marshal(req.Input)
+	now := time.Now()
+	exe := &entity.CommandExec{
+		Cmd:       req.Cmd,
+		Filters:   filters,
+		Keyword:   req.Keyword,
+		Status:    entity.ESRunning,
+		Total:     len(items),
+		CreatedID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = biz.save(ctx, exe, items); err != nil {
+		return 0, err
+	}
+
+	// 批量执行耗时较长，不能使用 HTTP 请求的 context
+	go biz.run(context.Background(), exe, items)
+
+	return exe.ID, nil
+}
+
+func (biz *commandExecService) Report(ctx context.Context, id int64) (*param.CommandExecReport, error) {
+	exe := new(entity.CommandExec)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(exe).Error; err != nil {
+		return nil, err
+	}
+
+	ret := &param.CommandExecReport{CommandExec: exe}
+	if exe.Status == entity.ESRunning {
+		ret.Pending = exe.Total - exe.Succeed - exe.Failed
+	}
+
+	outputs := make([]*param.CommandOutputGroup, 0, 20)
+	biz.db.WithContext(ctx).
+		Model(&entity.CommandExecItem{}).
+		Select("digest", "MAX(output) AS output", "COUNT(*) AS count").
+		Where("exec_id = ? AND status = ?", id, entity.EISSucceed).
+		Group("digest").
+		Order("count DESC").
+		Limit(20).
+		Scan(&outputs)
+	ret.Outputs = outputs
+
+	return ret, nil
+}
+
+func (biz *commandExecService) Items(ctx context.Context, req *param.CommandExecItemPage, page param.Pager) (int64, []*entity.CommandExecItem) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.CommandExecItem{}).
+		Where("exec_id = ?", req.ID)
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.Digest != "" {
+		db = db.Where("digest = ?", req.Digest)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR broker_name LIKE ? OR output LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.CommandExecItem
+	db.Order("id").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *commandExecService) Reset(ctx context.Context) error {
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []int64
+		if err := tx.Model(&entity.CommandExec{}).
+			Where("status = ?", entity.ESRunning).
+			Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := tx.Model(&entity.CommandExecItem{}).
+			Where("exec_id IN ? AND status = ?", ids, entity.EISPending).
+			UpdateColumns(map[string]any{
+				"status":      entity.EISFailed,
+				"reason":      "manager 重启，执行中断",
+				"finished_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&entity.CommandExec{}).
+			Where("id IN ?", ids).
+			UpdateColumn("status", entity.ESInterrupted).
+			Error
+	})
+}
+
+func (biz *commandExecService) save(ctx context.Context, exe *entity.CommandExec, items entity.CommandExecItems) error {
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(exe).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			item.ExecID = exe.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+}
+
+func (biz *commandExecService) newItem(mon *model.Minion, now time.Time) *entity.CommandExecItem {
+	return &entity.CommandExecItem{
+		MinionID:   mon.ID,
+		Inet:       mon.Inet,
+		BrokerID:   mon.BrokerID,
+		BrokerName: mon.BrokerName,
+		Status:     entity.EISPending,
+		CreatedAt:  now,
+	}
+}
+
+// selectMinions 查询符合条件且未删除的节点
+func (biz *commandExecService) selectMinions(ctx context.Context, scope dynsql.Scope, likes []gen.Condition) (entity.CommandExecItems, error) {
+	const limit = 500
+	db := minionFilterDB(ctx, scope, likes)
+	monTbl := query.Minion
+	deleted := uint8(model.MSDelete)
+	now := time.Now()
+	ret := make(entity.CommandExecItems, 0, limit)
+
+	for offset := 0; ; offset += limit {
+		var mids []int64
+		if err := db.Offset(offset).Limit(limit).Scan(&mids).Error; err != nil {
+			return nil, err
+		}
+		if len(mids) == 0 {
+			break
+		}
+
+		mons, err := monTbl.WithContext(ctx).
+			Select(monTbl.ID, monTbl.Inet, monTbl.BrokerID, monTbl.BrokerName).
+			Where(monTbl.ID.In(mids...)).
+			Where(monTbl.Status.Neq(deleted)).
+			Find()
+		if err != nil {
+			return nil, err
+		}
+		for _, mon := range mons {
+			ret = append(ret, biz.newItem(mon, now))
+		}
+		if len(mids) < limit {
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+// run 按照 broker 分组并发执行，每个 broker 最多同时向 workers 个节点下发指令
+func (biz *commandExecService) run(ctx context.Context, exe *entity.CommandExec, items entity.CommandExecItems) {
+	wg := new(sync.WaitGroup)
+	for _, group := range items.BrokerMap() {
+		ch := make(chan *entity.CommandExecItem, len(group))
+		for _, item := range group {
+			ch <- item
+		}
+		close(ch)
+
+		workers := biz.workers
+		if workers > len(group) {
+			workers = len(group)
+		}
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item := range ch {
+					biz.execute(ctx, exe, item)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	biz.summary(exe, entity.ESFinished)
+}
+
+func (biz *commandExecService) execute(ctx context.Context, exe *entity.CommandExec, item *entity.CommandExecItem) {
+	if item.BrokerID == 0 {
+		biz.finish(item, entity.EISFailed, "", "节点未连接 broker")
+		return
+	}
+
+	// 只有节点明确响应执行成功才算成功
+	reply, err := biz.pusher.Exec(ctx, item.BrokerID, item.MinionID, exe.Cmd)
+	switch {
+	case err != nil:
+		biz.finish(item, entity.EISFailed, "", err.Error())
+	case !reply.Succeed:
+		reason := reply.Error
+		if reason == "" {
+			reason = "节点未响应执行成功"
+		}
+		biz.finish(item, entity.EISFailed, reply.Output, reason)
+	default:
+		biz.finish(item, entity.EISSucceed, reply.Output, "")
+	}
+}
+
+func (biz *commandExecService) finish(item *entity.CommandExecItem, status entity.ExecItemStatus, output, reason string) {
+	if len(output) > biz.maxsize {
+		output = strings.ToValidUTF8(output[:biz.maxsize], "")
+	}
+	sum := sha1.Sum([]byte(output))
+	item.Status = status
+	item.Output = output
+	item.Digest = hex.EncodeToString(sum[:])
+	item.Reason = truncate(reason, 512)
+	item.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	biz.db.Model(item).UpdateColumns(map[string]any{
+		"status":      item.Status,
+		"output":      item.Output,
+		"digest":      item.Digest,
+		"reason":      item.Reason,
+		"finished_at": item.FinishedAt,
+	})
+}
+
+// summary 统计执行结果并更新执行状态
+func (biz *commandExecService) summary(exe *entity.CommandExec, status entity.ExecStatus) {
+	var stats []struct {
+		Status entity.ExecItemStatus `gorm:"column:status"`
+		Count  int                   `gorm:"column:count"`
+	}
+	biz.db.Model(&entity.CommandExecItem{}).
+		Select("status", "COUNT(*) AS count").
+		Where("exec_id = ?", exe.ID).
+		Group("status").
+		Scan(&stats)
+
+	var succeed, failed int
+	for _, st := range stats {
+		switch st.Status {
+		case entity.EISSucceed:
+			succeed = st.Count
+		case entity.EISFailed:
+			failed = st.Count
+		}
+	}
+
+	now := time.Now()
+	exe.Status, exe.Succeed, exe.Failed = status, succeed, failed
+	assigns := map[string]any{
+		"status":     status,
+		"succeed":    succeed,
+		"failed":     failed,
+		"updated_at": now,
+	}
+	if status != entity.ESRunning {
+		exe.FinishedAt = sql.NullTime{Time: now, Valid: true}
+		assigns["finished_at"] = now
+	}
+	biz.db.Model(exe).UpdateColumns(assigns)
+}
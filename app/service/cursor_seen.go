@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cursorWindow 回扫窗口，事务提交延迟超过该时间的数据仍会漏掉。
+const cursorWindow = 2 * time.Minute
+
+// cursorSeen 雪花 ID 在事务提交前生成，ID 较小的数据可能在更大 ID 的数据之后才提交，只按
+// id > last 读取会永久漏掉这些数据。按入库时间回扫 cursorWindow 内 ID 不大于游标的数据，
+// 已处理的数据 ID 与游标在同一事务中保存到 cursor_seen 表用于去重，重启后同样有效。
+type cursorSeen struct {
+	db *gorm.DB
+}
+
+// late 查询 table 中回扫窗口内 ID 在 (floorID, lastID] 之间、且 key 没有处理过的数据 ID。
+// floorID 为游标开始收集时的 ID，早于它的数据本来就不需要处理。
+func (cs cursorSeen) late(ctx context.Context, key, table string, floorID, lastID int64, limit int) ([]int64, error) {
+	var ids []int64
+	err := cs.db.WithContext(ctx).
+		Table(table+" AS t").
+		Where("t.id > ? AND t.id <= ? AND t.created_at >= ?", floorID, lastID, time.Now().Add(-cursorWindow)).
+		Where("NOT EXISTS (SELECT 1 FROM cursor_seen cs WHERE cs.cursor_key = ? AND cs.record_id = t.id)", key).
+		Order("t.id").
+		Limit(limit).
+		Pluck("t.id", &ids).Error
+
+	return ids, err
+}
+
+// mark 记录 key 已处理的数据 ID，需要与游标在同一事务中保存。
+func (cs cursorSeen) mark(tx *gorm.DB, key string, ids []int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	dats := make([]*entity.CursorSeen, 0, len(ids))
+	for _, id := range ids {
+		dats = append(dats, &entity.CursorSeen{CursorKey: key, RecordID: id, CreatedAt: now})
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(dats, 500).Error
+}
+
+// prune 删除回扫窗口之外的记录，处理时间早于窗口的数据，入库时间也早于窗口，不会再被回扫到。
+func (cs cursorSeen) prune(ctx context.Context) error {
+	return cs.db.WithContext(ctx).
+		Where("created_at < ?", time.Now().Add(-cursorWindow)).
+		Delete(&entity.CursorSeen{}).Error
+}
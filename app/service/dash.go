@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/logback"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"gorm.io/gorm"
 )
 
 type DashService interface {
@@ -15,13 +18,31 @@ type DashService interface {
 	Evtlvl(ctx context.Context) *param.DashELevelResp
 	Risklvl(ctx context.Context) *param.DashRLevelResp
 	Risksts(ctx context.Context) *param.DashRiskstsResp
+
+	// Trend 按小时、天或周统计指标的趋势，数据来自后台维护的预聚合表。
+	Trend(ctx context.Context, req *param.DashTrend) (*param.DashTrendResp, error)
+
+	// Run 定时维护趋势统计的预聚合数据
+	Run(ctx context.Context)
 }
 
-func Dash() DashService {
-	return &dashService{}
+func Dash(db *gorm.DB, slog logback.Logger) DashService {
+	return &dashService{
+		db:       db,
+		slog:     slog,
+		interval: 5 * time.Minute,
+		recount:  2 * time.Hour,
+		backfill: 7 * 24 * time.Hour,
+	}
 }
 
-type dashService struct{}
+type dashService struct {
+	db       *gorm.DB
+	slog     logback.Logger
+	interval time.Duration // 预聚合的间隔
+	recount  time.Duration // 每次重新统计最近多长时间的数据，兼容延迟上报
+	backfill time.Duration // 首次运行时回填多长时间的数据
+}
 
 func (biz *dashService) Status(ctx context.Context) *param.DashStatusResp {
 	var tmp []*struct {
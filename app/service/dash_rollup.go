@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dashSource 统计指标的数据来源
+type dashSource struct {
+	table  string // 数据表
+	column string // 时间字段
+}
+
+// dashSources 按时间累计的统计指标，在线节点数是每小时采样，不在此列。
+var dashSources = map[string]dashSource{
+	entity.DashMetricRisk:  {table: "risk", column: "occur_at"},
+	entity.DashMetricEvent: {table: "event", column: "occur_at"},
+	entity.DashMetricLogon: {table: "minion_logon", column: "logon_at"},
+}
+
+// dashDimensions 细分维度对应的 SQL 表达式，t 为数据表，m 为 minion 表。
+var dashDimensions = map[string]string{
+	"":                           "''",
+	entity.DashDimensionBroker:   "m.broker_name",
+	entity.DashDimensionIDC:      "m.idc",
+	entity.DashDimensionIBu:      "m.ibu",
+	entity.DashDimensionRiskType: "t.risk_type",
+}
+
+// dashDimensionsOf 统计指标支持的细分维度，只有风险事件支持按风险类型细分。
+func dashDimensionsOf(metric string) []string {
+	dims := []string{"", entity.DashDimensionBroker, entity.DashDimensionIDC, entity.DashDimensionIBu}
+	if metric == entity.DashMetricRisk {
+		dims = append(dims, entity.DashDimensionRiskType)
+	}
+	return dims
+}
+
+// dashCountSQL 按细分维度计数的 SQL，表名、字段均为内部常量。
+// 按 COALESCE 后的值分组，NULL 与空字符串合并为一行，避免写入时违反唯一索引。
+// 不细分时不分组，没有数据也会返回一行 0，写入后作为下次统计的起点。
+func dashCountSQL(dim, from, where string) string {
+	expr := "COALESCE(" + dashDimensions[dim] + ", '')"
+	rawSQL := "SELECT " + expr + " AS name, COUNT(*) AS count FROM " + from + " WHERE " + where
+	if dim != "" {
+		rawSQL += " GROUP BY " + expr
+	}
+	return rawSQL
+}
+
+// dashHour 时间所在小时的开始时间
+func dashHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+func (biz *dashService) Trend(ctx context.Context, req *param.DashTrend) (*param.DashTrendResp, error) {
+	if req.Dimension == entity.DashDimensionRiskType && req.Metric != entity.DashMetricRisk {
+		return nil, errcode.ErrDashDimension
+	}
+	interval, size, top := req.Interval, req.Size, req.Top
+	if interval == "" {
+		interval = "day"
+	}
+	if top == 0 {
+		top = 10
+	}
+
+	// 计算每个时间段的开始时间
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var step func(time.Time, int) time.Time
+	var first time.Time
+	layout := time.DateOnly
+	switch interval {
+	case "hour":
+		if size == 0 {
+			size = 24
+		}
+		layout = "2006-01-02 15:04"
+		step = func(t time.Time, n int) time.Time { return t.Add(time.Duration(n) * time.Hour) }
+		first = step(dashHour(now), 1-size)
+	case "week":
+		if size == 0 {
+			size = 12
+		}
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) }
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		first = step(monday, 1-size)
+	default:
+		if size == 0 {
+			size = 30
+		}
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, n) }
+		first = step(today, 1-size)
+	}
+	times := make([]time.Time, 0, size)
+	res := &param.DashTrendResp{Times: make([]string, 0, size)}
+	for i := 0; i < size; i++ {
+		at := step(first, i)
+		times = append(times, at)
+		res.Times = append(res.Times, at.Format(layout))
+	}
+
+	// 按天统计时在数据库中先聚合，减少返回的行数。在线节点数取峰值，其它指标求和。
+	agg := "SUM(count)"
+	if req.Metric == entity.DashMetricOnline {
+		agg = "MAX(count)"
+	}
+	var rows []*struct {
+		At    string `gorm:"column:at"`
+		Name  string `gorm:"column:name"`
+		Count int64  `gorm:"column:count"`
+	}
+	at := "DATE_FORMAT(bucket, '%Y-%m-%d')"
+	if interval == "hour" {
+		at = "DATE_FORMAT(bucket, '%Y-%m-%d %H:00')"
+	}
+	biz.db.WithContext(ctx).
+		Model(&entity.DashRollup{}).
+		Select(at+" AS at", "name", agg+" AS count").
+		Where("metric = ? AND dimension = ? AND bucket >= ?", req.Metric, req.Dimension, first).
+		Group(at + ", name").
+		Scan(&rows)
+
+	series := make(map[string]*param.DashTrendSeries, 16)
+	for _, row := range rows {
+		bucket, err := time.ParseInLocation(layout, row.At, now.Location())
+		if err != nil || bucket.Before(first) {
+			continue
+		}
+		// 找到数据所在的时间段
+		idx := sort.Search(len(times), func(i int) bool { return times[i].After(bucket) }) - 1
+		if idx < 0 {
+			continue
+		}
+
+		name := row.Name
+		if req.Dimension == "" {
+			name = "total"
+		}
+		s := series[name]
+		if s == nil {
+			s = &param.DashTrendSeries{Name: name, Values: make([]int64, size)}
+			series[name] = s
+		}
+		if req.Metric == entity.DashMetricOnline {
+			if row.Count > s.Values[idx] {
+				s.Values[idx] = row.Count
+			}
+		} else {
+			s.Values[idx] += row.Count
+		}
+	}
+
+	for _, s := range series {
+		for _, v := range s.Values {
+			s.Total += v
+		}
+		res.Series = append(res.Series, s)
+	}
+	sort.Slice(res.Series, func(i, j int) bool {
+		a, b := res.Series[i], res.Series[j]
+		return a.Total > b.Total || a.Total == b.Total && a.Name < b.Name
+	})
+	if len(res.Series) > top {
+		other := &param.DashTrendSeries{Name: "其他", Values: make([]int64, size)}
+		for _, s := range res.Series[top:] {
+			other.Total += s.Total
+			for i, v := range s.Values {
+				other.Values[i] += v
+			}
+		}
+		res.Series = append(res.Series[:top], other)
+	}
+
+	return res, nil
+}
+
+func (biz *dashService) Run(ctx context.Context) {
+	biz.rollup(ctx)
+
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.rollup(ctx)
+		}
+	}
+}
+
+// rollup 重新统计最近几个小时的数据（兼容延迟上报的数据），并采样当前的在线节点数。
+// 首次运行时回填最近 backfill 时间内的数据。
+func (biz *dashService) rollup(ctx context.Context) {
+	current := dashHour(time.Now())
+	earliest := current.Add(-biz.backfill)
+
+	for metric, src := range dashSources {
+		var last sql.NullTime
+		biz.db.WithContext(ctx).
+			Model(&entity.DashRollup{}).
+			Select("MAX(bucket)").
+			Where("metric = ? AND dimension = ''", metric).
+			Scan(&last)
+
+		start := earliest
+		if last.Valid && last.Time.Add(-biz.recount).After(start) {
+			start = dashHour(last.Time.Add(-biz.recount))
+		}
+		for hour := start; !hour.After(current); hour = hour.Add(time.Hour) {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := biz.rollupHour(ctx, metric, src, hour); err != nil {
+				biz.slog.Warnf("统计 %s 在 %s 的趋势数据出错：%s", metric, hour.Format(time.DateTime), err)
+				break
+			}
+		}
+	}
+
+	if err := biz.rollupOnline(ctx, current); err != nil {
+		biz.slog.Warnf("统计在线节点趋势数据出错：%s", err)
+	}
+}
+
+// rollupHour 统计一个小时内的累计指标，先删除该小时的旧数据再写入。
+func (biz *dashService) rollupHour(ctx context.Context, metric string, src dashSource, hour time.Time) error {
+	now := time.Now()
+	var dats []*entity.DashRollup
+	for _, dim := range dashDimensionsOf(metric) {
+		from := src.table + " t"
+		if dim != "" && dim != entity.DashDimensionRiskType {
+			from += " LEFT JOIN minion m ON m.id = t.minion_id"
+		}
+		where := "t." + src.column + " >= ? AND t." + src.column + " < ?"
+		rawSQL := dashCountSQL(dim, from, where)
+
+		var rows []*param.NameCount
+		if err := biz.db.WithContext(ctx).
+			Raw(rawSQL, hour, hour.Add(time.Hour)).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			dats = append(dats, &entity.DashRollup{
+				Metric:    metric,
+				Bucket:    hour,
+				Dimension: dim,
+				Name:      row.Name,
+				Count:     int64(row.Count),
+				UpdatedAt: now,
+			})
+		}
+	}
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("metric = ? AND bucket = ?", metric, hour).
+			Delete(&entity.DashRollup{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(dats, 200).Error
+	})
+}
+
+// rollupOnline 采样当前的在线节点数，同一小时内保留峰值。
+func (biz *dashService) rollupOnline(ctx context.Context, hour time.Time) error {
+	now := time.Now()
+	metric := entity.DashMetricOnline
+	var dats []*entity.DashRollup
+	for _, dim := range dashDimensionsOf(metric) {
+		rawSQL := dashCountSQL(dim, "minion m", "m.status = ?")
+		var rows []*param.NameCount
+		if err := biz.db.WithContext(ctx).
+			Raw(rawSQL, model.MSOnline).
+			Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			dats = append(dats, &entity.DashRollup{
+				Metric:    metric,
+				Bucket:    hour,
+				Dimension: dim,
+				Name:      row.Name,
+				Count:     int64(row.Count),
+				UpdatedAt: now,
+			})
+		}
+	}
+
+	return biz.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"count":      gorm.Expr("GREATEST(count, VALUES(count))"),
+				"updated_at": now,
+			}),
+		}).
+		CreateInBatches(dats, 200).Error
+}
@@ -2,53 +2,296 @@ package service
 
 import (
 	"context"
+	"net"
+	"strconv"
 
-	"github.com/vela-ssoc/vela-common-mb/dal/model"
-	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/ciphertext"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/mailer"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
 )
 
 type EmailService interface {
-	Page(ctx context.Context, page param.Pager) (int64, []*model.Email)
-	Create(ctx context.Context) error
-	Update(ctx context.Context) error
-	Delete(ctx context.Context) error
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.Email)
+	Create(ctx context.Context, req *param.EmailCreate) error
+	Update(ctx context.Context, req *param.EmailUpdate) error
+	Delete(ctx context.Context, id int64) error
+
+	// Test 使用指定的邮件服务器发送测试邮件
+	Test(ctx context.Context, req *param.EmailTest) error
+
+	// Send 使用启用的邮件服务器发送邮件，用于 manager 端的通知
+	Send(ctx context.Context, msg *mailer.Message) error
+
+	// Seal 加密历史明文保存的邮箱密码，没有配置密钥时跳过
+	Seal(ctx context.Context) error
+
+	// Enabled 查询启用的邮件服务器配置，密码已解密，供 broker 通过连接通道拉取
+	Enabled(ctx context.Context) (*param.EmailServer, error)
 }
 
-func Email() EmailService {
-	return &emailService{}
+// Email secret 为邮箱密码的加密密钥，为空时不能保存邮箱密码。
+//
+// 密码加密存储，broker 不再直接读取 email 表，而是在收到 accord.FPNotifierReset
+// 后通过 broker 连接通道向 manager 拉取解密后的配置。
+func Email(db *gorm.DB, pusher push.Pusher, secret string) EmailService {
+	sealer, _ := ciphertext.NewSealer(secret)
+	return &emailService{
+		db:     db,
+		pusher: pusher,
+		sealer: sealer,
+	}
 }
 
-type emailService struct{}
+type emailService struct {
+	db     *gorm.DB
+	pusher push.Pusher
+	sealer *ciphertext.Sealer // 没有配置密钥时为 nil
+}
 
-func (biz *emailService) Page(ctx context.Context, page param.Pager) (int64, []*model.Email) {
-	tbl := query.Email
-	dao := tbl.WithContext(ctx).Order(tbl.Enable.Desc())
+func (biz *emailService) Page(ctx context.Context, page param.Pager) (int64, []*entity.Email) {
+	db := biz.db.WithContext(ctx).Model(&entity.Email{})
 	if kw := page.Keyword(); kw != "" {
-		dao.Where(tbl.Host.Like(kw)).
-			Or(tbl.Username.Like(kw))
+		db = db.Where("host LIKE ? OR username LIKE ?", kw, kw)
 	}
-	count, _ := dao.Count()
-	if count == 0 {
+	var count int64
+	if db.Count(&count); count == 0 {
 		return 0, nil
 	}
 
-	dats, _ := dao.Scopes(page.Scope(count)).Find()
+	var dats []*entity.Email
+	db.Order("enable DESC, id").Scopes(page.DBScope(count)).Find(&dats)
 
 	return count, dats
 }
 
-func (biz *emailService) Create(ctx context.Context) error {
-	// TODO implement me
-	panic("implement me")
+func (biz *emailService) Create(ctx context.Context, req *param.EmailCreate) error {
+	if req.Password == "" {
+		return errcode.ErrRequiredPassword
+	}
+	if biz.sealer == nil {
+		return errcode.ErrEmailSecret
+	}
+	dat := &entity.Email{
+		Host:     req.Host,
+		Port:     req.Port,
+		TLSMode:  req.TLSMode,
+		Username: req.Username,
+		Password: biz.sealer.Seal(req.Password),
+		Sender:   req.Sender,
+		Enable:   req.Enable,
+	}
+	if !req.Enable {
+		return biz.db.WithContext(ctx).Create(dat).Error
+	}
+
+	// 同一时刻只能启用一个邮件服务器
+	if err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Email{}).
+			Where("enable = ?", true).
+			UpdateColumn("enable", false).Error; err != nil {
+			return err
+		}
+		return tx.Create(dat).Error
+	}); err != nil {
+		return err
+	}
+
+	// 重置所有节点的邮件配置
+	biz.pusher.EmailReset(ctx)
+
+	return nil
+}
+
+func (biz *emailService) Update(ctx context.Context, req *param.EmailUpdate) error {
+	old := new(entity.Email)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(old).Error; err != nil {
+		return err
+	}
+
+	cols := map[string]any{
+		"host":     req.Host,
+		"port":     req.Port,
+		"tls_mode": req.TLSMode,
+		"username": req.Username,
+		"sender":   req.Sender,
+		"enable":   req.Enable,
+	}
+	if req.Password != "" {
+		if biz.sealer == nil {
+			return errcode.ErrEmailSecret
+		}
+		cols["password"] = biz.sealer.Seal(req.Password)
+	}
+	if !req.Enable {
+		if err := biz.db.WithContext(ctx).
+			Model(old).
+			UpdateColumns(cols).Error; err != nil || !old.Enable {
+			return err
+		}
+		biz.pusher.EmailReset(ctx)
+		return nil
+	}
+
+	if err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Email{}).
+			Where("enable = ? AND id <> ?", true, req.ID).
+			UpdateColumn("enable", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(old).UpdateColumns(cols).Error
+	}); err != nil {
+		return err
+	}
+
+	// 重置所有节点的邮件配置
+	biz.pusher.EmailReset(ctx)
+
+	return nil
+}
+
+func (biz *emailService) Delete(ctx context.Context, id int64) error {
+	dat := new(entity.Email)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(dat).Error; err != nil {
+		return err
+	}
+	if err := biz.db.WithContext(ctx).
+		Delete(dat).Error; err != nil || !dat.Enable {
+		return err
+	}
+
+	// 重置所有节点的邮件配置
+	biz.pusher.EmailReset(ctx)
+
+	return nil
+}
+
+func (biz *emailService) Test(ctx context.Context, req *param.EmailTest) error {
+	dat := new(entity.Email)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(dat).Error; err != nil {
+		return err
+	}
+
+	msg := &mailer.Message{
+		To:      []string{req.To},
+		Subject: "[ssoc] 测试邮件",
+		Body:    "这是一封测试邮件，收到此邮件说明邮件服务器 " + dat.Host + " 配置正确。",
+	}
+
+	return biz.send(ctx, dat, msg)
+}
+
+func (biz *emailService) Send(ctx context.Context, msg *mailer.Message) error {
+	dat := new(entity.Email)
+	if biz.db.WithContext(ctx).
+		Where("enable = ?", true).
+		Limit(1).
+		Find(dat); dat.ID == 0 {
+		return errcode.ErrEmailDisabled
+	}
+
+	return biz.send(ctx, dat, msg)
 }
 
-func (biz *emailService) Update(ctx context.Context) error {
-	// TODO implement me
-	panic("implement me")
+func (biz *emailService) Seal(ctx context.Context) error {
+	if biz.sealer == nil {
+		return nil
+	}
+
+	var dats []*entity.Email
+	if err := biz.db.WithContext(ctx).
+		Where("password NOT LIKE ?", "aes:%").
+		Find(&dats).Error; err != nil || len(dats) == 0 {
+		return err
+	}
+	for _, dat := range dats {
+		if err := biz.db.WithContext(ctx).
+			Model(dat).
+			UpdateColumn("password", biz.sealer.Seal(dat.Password)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (biz *emailService) Delete(ctx context.Context) error {
-	// TODO implement me
-	panic("implement me")
+func (biz *emailService) Enabled(ctx context.Context) (*param.EmailServer, error) {
+	dat := new(entity.Email)
+	if biz.db.WithContext(ctx).
+		Where("enable = ?", true).
+		Limit(1).
+		Find(dat); dat.ID == 0 {
+		return nil, errcode.ErrEmailDisabled
+	}
+	srv, err := biz.server(dat)
+	if err != nil {
+		return nil, err
+	}
+	ret := &param.EmailServer{
+		Host:     srv.Host,
+		Port:     srv.Port,
+		TLSMode:  srv.TLSMode,
+		Username: srv.Username,
+		Password: srv.Password,
+		Sender:   srv.Sender,
+	}
+
+	return ret, nil
+}
+
+func (biz *emailService) send(ctx context.Context, dat *entity.Email, msg *mailer.Message) error {
+	srv, err := biz.server(dat)
+	if err != nil {
+		return err
+	}
+	if err = mailer.Send(ctx, srv, msg); err != nil {
+		return errcode.FmtErrEmailSend.Fmt(err)
+	}
+
+	return nil
+}
+
+// server 解密密码并整理为邮件服务器配置，没有密文前缀的密码视为历史明文。
+func (biz *emailService) server(dat *entity.Email) (mailer.Server, error) {
+	passwd := dat.Password
+	if ciphertext.Sealed(passwd) {
+		if biz.sealer == nil {
+			return mailer.Server{}, errcode.ErrEmailDecrypt
+		}
+		var err error
+		if passwd, err = biz.sealer.Open(passwd); err != nil {
+			return mailer.Server{}, errcode.ErrEmailDecrypt
+		}
+	}
+
+	srv := mailer.Server{
+		Host:     dat.Host,
+		Port:     dat.Port,
+		TLSMode:  dat.TLSMode,
+		Username: dat.Username,
+		Password: passwd,
+		Sender:   dat.Sender,
+	}
+	// 兼容历史数据：没有端口时 host 可能是 host:port 格式
+	if srv.Port == 0 {
+		srv.Port = 25
+		if h, p, exx := net.SplitHostPort(dat.Host); exx == nil {
+			srv.Host = h
+			srv.Port, _ = strconv.Atoi(p)
+		}
+		if srv.TLSMode == "" && srv.Port == 465 {
+			srv.TLSMode = mailer.TLSImplicit
+		}
+	}
+
+	return srv, nil
 }
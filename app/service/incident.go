@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// IncidentService 将相关的风险事件自动聚合为安全事件
+type IncidentService interface {
+	Page(ctx context.Context, req *param.IncidentPage, page param.Pager) (int64, []*entity.Incident)
+	Detail(ctx context.Context, id int64) (*param.IncidentDetail, error)
+	Risks(ctx context.Context, id int64, page param.Pager) (int64, []*model.Risk)
+
+	// Status 修改事件状态，关闭事件时会将成员风险批量标记为已处理。
+	Status(ctx context.Context, req *param.IncidentStatus, userID int64) error
+	Merge(ctx context.Context, req *param.IncidentMerge, userID int64) error
+	Split(ctx context.Context, req *param.IncidentSplit, userID int64) error
+	Setting(ctx context.Context) *param.IncidentSetting
+	SetSetting(ctx context.Context, req *param.IncidentSetting) error
+
+	// Run 定时将尚未归属事件的风险关联到事件中
+	Run(ctx context.Context)
+}
+
+func Incident(db *gorm.DB, rcase RiskCaseService, slog logback.Logger) IncidentService {
+	return &incidentService{
+		db:       db,
+		rcase:    rcase,
+		slog:     slog,
+		interval: time.Minute,
+	}
+}
+
+type incidentService struct {
+	db       *gorm.DB
+	rcase    RiskCaseService
+	slog     logback.Logger
+	interval time.Duration
+}
+
+// incidentSettingID 风险关联设置在 store 中的 ID
+const incidentSettingID = "global.incident.correlation"
+
+func (biz *incidentService) Page(ctx context.Context, req *param.IncidentPage, page param.Pager) (int64, []*entity.Incident) {
+	db := biz.db.WithContext(ctx).Model(&entity.Incident{})
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("title LIKE ? OR corr_key LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.Incident
+	db.Order("last_at DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *incidentService) Detail(ctx context.Context, id int64) (*param.IncidentDetail, error) {
+	inc := new(entity.Incident)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(inc).Error; err != nil {
+		return nil, err
+	}
+
+	ret := &param.IncidentDetail{Incident: inc, Timeline: []*entity.IncidentTimeline{}}
+	biz.db.WithContext(ctx).
+		Where("incident_id = ?", id).
+		Order("id").
+		Find(&ret.Timeline)
+
+	return ret, nil
+}
+
+func (biz *incidentService) Risks(ctx context.Context, id int64, page param.Pager) (int64, []*model.Risk) {
+	db := biz.db.WithContext(ctx).
+		Model(&model.Risk{}).
+		Where("id IN (?)", biz.members(id))
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*model.Risk
+	db.Order("occur_at").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *incidentService) Status(ctx context.Context, req *param.IncidentStatus, userID int64) error {
+	inc := new(entity.Incident)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(inc).Error; err != nil {
+		return err
+	}
+	if inc.Status == req.Status {
+		return nil
+	}
+
+	now := time.Now()
+	assigns := map[string]any{"status": req.Status, "updated_at": now}
+	if req.Status == entity.IncClosed {
+		assigns["closed_id"] = userID
+		assigns["closed_at"] = now
+	}
+	content := fmt.Sprintf("状态由 %s 变更为 %s", inc.Status, req.Status)
+	if req.Reason != "" {
+		content += "：" + req.Reason
+	}
+	if err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(inc).UpdateColumns(assigns).Error; err != nil {
+			return err
+		}
+		return biz.timeline(tx, inc.ID, userID, "status", content, now)
+	}); err != nil {
+		return err
+	}
+	if req.Status != entity.IncClosed {
+		return nil
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "关闭安全事件：" + inc.Title
+	}
+	members := biz.members(inc.ID)
+	where := func(db *gorm.DB) *gorm.DB {
+		return db.Where("id IN (?)", members)
+	}
+
+	return biz.rcase.Transit(ctx, where, model.RSProcessed, reason, userID)
+}
+
+func (biz *incidentService) Merge(ctx context.Context, req *param.IncidentMerge, userID int64) error {
+	var incs []*entity.Incident
+	if err := biz.db.WithContext(ctx).
+		Where("id IN ?", req.IDs).
+		Order("first_at").
+		Find(&incs).Error; err != nil {
+		return err
+	}
+	if len(incs) != len(req.IDs) {
+		return errcode.ErrOperateFailed
+	}
+
+	// 合并到最早发生的事件中
+	target, others := incs[0], incs[1:]
+	otherIDs := make([]int64, 0, len(others))
+	titles := make([]string, 0, len(others))
+	for _, inc := range others {
+		otherIDs = append(otherIDs, inc.ID)
+		titles = append(titles, inc.Title)
+	}
+
+	now := time.Now()
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.IncidentRisk{}).
+			Where("incident_id IN ?", otherIDs).
+			UpdateColumn("incident_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.IncidentTimeline{}).
+			Where("incident_id IN ?", otherIDs).
+			UpdateColumn("incident_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", otherIDs).
+			Delete(&entity.Incident{}).Error; err != nil {
+			return err
+		}
+		if req.Title != "" {
+			if err := tx.Model(target).
+				UpdateColumn("title", req.Title).Error; err != nil {
+				return err
+			}
+		}
+		content := "合并事件：" + strings.Join(titles, "、")
+		if err := biz.timeline(tx, target.ID, userID, "merge", content, now); err != nil {
+			return err
+		}
+
+		return biz.refresh(tx, target.ID, now)
+	})
+}
+
+func (biz *incidentService) Split(ctx context.Context, req *param.IncidentSplit, userID int64) error {
+	src := new(entity.Incident)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(src).Error; err != nil {
+		return err
+	}
+
+	var count int64
+	biz.db.WithContext(ctx).
+		Model(&entity.IncidentRisk{}).
+		Where("incident_id = ?", src.ID).
+		Count(&count)
+	if int(count) <= len(req.RiskIDs) {
+		return errcode.ErrIncidentSplit
+	}
+
+	now := time.Now()
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		dst := &entity.Incident{
+			Title:     req.Title,
+			Level:     src.Level,
+			Status:    src.Status,
+			FirstAt:   src.FirstAt,
+			LastAt:    src.LastAt,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := tx.Create(dst).Error; err != nil {
+			return err
+		}
+		ret := tx.Model(&entity.IncidentRisk{}).
+			Where("incident_id = ? AND risk_id IN ?", src.ID, []int64(req.RiskIDs)).
+			UpdateColumn("incident_id", dst.ID)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if ret.RowsAffected != int64(len(req.RiskIDs)) {
+			return errcode.ErrIncidentSplit
+		}
+
+		srcMsg := fmt.Sprintf("拆分出 %d 条风险到事件：%s", len(req.RiskIDs), dst.Title)
+		if err := biz.timeline(tx, src.ID, userID, "split", srcMsg, now); err != nil {
+			return err
+		}
+		dstMsg := "从事件拆分：" + src.Title
+		if err := biz.timeline(tx, dst.ID, userID, "split", dstMsg, now); err != nil {
+			return err
+		}
+		if err := biz.refresh(tx, src.ID, now); err != nil {
+			return err
+		}
+
+		return biz.refresh(tx, dst.ID, now)
+	})
+}
+
+func (biz *incidentService) Setting(ctx context.Context) *param.IncidentSetting {
+	ret := &param.IncidentSetting{Enabled: true, Keys: []string{"remote_ip", "risk_type"}, Window: 3600}
+	storeLoadJSON(ctx, incidentSettingID, ret)
+
+	return ret
+}
+
+func (biz *incidentService) SetSetting(ctx context.Context, req *param.IncidentSetting) error {
+	return storeSaveJSON(ctx, incidentSettingID, "风险事件自动关联设置", req)
+}
+
+func (biz *incidentService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := biz.correlate(ctx); err != nil {
+				biz.slog.Warnf("风险事件关联出错：%s", err)
+			}
+		}
+	}
+}
+
+// correlate 关联最近一天内尚未归属事件的风险，关联键相同且在时间窗口内有新风险的事件会被复用。
+func (biz *incidentService) correlate(ctx context.Context) error {
+	setting := biz.Setting(ctx)
+	if !setting.Enabled || len(setting.Keys) == 0 {
+		return nil
+	}
+	window := time.Duration(setting.Window) * time.Second
+	since := time.Now().Add(-24 * time.Hour)
+
+	var lastID int64
+	for {
+		var risks []*model.Risk
+		if err := biz.db.WithContext(ctx).
+			Where("id > ? AND created_at >= ?", lastID, since).
+			Where("NOT EXISTS (SELECT 1 FROM incident_risk WHERE incident_risk.risk_id = risk.id)").
+			Order("id").
+			Limit(500).
+			Find(&risks).Error; err != nil {
+			return err
+		}
+		if len(risks) == 0 {
+			return nil
+		}
+		lastID = risks[len(risks)-1].ID
+
+		for _, rsk := range risks {
+			if err := biz.attach(ctx, rsk, setting.Keys, window); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (biz *incidentService) attach(ctx context.Context, rsk *model.Risk, keys []string, window time.Duration) error {
+	corrKey, title := biz.corrKey(rsk, keys)
+	now := time.Now()
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		inc := new(entity.Incident)
+		tx.Where("corr_key = ? AND status <> ?", corrKey, entity.IncClosed).
+			Where("last_at >= ?", rsk.OccurAt.Add(-window)).
+			Order("id DESC").
+			Limit(1).
+			Find(inc)
+		if inc.ID == 0 {
+			inc = &entity.Incident{
+				Title:     title,
+				CorrKey:   corrKey,
+				Level:     rsk.Level,
+				Status:    entity.IncOpen,
+				FirstAt:   rsk.OccurAt,
+				LastAt:    rsk.OccurAt,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := tx.Create(inc).Error; err != nil {
+				return err
+			}
+			if err := biz.timeline(tx, inc.ID, 0, "create", "根据 "+corrKey+" 自动创建", now); err != nil {
+				return err
+			}
+		}
+
+		member := &entity.IncidentRisk{IncidentID: inc.ID, RiskID: rsk.ID, CreatedAt: now}
+		if err := tx.Create(member).Error; err != nil {
+			return err
+		}
+
+		return biz.grow(tx, inc, rsk, now)
+	})
+}
+
+// grow 根据新关联的风险增量更新事件的统计数据，避免每关联一条风险都重新统计全部成员。
+func (biz *incidentService) grow(tx *gorm.DB, inc *entity.Incident, rsk *model.Risk, now time.Time) error {
+	assigns := map[string]any{"risk_count": gorm.Expr("risk_count + 1"), "updated_at": now}
+	if rsk.OccurAt.Before(inc.FirstAt) {
+		assigns["first_at"] = rsk.OccurAt
+	}
+	if rsk.OccurAt.After(inc.LastAt) {
+		assigns["last_at"] = rsk.OccurAt
+	}
+	if biz.severe(rsk.Level, inc.Level) {
+		assigns["level"] = rsk.Level
+	}
+
+	return tx.Model(&entity.Incident{}).
+		Where("id = ?", inc.ID).
+		UpdateColumns(assigns).Error
+}
+
+// corrKey 根据关联字段生成关联键与事件标题
+func (*incidentService) corrKey(rsk *model.Risk, keys []string) (string, string) {
+	pairs := make([]string, 0, len(keys))
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		var val, show string
+		switch key {
+		case "remote_ip":
+			val = rsk.RemoteIP
+		case "minion_id":
+			// 标题中使用节点 IP 更直观
+			val, show = strconv.FormatInt(rsk.MinionID, 10), rsk.Inet
+		case "risk_type":
+			val = rsk.RiskType
+		case "subject":
+			val = rsk.Subject
+		}
+		if show == "" {
+			show = val
+		}
+		pairs = append(pairs, key+"="+val)
+		values = append(values, show)
+	}
+
+	return strings.Join(pairs, ","), strings.Join(values, " / ")
+}
+
+// refresh 根据成员风险重新计算事件的风险数量、级别与发生时间
+// refresh 重新统计全部成员，只在合并与拆分时使用。
+func (biz *incidentService) refresh(tx *gorm.DB, id int64, now time.Time) error {
+	var stat struct {
+		Count   int          `gorm:"column:count"`
+		FirstAt sql.NullTime `gorm:"column:first_at"`
+		LastAt  sql.NullTime `gorm:"column:last_at"`
+	}
+	if err := tx.Model(&model.Risk{}).
+		Select("COUNT(*) AS count", "MIN(occur_at) AS first_at", "MAX(occur_at) AS last_at").
+		Where("id IN (?)", biz.membersTx(tx, id)).
+		Scan(&stat).Error; err != nil {
+		return err
+	}
+
+	var levels []model.RiskLevel
+	tx.Model(&model.Risk{}).
+		Distinct("level").
+		Where("id IN (?)", biz.membersTx(tx, id)).
+		Pluck("level", &levels)
+	var level model.RiskLevel
+	for _, lvl := range levels {
+		if biz.severe(lvl, level) {
+			level = lvl
+		}
+	}
+
+	assigns := map[string]any{"risk_count": stat.Count, "level": level, "updated_at": now}
+	if stat.FirstAt.Valid {
+		assigns["first_at"] = stat.FirstAt.Time
+		assigns["last_at"] = stat.LastAt.Time
+	}
+
+	return tx.Model(&entity.Incident{}).
+		Where("id = ?", id).
+		UpdateColumns(assigns).Error
+}
+
+// severe 判断风险级别 a 是否比 b 更严重，RiskLevel 的取值与严重程度并不一致。
+func (*incidentService) severe(a, b model.RiskLevel) bool {
+	rank := map[model.RiskLevel]int{
+		model.RLvlLow:      1,
+		model.RLvlMiddle:   2,
+		model.RLvlHigh:     3,
+		model.RLvlCritical: 4,
+	}
+	return rank[a] > rank[b]
+}
+
+func (biz *incidentService) timeline(tx *gorm.DB, id, userID int64, action, content string, now time.Time) error {
+	dat := &entity.IncidentTimeline{
+		IncidentID: id,
+		UserID:     userID,
+		Action:     action,
+		Content:    content,
+		CreatedAt:  now,
+	}
+	return tx.Create(dat).Error
+}
+
+// members 事件成员风险 ID 的子查询
+func (biz *incidentService) members(id int64) *gorm.DB {
+	return biz.membersTx(biz.db, id)
+}
+
+func (*incidentService) membersTx(db *gorm.DB, id int64) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&entity.IncidentRisk{}).
+		Select("risk_id").
+		Where("incident_id = ?", id)
+}
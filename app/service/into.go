@@ -9,22 +9,27 @@ import (
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"github.com/vela-ssoc/vela-common-mb/problem"
 	"github.com/vela-ssoc/vela-common-mba/netutil"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/bridge/linkhub"
 	"github.com/vela-ssoc/vela-manager/errcode"
 )
 
 type IntoService interface {
-	BRR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error
-	BWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error
-	ARR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error
-	AWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error
+	BRR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error
+	BWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error
+	ARR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error
+	AWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error
 }
 
-func Into(hub linkhub.Huber) IntoService {
+func Into(hub linkhub.Huber, record SessionRecordService, policy ProxyPolicyService, audit ProxyAuditService) IntoService {
 	name := hub.Name()
 	ito := &intoService{
-		name: name,
-		hub:  hub,
+		name:   name,
+		hub:    hub,
+		record: record,
+		policy: policy,
+		audit:  audit,
 	}
 	upgrade := netutil.Upgrade(ito.upgradeErrorFunc)
 	ito.upgrade = upgrade
@@ -35,10 +40,13 @@ func Into(hub linkhub.Huber) IntoService {
 type intoService struct {
 	name    string
 	hub     linkhub.Huber
+	record  SessionRecordService
+	policy  ProxyPolicyService
+	audit   ProxyAuditService
 	upgrade websocket.Upgrader
 }
 
-func (ito *intoService) BRR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error {
+func (ito *intoService) BRR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error {
 	bid, err := strconv.ParseInt(node, 10, 64)
 	if err != nil {
 		return errcode.ErrNodeNotExist
@@ -53,13 +61,19 @@ func (ito *intoService) BRR(ctx context.Context, w http.ResponseWriter, r *http.
 	if err != nil {
 		return errcode.ErrNodeNotExist
 	}
+	acc.BrokerID = broker.ID
+	if err = ito.policy.Check(ctx, acc); err != nil {
+		return err
+	}
 
-	ito.hub.Forward(broker.ID, w, r)
+	ito.audit.Audit(ctx, w, r, acc, func(aw http.ResponseWriter) {
+		ito.hub.Forward(broker.ID, aw, r)
+	})
 
 	return nil
 }
 
-func (ito *intoService) BWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error {
+func (ito *intoService) BWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error {
 	bid, err := strconv.ParseInt(node, 10, 64)
 	if err != nil {
 		return errcode.ErrNodeNotExist
@@ -74,6 +88,10 @@ func (ito *intoService) BWS(ctx context.Context, w http.ResponseWriter, r *http.
 	if err != nil {
 		return errcode.ErrNodeNotExist
 	}
+	acc.BrokerID = broker.ID
+	if err = ito.policy.Check(ctx, acc); err != nil {
+		return err
+	}
 
 	path := r.URL.Path
 	up, _, err := ito.hub.Stream(ctx, broker.ID, path, nil)
@@ -87,15 +105,21 @@ func (ito *intoService) BWS(ctx context.Context, w http.ResponseWriter, r *http.
 		return err
 	}
 
-	netutil.PipeWebsocket(up, down)
+	rec := &entity.SessionRecord{
+		Kind:     "bws",
+		UserID:   acc.UserID,
+		BrokerID: broker.ID,
+		Path:     path,
+	}
+	ito.record.Pipe(ctx, up, down, rec)
 
 	return nil
 }
 
-func (ito *intoService) ARR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error {
+func (ito *intoService) ARR(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error {
 	mon := query.Minion
 	db := mon.WithContext(ctx).
-		Select(mon.ID, mon.BrokerID).
+		Select(mon.ID, mon.Inet, mon.BrokerID).
 		Where(mon.Inet.Eq(node))
 	if mid, _ := strconv.ParseInt(node, 10, 64); mid != 0 {
 		db.Or(mon.ID.Eq(mid))
@@ -104,17 +128,23 @@ func (ito *intoService) ARR(ctx context.Context, w http.ResponseWriter, r *http.
 	if err != nil {
 		return errcode.ErrNodeNotExist
 	}
+	acc.MinionID, acc.BrokerID, acc.Inet = minion.ID, minion.BrokerID, minion.Inet
+	if err = ito.policy.Check(ctx, acc); err != nil {
+		return err
+	}
 
 	r.Header.Set(linkhub.HeaderXNodeID, strconv.FormatInt(minion.ID, 10))
-	ito.hub.Forward(minion.BrokerID, w, r)
+	ito.audit.Audit(ctx, w, r, acc, func(aw http.ResponseWriter) {
+		ito.hub.Forward(minion.BrokerID, aw, r)
+	})
 
 	return nil
 }
 
-func (ito *intoService) AWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string) error {
+func (ito *intoService) AWS(ctx context.Context, w http.ResponseWriter, r *http.Request, node string, acc *param.ProxyAccess) error {
 	mon := query.Minion
 	db := mon.WithContext(ctx).
-		Select(mon.ID, mon.BrokerID).
+		Select(mon.ID, mon.Inet, mon.BrokerID).
 		Where(mon.Inet.Eq(node))
 	if mid, _ := strconv.ParseInt(node, 10, 64); mid != 0 {
 		db.Or(mon.ID.Eq(mid))
@@ -123,6 +153,10 @@ func (ito *intoService) AWS(ctx context.Context, w http.ResponseWriter, r *http.
 	if err != nil {
 		return errcode.ErrNodeNotExist
 	}
+	acc.MinionID, acc.BrokerID, acc.Inet = minion.ID, minion.BrokerID, minion.Inet
+	if err = ito.policy.Check(ctx, acc); err != nil {
+		return err
+	}
 
 	header := http.Header{linkhub.HeaderXNodeID: []string{strconv.FormatInt(minion.ID, 10)}}
 	path := r.URL.Path
@@ -137,7 +171,15 @@ func (ito *intoService) AWS(ctx context.Context, w http.ResponseWriter, r *http.
 		return err
 	}
 
-	netutil.PipeWebsocket(up, down)
+	rec := &entity.SessionRecord{
+		Kind:     "aws",
+		UserID:   acc.UserID,
+		MinionID: minion.ID,
+		BrokerID: minion.BrokerID,
+		Inet:     minion.Inet,
+		Path:     path,
+	}
+	ito.record.Pipe(ctx, up, down, rec)
 
 	return nil
 }
@@ -14,31 +14,34 @@ import (
 	"github.com/vela-ssoc/vela-manager/errcode"
 	"gorm.io/gen"
 	"gorm.io/gen/field"
+	"gorm.io/gorm"
 )
 
 type MinionService interface {
 	Page(ctx context.Context, page param.Pager, scope dynsql.Scope, likes []gen.Condition) (int64, []*param.MinionSummary)
 	Detail(ctx context.Context, id int64) (*param.MinionDetail, error)
-	Drop(ctx context.Context, id int64) error
 	Create(ctx context.Context, mc *param.MinionCreate) error
 	Delete(ctx context.Context, scope dynsql.Scope, likes []gen.Condition) error
-	CSV(ctx context.Context) sheet.CSVStreamer
+	Export(ctx context.Context, format string, scope dynsql.Scope, likes []gen.Condition) sheet.CSVStreamer
 	Upgrade(ctx context.Context, id int64, semver model.Semver) error
 	Batch(ctx context.Context, scope dynsql.Scope, likes []gen.Condition) error
-	Command(ctx context.Context, mid int64, cmd string) error
 	Unload(ctx context.Context, mid int64, unload bool) error
 }
 
-func Minion(cmdbw cmdb.Client, pusher push.Pusher) MinionService {
+func Minion(cmdbw cmdb.Client, pusher push.Pusher, offline MinionOfflineService, tagRule TagRuleService) MinionService {
 	return &minionService{
-		cmdbw:  cmdbw,
-		pusher: pusher,
+		cmdbw:   cmdbw,
+		pusher:  pusher,
+		offline: offline,
+		tagRule: tagRule,
 	}
 }
 
 type minionService struct {
-	cmdbw  cmdb.Client
-	pusher push.Pusher
+	cmdbw   cmdb.Client
+	pusher  push.Pusher
+	offline MinionOfflineService
+	tagRule TagRuleService
 }
 
 func (biz *minionService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope, likes []gen.Condition) (int64, []*param.MinionSummary) {
@@ -138,110 +141,31 @@ func (biz *minionService) Detail(ctx context.Context, id int64) (*param.MinionDe
 	return dat, nil
 }
 
-func (biz *minionService) Drop(ctx context.Context, id int64) error {
-	tbl := query.Minion
-	mon, err := tbl.WithContext(ctx).Where(tbl.ID.Eq(id)).First()
+func (biz *minionService) Create(ctx context.Context, mc *param.MinionCreate) error {
+	ipv4, err := minionInet(ctx, mc.Inet)
 	if err != nil {
 		return err
 	}
-	if mon.Status != model.MSDelete {
-		return errcode.ErrDeleteFailed
-	}
 
-	// 查询该节点关联的标签
-	var tags []string
-	tagTbl := query.MinionTag
-	if err = tagTbl.WithContext(ctx).
-		Distinct(tagTbl.Tag).
-		Where(tagTbl.MinionID.Eq(id)).
-		Scan(&tags); err != nil {
-		return err
+	mon := &model.Minion{
+		Inet: ipv4,
+		Goos: mc.Goos,
+		Arch: mc.Arch,
 	}
 
-	subTbl := query.Substance
-	if err = query.Q.Transaction(func(tx *query.Query) error {
-		if _, exx := tx.WithContext(ctx).MinionTag.
-			Where(tagTbl.MinionID.Eq(id)).Delete(); exx != nil {
-			return exx
-		}
-		if _, exx := tx.WithContext(ctx).Substance.
-			Where(subTbl.MinionID.Eq(id)).Delete(); exx != nil {
-			return exx
-		}
-
-		_, exx := tx.WithContext(ctx).Minion.Where(tbl.ID.Eq(id)).Delete()
-		return exx
-	}); err != nil {
+	if err = createMinion(ctx, biz.cmdbw, mon, nil); err != nil {
 		return err
 	}
+	_, _ = biz.tagRule.Evaluate(ctx, []int64{mon.ID})
 
-	cmdbTbl := query.Cmdb
-	_, _ = cmdbTbl.WithContext(ctx).Where(cmdbTbl.ID.Eq(id)).Delete()
-	infTbl := query.SysInfo
-	_, _ = infTbl.WithContext(ctx).Where(infTbl.ID.Eq(id)).Delete()
-	evtTbl := query.Event
-	_, _ = evtTbl.WithContext(ctx).Where(evtTbl.MinionID.Eq(id)).Delete()
-	rskTbl := query.Risk
-	_, _ = rskTbl.WithContext(ctx).Where(rskTbl.MinionID.Eq(id)).Delete()
-
-	accTbl := query.MinionAccount
-	_, _ = accTbl.WithContext(ctx).Where(accTbl.MinionID.Eq(id)).Delete()
-	grpTbl := query.MinionGroup
-	_, _ = grpTbl.WithContext(ctx).Where(grpTbl.MinionID.Eq(id)).Delete()
-	lisTbl := query.MinionListen
-	_, _ = lisTbl.WithContext(ctx).Where(lisTbl.MinionID.Eq(id)).Delete()
-	lonTbl := query.MinionListen
-	_, _ = lonTbl.WithContext(ctx).Where(lonTbl.MinionID.Eq(id)).Delete()
-	procTbl := query.MinionProcess
-	_, _ = procTbl.WithContext(ctx).Where(procTbl.MinionID.Eq(id)).Delete()
-	taskTbl := query.MinionTask
-	_, _ = taskTbl.WithContext(ctx).Where(taskTbl.MinionID.Eq(id)).Delete()
-
-	// 清理该节点的 SBOM 信息
-	bomMonTbl := query.SBOMMinion
-	_, _ = bomMonTbl.WithContext(ctx).Where(bomMonTbl.ID.Eq(id)).Delete()
-	bomPjtTbl := query.SBOMProject
-	_, _ = bomPjtTbl.WithContext(ctx).Where(bomPjtTbl.MinionID.Eq(id)).Delete()
-	bomComTbl := query.SBOMComponent
-	_, _ = bomComTbl.WithContext(ctx).Where(bomComTbl.MinionID.Eq(id)).Delete()
-
-	size := len(tags)
-	if size == 0 {
-		return nil
-	}
-	// -----[ 删除野标签 ]-----
-	var afterTags []string
-	if err = tagTbl.WithContext(ctx).
-		Distinct(tagTbl.Tag).
-		Where(tagTbl.Tag.In(tags...)).
-		Scan(&afterTags); err != nil {
-		return err
-	}
-	thm := make(map[string]struct{}, size)
-	for _, tag := range tags {
-		thm[tag] = struct{}{}
-	}
-	for _, tag := range afterTags {
-		delete(thm, tag)
-	}
-	wildTags := make([]string, 0, 10)
-	for tag := range thm {
-		wildTags = append(wildTags, tag)
-	}
-	if len(wildTags) == 0 {
-		return nil
-	}
-	// 删除 effect
-	effTbl := query.Effect
-	_, err = effTbl.WithContext(ctx).Where(effTbl.Tag.In(wildTags...)).Delete()
-
-	return err
+	return nil
 }
 
-func (biz *minionService) Create(ctx context.Context, mc *param.MinionCreate) error {
-	inet := net.ParseIP(mc.Inet)
+// minionInet 校验节点 IPv4 地址是否合法且未被占用
+func minionInet(ctx context.Context, addr string) (string, error) {
+	inet := net.ParseIP(addr)
 	if len(inet) == 0 || inet.IsLoopback() || inet.IsUnspecified() || inet.Equal(net.IPv4bcast) {
-		return errcode.ErrInetAddress
+		return "", errcode.ErrInetAddress
 	}
 
 	// 检查IPv4是否重复
@@ -250,28 +174,36 @@ func (biz *minionService) Create(ctx context.Context, mc *param.MinionCreate) er
 	if count, err := tbl.WithContext(ctx).
 		Where(tbl.Inet.Eq(ipv4)).
 		Count(); err != nil || count != 0 {
-		return errcode.FmtErrInetExist.Fmt(ipv4)
+		return "", errcode.FmtErrInetExist.Fmt(ipv4)
 	}
 
-	mon := &model.Minion{
-		Inet:   ipv4,
-		Goos:   mc.Goos,
-		Arch:   mc.Arch,
-		Status: model.MSOffline,
-	}
+	return ipv4, nil
+}
+
+// createMinion 创建节点并添加系统标签与手动标签，最后从 CMDB 拉取节点资产信息
+func createMinion(ctx context.Context, cmdbw cmdb.Client, mon *model.Minion, manual []string) error {
+	mon.Status = model.MSOffline
+	tbl := query.Minion
 	if err := tbl.WithContext(ctx).Create(mon); err != nil {
 		return err
 	}
+	ipv4 := mon.Inet
 	tags := []*model.MinionTag{{Tag: ipv4, MinionID: mon.ID, Kind: model.TkLifelong}}
-	if mc.Goos != "" {
-		tags = append(tags, &model.MinionTag{Tag: mc.Goos, MinionID: mon.ID, Kind: model.TkLifelong})
+	if mon.Goos != "" {
+		tags = append(tags, &model.MinionTag{Tag: mon.Goos, MinionID: mon.ID, Kind: model.TkLifelong})
+	}
+	if mon.Arch != "" {
+		tags = append(tags, &model.MinionTag{Tag: mon.Arch, MinionID: mon.ID, Kind: model.TkLifelong})
 	}
-	if mc.Arch != "" {
-		tags = append(tags, &model.MinionTag{Tag: mc.Arch, MinionID: mon.ID, Kind: model.TkLifelong})
+	for _, tag := range manual {
+		if tag == ipv4 || tag == mon.Goos || tag == mon.Arch {
+			continue
+		}
+		tags = append(tags, &model.MinionTag{Tag: tag, MinionID: mon.ID, Kind: model.TkManual})
 	}
 
 	_ = query.MinionTag.WithContext(ctx).Create(tags...)
-	_ = biz.cmdbw.FetchAndSave(ctx, mon.ID, ipv4)
+	_ = cmdbw.FetchAndSave(ctx, mon.ID, ipv4)
 
 	return nil
 }
@@ -306,16 +238,17 @@ func (biz *minionService) Delete(ctx context.Context, scope dynsql.Scope, likes
 		_, _ = tbl.WithContext(ctx).
 			Where(tbl.Status.Neq(deleted), tbl.ID.In(mids...)).
 			UpdateColumnSimple(tbl.Status.Value(deleted))
-		// TODO 通知 broker 节点下线
-		// biz.pusher.Offline(ctx)
+		// 通知 broker 节点下线
+		_ = biz.offline.Notify(ctx, mids)
 	}
 
 	return err
 }
 
-func (biz *minionService) CSV(ctx context.Context) sheet.CSVStreamer {
-	read := sheet.MinionCSV(ctx, 500, true)
-	return sheet.NewCSV(read)
+func (biz *minionService) Export(ctx context.Context, format string, scope dynsql.Scope, likes []gen.Condition) sheet.CSVStreamer {
+	db := minionFilterDB(ctx, scope, likes)
+	read := sheet.MinionCSV(ctx, db, 500, true)
+	return sheet.NewStream(format, read)
 }
 
 func (biz *minionService) Upgrade(ctx context.Context, mid int64, semver model.Semver) error {
@@ -332,7 +265,7 @@ func (biz *minionService) Upgrade(ctx context.Context, mid int64, semver model.S
 	}
 
 	// 通知 agent 升级
-	biz.pusher.Upgrade(ctx, mon.BrokerID, mon.ID, string(semver))
+	_ = biz.pusher.Upgrade(ctx, mon.BrokerID, mon.ID, string(semver))
 
 	return nil
 }
@@ -341,25 +274,6 @@ func (biz *minionService) Batch(ctx context.Context, scope dynsql.Scope, likes [
 	return nil
 }
 
-func (biz *minionService) Command(ctx context.Context, mid int64, cmd string) error {
-	tbl := query.Minion
-	mon, err := tbl.WithContext(ctx).
-		Select(tbl.Status, tbl.BrokerID).
-		Where(tbl.ID.Eq(mid)).
-		First()
-	if err != nil {
-		return err
-	}
-	status := mon.Status
-	if status == model.MSDelete || status == model.MSInactive {
-		return errcode.ErrNodeStatus
-	}
-
-	biz.pusher.Command(ctx, mon.BrokerID, mid, cmd)
-
-	return nil
-}
-
 func (biz *minionService) Unload(ctx context.Context, mid int64, unload bool) error {
 	// 查询节点信息
 	tbl := query.Minion
@@ -387,3 +301,23 @@ func (biz *minionService) Unload(ctx context.Context, mid int64, unload bool) er
 
 	return err
 }
+
+// minionFilterDB 按照 dynsql 条件与关键字筛选节点 ID，筛选逻辑与 Page 保持一致，
+// 按条件批量操作节点时使用该方法查询节点 ID。
+func minionFilterDB(ctx context.Context, scope dynsql.Scope, likes []gen.Condition) *gorm.DB {
+	tagTbl := query.MinionTag
+	monTbl := query.Minion
+	dao := monTbl.WithContext(ctx).
+		Distinct(monTbl.ID).
+		LeftJoin(tagTbl, monTbl.ID.EqCol(tagTbl.MinionID)).
+		Order(monTbl.ID)
+	if len(likes) != 0 {
+		conds := make([]gen.Condition, 0, len(likes))
+		for _, like := range likes {
+			conds = append(conds, dao.Or(like))
+		}
+		dao.Where(conds...)
+	}
+
+	return dao.UnderlyingDB().Scopes(scope.Where).Session(&gorm.Session{})
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/integration/cmdb"
+	"github.com/vela-ssoc/vela-common-mb/validate"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/internal/sheet"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+)
+
+type MinionImportService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionImport)
+	Items(ctx context.Context, id int64, page param.Pager) (int64, []*entity.MinionImportItem)
+
+	// Import 校验导入文件，非 dry-run 模式下会在后台创建校验通过的节点
+	Import(ctx context.Context, req *param.MinionImport, userID int64) (*param.MinionImportReport, error)
+
+	// Reset 将 manager 重启前未执行完毕的导入任务标记为中断
+	Reset(ctx context.Context) error
+}
+
+func MinionImport(db *gorm.DB, cmdbw cmdb.Client, valid validate.Validator, tagRule TagRuleService) MinionImportService {
+	return &minionImportService{
+		db:      db,
+		cmdbw:   cmdbw,
+		valid:   valid,
+		tagRule: tagRule,
+		maxRows: 10000,
+	}
+}
+
+type minionImportService struct {
+	db      *gorm.DB
+	cmdbw   cmdb.Client
+	valid   validate.Validator
+	tagRule TagRuleService
+	maxRows int        // 单个文件最多导入的行数
+	mutex   sync.Mutex // 同一时刻只执行一个导入任务
+}
+
+func (biz *minionImportService) Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionImport) {
+	db := biz.db.WithContext(ctx).Model(&entity.MinionImport{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("filename LIKE ?", kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.MinionImport
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *minionImportService) Items(ctx context.Context, id int64, page param.Pager) (int64, []*entity.MinionImportItem) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.MinionImportItem{}).
+		Where("import_id = ?", id)
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR reason LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.MinionImportItem
+	db.Order("`row`").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *minionImportService) Import(ctx context.Context, req *param.MinionImport, userID int64) (*param.MinionImportReport, error) {
+	records, err := biz.readFile(req)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := biz.parse(records)
+	if err != nil {
+		return nil, err
+	}
+	ret := biz.check(ctx, rows)
+	if req.DryRun || ret.Valid == 0 {
+		return ret, nil
+	}
+
+	now := time.Now()
+	job := &entity.MinionImport{
+		Filename:  req.File.Filename,
+		Status:    entity.ISRunning,
+		Total:     ret.Total,
+		Failed:    ret.Invalid,
+		CreatedID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	items := make([]*entity.MinionImportItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, &entity.MinionImportItem{
+			Row:       row.Row,
+			Inet:      row.Inet,
+			Reason:    row.Reason,
+			CreatedAt: now,
+		})
+	}
+	if err = biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Create(job).Error; exx != nil {
+			return exx
+		}
+		for _, item := range items {
+			item.ImportID = job.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	}); err != nil {
+		return nil, err
+	}
+	ret.ID = job.ID
+
+	// 创建节点与拉取 CMDB 耗时较长，不能使用 HTTP 请求的 context
+	go biz.run(context.Background(), job, rows, items)
+
+	return ret, nil
+}
+
+func (biz *minionImportService) Reset(ctx context.Context) error {
+	return biz.db.WithContext(ctx).
+		Model(&entity.MinionImport{}).
+		Where("status = ?", entity.ISRunning).
+		UpdateColumn("status", entity.ISInterrupted).
+		Error
+}
+
+// readFile 根据文件扩展名读取 csv 或 xlsx 文件
+func (biz *minionImportService) readFile(req *param.MinionImport) ([][]string, error) {
+	file, err := req.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(req.File.Filename)) {
+	// 多读两行（表头与超出的一行），用于判断是否超过行数限制
+	case ".xlsx":
+		return sheet.ReadXLSX(file, req.File.Size, biz.maxRows+2)
+	case ".csv":
+		return sheet.ReadCSV(file, biz.maxRows+2)
+	default:
+		return nil, errcode.ErrImportFormat
+	}
+}
+
+// parse 按照导出文件的表头解析每一行数据，表头顺序可以调整，不认识的列会被忽略。
+func (biz *minionImportService) parse(records [][]string) ([]*param.MinionImportRow, error) {
+	if len(records) < 2 {
+		return nil, errcode.ErrImportEmpty
+	}
+	if len(records)-1 > biz.maxRows {
+		return nil, errcode.ErrImportTooMany
+	}
+
+	columns := make(map[string]int, 16)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["IPv4"]; !ok {
+		return nil, errcode.ErrImportHeader
+	}
+	cell := func(record []string, name string) string {
+		idx, exist := columns[name]
+		if !exist || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	rows := make([]*param.MinionImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := &param.MinionImportRow{
+			Row:      i + 2, // 第一行是表头
+			Inet:     cell(record, "IPv4"),
+			Goos:     cell(record, "操作系统"),
+			Arch:     cell(record, "系统架构"),
+			IDC:      cell(record, "IDC"),
+			IBu:      cell(record, "部门"),
+			Category: cell(record, "业务类型"),
+			Comment:  cell(record, "备注"),
+			Tags:     biz.splitTags(cell(record, "标签")),
+		}
+		if row.Inet == "" && row.Goos == "" && row.Arch == "" {
+			continue // 跳过空行
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, errcode.ErrImportEmpty
+	}
+
+	return rows, nil
+}
+
+func (*minionImportService) splitTags(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.FieldsFunc(str, func(r rune) bool {
+		return r == ',' || r == '，' || r == ';' || r == '；' || r == '|' || r == ' ' || r == '\n'
+	})
+}
+
+// check 使用与新增节点相同的规则校验每一行数据
+func (biz *minionImportService) check(ctx context.Context, rows []*param.MinionImportRow) *param.MinionImportReport {
+	ret := &param.MinionImportReport{Total: len(rows), Rows: rows}
+	inets := make(map[string]int, len(rows))
+	for _, row := range rows {
+		if err := biz.valid.Validate(row); err != nil {
+			row.Reason = err.Error()
+		} else if ipv4, err := minionInet(ctx, row.Inet); err != nil {
+			row.Reason = err.Error()
+		} else if prev, ok := inets[ipv4]; ok {
+			row.Reason = errcode.FmtErrImportRepeat.Fmt(prev).Error()
+		} else {
+			inets[ipv4] = row.Row
+			row.Inet = ipv4
+		}
+
+		if row.Reason == "" {
+			ret.Valid++
+		} else {
+			ret.Invalid++
+		}
+	}
+
+	return ret
+}
+
+func (biz *minionImportService) run(ctx context.Context, job *entity.MinionImport, rows []*param.MinionImportRow, items []*entity.MinionImportItem) {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+
+	monTbl := query.Minion
+	succeed, failed := 0, job.Failed
+	created := make([]int64, 0, len(rows))
+	for i, row := range rows {
+		if row.Reason != "" {
+			continue
+		}
+
+		item := items[i]
+		assigns := map[string]any{}
+		// 导入期间其他途径可能已经创建了相同 IP 的节点，需要再次校验
+		ipv4, err := minionInet(ctx, row.Inet)
+		if err == nil {
+			mon := &model.Minion{
+				Inet:     ipv4,
+				Goos:     row.Goos,
+				Arch:     row.Arch,
+				IDC:      row.IDC,
+				IBu:      row.IBu,
+				Category: row.Category,
+				Comment:  row.Comment,
+			}
+			if err = createMinion(ctx, biz.cmdbw, mon, row.Tags); err == nil {
+				created = append(created, mon.ID)
+				assigns["minion_id"] = mon.ID
+				// 导入文件中填写的信息优先于 CMDB 中的信息
+				if overwrites := biz.overwrite(row); len(overwrites) != 0 {
+					_, _ = monTbl.WithContext(ctx).
+						Where(monTbl.ID.Eq(mon.ID)).
+						UpdateColumnSimple(overwrites...)
+				}
+			}
+		}
+		if err != nil {
+			failed++
+			assigns["reason"] = err.Error()
+		} else {
+			succeed++
+			assigns["succeed"] = true
+		}
+		biz.db.Model(item).UpdateColumns(assigns)
+	}
+
+	// 所有节点创建完毕后再执行自动标签规则
+	for len(created) != 0 {
+		size := 500
+		if size > len(created) {
+			size = len(created)
+		}
+		_, _ = biz.tagRule.Evaluate(ctx, created[:size])
+		created = created[size:]
+	}
+
+	now := time.Now()
+	biz.db.Model(job).UpdateColumns(map[string]any{
+		"status":      entity.ISFinished,
+		"succeed":     succeed,
+		"failed":      failed,
+		"finished_at": now,
+		"updated_at":  now,
+	})
+}
+
+// overwrite 导入文件中非空的字段
+func (*minionImportService) overwrite(row *param.MinionImportRow) []field.AssignExpr {
+	monTbl := query.Minion
+	assigns := make([]field.AssignExpr, 0, 4)
+	if row.IDC != "" {
+		assigns = append(assigns, monTbl.IDC.Value(row.IDC))
+	}
+	if row.IBu != "" {
+		assigns = append(assigns, monTbl.IBu.Value(row.IBu))
+	}
+	if row.Category != "" {
+		assigns = append(assigns, monTbl.Category.Value(row.Category))
+	}
+	if row.Comment != "" {
+		assigns = append(assigns, monTbl.Comment.Value(row.Comment))
+	}
+
+	return assigns
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+type MinionOfflineService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionOffline)
+
+	// Notify 记录并通知 broker 下线已被删除的节点
+	Notify(ctx context.Context, mids []int64) error
+
+	// Retry 手动重试通知
+	Retry(ctx context.Context, id int64) error
+
+	// Run 定时重试通知失败的记录，直至 ctx 结束
+	Run(ctx context.Context)
+}
+
+func MinionOffline(db *gorm.DB, pusher push.Pusher) MinionOfflineService {
+	return &minionOfflineService{
+		db:       db,
+		pusher:   pusher,
+		interval: time.Minute,
+		retries:  60,
+	}
+}
+
+type minionOfflineService struct {
+	db       *gorm.DB
+	pusher   push.Pusher
+	interval time.Duration // 自动重试间隔
+	retries  int           // 自动重试的最大次数
+}
+
+func (biz *minionOfflineService) Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionOffline) {
+	db := biz.db.WithContext(ctx).Model(&entity.MinionOffline{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR broker_name LIKE ? OR reason LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.MinionOffline
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *minionOfflineService) Notify(ctx context.Context, mids []int64) error {
+	if len(mids) == 0 {
+		return nil
+	}
+
+	tbl := query.Minion
+	mons, err := tbl.WithContext(ctx).
+		Select(tbl.ID, tbl.Inet, tbl.BrokerID, tbl.BrokerName).
+		Where(tbl.ID.In(mids...)).
+		Find()
+	if err != nil || len(mons) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	dats := make([]*entity.MinionOffline, 0, len(mons))
+	for _, mon := range mons {
+		dats = append(dats, &entity.MinionOffline{
+			MinionID:   mon.ID,
+			Inet:       mon.Inet,
+			BrokerID:   mon.BrokerID,
+			BrokerName: mon.BrokerName,
+			Status:     entity.OSPending,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+
+	// 同一个节点只保留最近一次的通知记录
+	err = biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Where("minion_id IN ?", mids).
+			Delete(&entity.MinionOffline{}).Error; exx != nil {
+			return exx
+		}
+		return tx.CreateInBatches(dats, 100).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 通知 broker 是同步调用，不能阻塞删除接口
+	go func() {
+		for _, dat := range dats {
+			biz.push(context.Background(), dat, false)
+		}
+	}()
+
+	return nil
+}
+
+func (biz *minionOfflineService) Retry(ctx context.Context, id int64) error {
+	dat := new(entity.MinionOffline)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(dat).Error; err != nil {
+		return err
+	}
+	if dat.Status == entity.OSSucceed {
+		return errcode.ErrOperateFailed
+	}
+
+	return biz.push(ctx, dat, true)
+}
+
+func (biz *minionOfflineService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.retryFailed(ctx)
+		}
+	}
+}
+
+// retryFailed 重试所属 broker 在线且未通知成功的记录
+func (biz *minionOfflineService) retryFailed(ctx context.Context) {
+	brkTbl := query.Broker
+	var bids []int64
+	_ = brkTbl.WithContext(ctx).
+		Where(brkTbl.Status.Is(true)).
+		Pluck(brkTbl.ID, &bids)
+	if len(bids) == 0 {
+		return
+	}
+
+	// 等待中的记录可能是 manager 重启导致未执行完毕，也需要重试
+	var dats []*entity.MinionOffline
+	biz.db.WithContext(ctx).
+		Where("status <> ? AND retries < ? AND broker_id IN ?", entity.OSSucceed, biz.retries, bids).
+		Where("updated_at < ?", time.Now().Add(-biz.interval)).
+		Order("id").
+		Limit(500).
+		Find(&dats)
+	for _, dat := range dats {
+		if ctx.Err() != nil {
+			return
+		}
+		_ = biz.push(ctx, dat, true)
+	}
+}
+
+func (biz *minionOfflineService) push(ctx context.Context, dat *entity.MinionOffline, retry bool) error {
+	now := time.Now()
+	assigns := map[string]any{"updated_at": now}
+	if retry {
+		assigns["retries"] = gorm.Expr("retries + 1")
+	}
+
+	err := biz.pusher.Offline(ctx, dat.BrokerID, dat.MinionID)
+	if err != nil {
+		assigns["status"] = entity.OSFailed
+		assigns["reason"] = err.Error()
+	} else {
+		assigns["status"] = entity.OSSucceed
+		assigns["reason"] = ""
+		assigns["notified_at"] = sql.NullTime{Time: now, Valid: true}
+	}
+	biz.db.WithContext(ctx).
+		Model(&entity.MinionOffline{}).
+		Where("id = ?", dat.ID).
+		UpdateColumns(assigns)
+
+	return err
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+type MinionPurgeService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionPurge)
+	Report(ctx context.Context, id int64) (*param.MinionPurgeReport, error)
+
+	// Drop 物理删除已被逻辑删除的节点，关联数据在后台分批清理
+	Drop(ctx context.Context, mids []int64, userID int64) (int64, error)
+
+	// Reset 将 manager 重启前未执行完毕的清理任务标记为中断
+	Reset(ctx context.Context) error
+}
+
+func MinionPurge(db *gorm.DB) MinionPurgeService {
+	return &minionPurgeService{
+		db:    db,
+		batch: 1000,
+		steps: minionPurgeSteps(),
+	}
+}
+
+type minionPurgeService struct {
+	db    *gorm.DB
+	batch int // 每次最多删除的行数，避免大事务长时间锁表
+	steps []*purgeStep
+	mutex sync.Mutex // 同一时刻只执行一个清理任务
+}
+
+// purgeStep 单张数据表的清理步骤
+type purgeStep struct {
+	name  string
+	purge func(ctx context.Context, db *gorm.DB, mids []int64, batch int) (int64, error)
+}
+
+// minionPurgeSteps 节点关联数据的清理步骤注册表，新增与节点关联的数据表时需要在此注册。
+// 节点表本身由 run 在最后单独处理，不在此注册。
+func minionPurgeSteps() []*purgeStep {
+	return []*purgeStep{
+		purgeByColumn(query.Substance.TableName(), query.Substance.MinionID.ColumnName().String()),
+		purgeByColumn(query.Cmdb.TableName(), query.Cmdb.ID.ColumnName().String()),
+		purgeByColumn(query.SysInfo.TableName(), query.SysInfo.ID.ColumnName().String()),
+		purgeByColumn(query.Event.TableName(), query.Event.MinionID.ColumnName().String()),
+		purgeByColumn(query.Risk.TableName(), query.Risk.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionAccount.TableName(), query.MinionAccount.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionGroup.TableName(), query.MinionGroup.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionListen.TableName(), query.MinionListen.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionLogon.TableName(), query.MinionLogon.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionProcess.TableName(), query.MinionProcess.MinionID.ColumnName().String()),
+		purgeByColumn(query.MinionTask.TableName(), query.MinionTask.MinionID.ColumnName().String()),
+		purgeByColumn(query.SBOMMinion.TableName(), query.SBOMMinion.ID.ColumnName().String()),
+		purgeByColumn(query.SBOMProject.TableName(), query.SBOMProject.MinionID.ColumnName().String()),
+		purgeByColumn(query.SBOMComponent.TableName(), query.SBOMComponent.MinionID.ColumnName().String()),
+		purgeByColumn(entity.MinionOffline{}.TableName(), "minion_id"),
+		purgeByColumn(query.MinionTag.TableName(), query.MinionTag.MinionID.ColumnName().String()),
+	}
+}
+
+// purgeByColumn 按照节点 ID 列分批删除数据
+func purgeByColumn(table, column string) *purgeStep {
+	rawSQL := "DELETE FROM `" + table + "` WHERE `" + column + "` IN ? LIMIT ?"
+	return &purgeStep{
+		name: table,
+		purge: func(ctx context.Context, db *gorm.DB, mids []int64, batch int) (int64, error) {
+			var removed int64
+			for {
+				ret := db.WithContext(ctx).Exec(rawSQL, mids, batch)
+				if err := ret.Error; err != nil {
+					return removed, err
+				}
+				removed += ret.RowsAffected
+				if ret.RowsAffected < int64(batch) {
+					return removed, nil
+				}
+			}
+		},
+	}
+}
+
+func (biz *minionPurgeService) Page(ctx context.Context, page param.Pager) (int64, []*entity.MinionPurge) {
+	db := biz.db.WithContext(ctx).Model(&entity.MinionPurge{})
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.MinionPurge
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *minionPurgeService) Report(ctx context.Context, id int64) (*param.MinionPurgeReport, error) {
+	job := new(entity.MinionPurge)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(job).Error; err != nil {
+		return nil, err
+	}
+
+	var steps []*entity.MinionPurgeStep
+	biz.db.WithContext(ctx).
+		Where("purge_id = ?", id).
+		Order("id").
+		Find(&steps)
+
+	return &param.MinionPurgeReport{MinionPurge: job, Steps: steps}, nil
+}
+
+func (biz *minionPurgeService) Drop(ctx context.Context, mids []int64, userID int64) (int64, error) {
+	// 只能物理删除已被逻辑删除的节点
+	tbl := query.Minion
+	deleted := uint8(model.MSDelete)
+	var ids []int64
+	if err := tbl.WithContext(ctx).
+		Where(tbl.ID.In(mids...), tbl.Status.Eq(deleted)).
+		Pluck(tbl.ID, &ids); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, errcode.ErrDeleteFailed
+	}
+
+	now := time.Now()
+	job := &entity.MinionPurge{
+		Total:     len(ids),
+		Status:    entity.PSPending,
+		CreatedID: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := biz.db.WithContext(ctx).Create(job).Error; err != nil {
+		return 0, err
+	}
+
+	// 清理数据耗时较长，不能使用 HTTP 请求的 context
+	go biz.run(context.Background(), job, ids)
+
+	return job.ID, nil
+}
+
+func (biz *minionPurgeService) Reset(ctx context.Context) error {
+	return biz.db.WithContext(ctx).
+		Model(&entity.MinionPurge{}).
+		Where("status IN ?", []entity.PurgeStatus{entity.PSPending, entity.PSRunning}).
+		UpdateColumn("status", entity.PSInterrupted).
+		Error
+}
+
+func (biz *minionPurgeService) run(ctx context.Context, job *entity.MinionPurge, mids []int64) {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+
+	biz.db.Model(job).UpdateColumns(map[string]any{
+		"status":     entity.PSRunning,
+		"updated_at": time.Now(),
+	})
+
+	// 删除标签前先记录关联的标签，用于清理不再使用的 effect
+	tagTbl := query.MinionTag
+	var tags []string
+	_ = tagTbl.WithContext(ctx).
+		Distinct(tagTbl.Tag).
+		Where(tagTbl.MinionID.In(mids...)).
+		Scan(&tags)
+
+	var removed int64
+	var failed int
+	record := func(name string, start time.Time, num int64, err error) {
+		step := &entity.MinionPurgeStep{
+			PurgeID:   job.ID,
+			Name:      name,
+			Removed:   num,
+			Elapsed:   time.Since(start).Milliseconds(),
+			CreatedAt: time.Now(),
+		}
+		if err != nil {
+			failed++
+			step.Failed, step.Reason = true, err.Error()
+		}
+		removed += num
+		biz.db.Create(step)
+	}
+
+	for _, step := range biz.steps {
+		start := time.Now()
+		num, err := step.purge(ctx, biz.db, mids, biz.batch)
+		record(step.name, start, num, err)
+	}
+
+	// 关联数据有清理失败的，保留节点记录以便再次物理删除
+	start := time.Now()
+	if failed != 0 {
+		err := errors.New("存在清理失败的数据表，保留节点记录以便重试")
+		record(query.Minion.TableName(), start, 0, err)
+	} else {
+		monTbl := query.Minion
+		ret, err := monTbl.WithContext(ctx).Where(monTbl.ID.In(mids...)).Delete()
+		record(monTbl.TableName(), start, ret.RowsAffected, err)
+	}
+
+	if len(tags) != 0 {
+		start = time.Now()
+		num, err := biz.dropWildEffect(ctx, tags)
+		record(query.Effect.TableName(), start, num, err)
+	}
+
+	now := time.Now()
+	biz.db.Model(job).UpdateColumns(map[string]any{
+		"status":      entity.PSFinished,
+		"removed":     removed,
+		"failed":      failed,
+		"finished_at": now,
+		"updated_at":  now,
+	})
+}
+
+// dropWildEffect 删除不再有节点使用的标签所关联的 effect
+func (biz *minionPurgeService) dropWildEffect(ctx context.Context, tags []string) (int64, error) {
+	tagTbl := query.MinionTag
+	var afterTags []string
+	if err := tagTbl.WithContext(ctx).
+		Distinct(tagTbl.Tag).
+		Where(tagTbl.Tag.In(tags...)).
+		Scan(&afterTags); err != nil {
+		return 0, err
+	}
+	thm := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		thm[tag] = struct{}{}
+	}
+	for _, tag := range afterTags {
+		delete(thm, tag)
+	}
+	wildTags := make([]string, 0, len(thm))
+	for tag := range thm {
+		wildTags = append(wildTags, tag)
+	}
+	if len(wildTags) == 0 {
+		return 0, nil
+	}
+
+	effTbl := query.Effect
+	ret, err := effTbl.WithContext(ctx).Where(effTbl.Tag.In(wildTags...)).Delete()
+
+	return ret.RowsAffected, err
+}
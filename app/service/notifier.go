@@ -2,12 +2,21 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/integration/devops"
+	"github.com/vela-ssoc/vela-common-mb/integration/dong"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/mailer"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/bridge/push"
 	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
 )
 
 type NotifierService interface {
@@ -15,16 +24,36 @@ type NotifierService interface {
 	Create(ctx context.Context, req *param.NotifierCreate) error
 	Update(ctx context.Context, req *param.NotifierUpdate) error
 	Delete(ctx context.Context, id int64) error
+
+	// Test 使用样例事件或风险，通过通知人配置的所有通知方式发送测试通知
+	Test(ctx context.Context, req *param.NotifierTest) ([]*entity.NotifierDelivery, error)
+
+	// Deliveries 通知记录
+	Deliveries(ctx context.Context, req *param.NotifierDeliveryPage, page param.Pager) (int64, []*entity.NotifierDelivery)
+
+	// Notify 由 manager 向通知人发送通知并记录发送结果
+	Notify(ctx context.Context, ntfs []*model.Notifier, source, title, body string) error
+
+	// Report 记录 broker 上报的通知发送结果
+	Report(ctx context.Context, brokerID int64, req *param.NotifierDeliveryReport) error
 }
 
-func Notifier(pusher push.Pusher) NotifierService {
+func Notifier(db *gorm.DB, pusher push.Pusher, dong dong.Client, dps devops.Client, email EmailService) NotifierService {
 	return &notifierService{
+		db:     db,
 		pusher: pusher,
+		dong:   dong,
+		dps:    dps,
+		email:  email,
 	}
 }
 
 type notifierService struct {
+	db     *gorm.DB
 	pusher push.Pusher
+	dong   dong.Client
+	dps    devops.Client
+	email  EmailService
 }
 
 func (biz *notifierService) Page(ctx context.Context, page param.Pager) (int64, []*model.Notifier) {
@@ -106,3 +135,189 @@ func (biz *notifierService) Delete(ctx context.Context, id int64) error {
 
 	return nil
 }
+
+func (biz *notifierService) Test(ctx context.Context, req *param.NotifierTest) ([]*entity.NotifierDelivery, error) {
+	ntf := new(model.Notifier)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(ntf).Error; err != nil {
+		return nil, err
+	}
+	if len(ntf.Ways) == 0 {
+		return nil, errcode.ErrNotifierWays
+	}
+
+	title, body := biz.sample(ntf, req.Source)
+	dats := biz.deliver(ctx, ntf, req.Source, title, body, true)
+	if len(dats) != 0 {
+		if err := biz.db.WithContext(ctx).Create(dats).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return dats, nil
+}
+
+func (biz *notifierService) Deliveries(ctx context.Context, req *param.NotifierDeliveryPage, page param.Pager) (int64, []*entity.NotifierDelivery) {
+	db := biz.db.WithContext(ctx).Model(&entity.NotifierDelivery{})
+	if req.NotifierID != 0 {
+		db = db.Where("notifier_id = ?", req.NotifierID)
+	}
+	if req.Channel != "" {
+		db = db.Where("channel = ?", req.Channel)
+	}
+	if req.Performer != "" {
+		db = db.Where("performer = ?", req.Performer)
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("subject LIKE ? OR error LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.NotifierDelivery
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *notifierService) Notify(ctx context.Context, ntfs []*model.Notifier, source, title, body string) error {
+	var dats []*entity.NotifierDelivery
+	for _, ntf := range ntfs {
+		dats = append(dats, biz.deliver(ctx, ntf, source, title, body, false)...)
+	}
+	if len(dats) == 0 {
+		return nil
+	}
+	var errs []string
+	if err := biz.db.WithContext(ctx).CreateInBatches(dats, 200).Error; err != nil {
+		errs = append(errs, "保存通知记录: "+err.Error())
+	}
+	for _, dat := range dats {
+		if dat.Status == entity.NsFailed {
+			errs = append(errs, dat.Channel+": "+dat.Error)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (biz *notifierService) Report(ctx context.Context, brokerID int64, req *param.NotifierDeliveryReport) error {
+	if len(req.Deliveries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	dats := make([]*entity.NotifierDelivery, 0, len(req.Deliveries))
+	for _, d := range req.Deliveries {
+		dat := &entity.NotifierDelivery{
+			NotifierID: d.NotifierID,
+			Channel:    d.Channel,
+			Source:     d.Source,
+			Subject:    truncate(d.Subject, 255),
+			Status:     entity.NsSucceed,
+			Performer:  entity.PerformerBroker,
+			BrokerID:   brokerID,
+			CreatedAt:  d.SentAt,
+		}
+		if d.Error != "" {
+			dat.Status = entity.NsFailed
+			dat.Error = truncate(d.Error, 1024)
+		}
+		if dat.CreatedAt.IsZero() {
+			dat.CreatedAt = now
+		}
+		dats = append(dats, dat)
+	}
+
+	return biz.db.WithContext(ctx).CreateInBatches(dats, 200).Error
+}
+
+// deliver 通过通知人配置的每种通知方式分别发送，返回每种方式的发送结果。
+func (biz *notifierService) deliver(ctx context.Context, ntf *model.Notifier, source, title, body string, test bool) []*entity.NotifierDelivery {
+	dats := make([]*entity.NotifierDelivery, 0, len(ntf.Ways))
+	for _, way := range ntf.Ways {
+		var err error
+		switch way {
+		case "dong":
+			if ntf.Dong == "" {
+				continue
+			}
+			err = biz.dong.Send(ctx, []string{ntf.Dong}, nil, title, body)
+		case "email":
+			if ntf.Email == "" {
+				continue
+			}
+			msg := &mailer.Message{To: []string{ntf.Email}, Subject: title, Body: body}
+			err = biz.email.Send(ctx, msg)
+		case "wechat", "sms", "call":
+			if ntf.Mobile == "" {
+				continue
+			}
+			users := []*model.Devops{{Name: ntf.Name, Mobile: ntf.Mobile, NotifyMethods: way}}
+			err = biz.dps.Send(ctx, title, body, users)
+		default:
+			continue
+		}
+
+		dat := &entity.NotifierDelivery{
+			NotifierID: ntf.ID,
+			Channel:    way,
+			Source:     source,
+			Subject:    truncate(title, 255),
+			Status:     entity.NsSucceed,
+			Performer:  entity.PerformerManager,
+			Test:       test,
+			CreatedAt:  time.Now(),
+		}
+		if err != nil {
+			dat.Status = entity.NsFailed
+			dat.Error = truncate(err.Error(), 1024)
+		}
+		dats = append(dats, dat)
+	}
+
+	return dats
+}
+
+// sample 渲染样例事件或风险，样例的类型优先使用通知人订阅的第一个类型。
+func (biz *notifierService) sample(ntf *model.Notifier, source string) (string, string) {
+	now := time.Now().Format(time.DateTime)
+	if source == "risk" {
+		typ := "暴力破解"
+		if len(ntf.Risks) != 0 {
+			typ = ntf.Risks[0]
+		}
+		title := "[测试] 风险事件：" + typ
+		body := fmt.Sprintf("这是一条测试通知，请忽略。\n风险类型：%s\n风险级别：%s\n节点：127.0.0.1\n远程地址：192.0.2.1:22\n发生时间：%s",
+			typ, model.RLvlHigh, now)
+		return title, body
+	}
+
+	typ := "测试事件"
+	if len(ntf.Events) != 0 {
+		typ = ntf.Events[0]
+	}
+	title := "[测试] 安全事件：" + typ
+	body := fmt.Sprintf("这是一条测试通知，请忽略。\n事件主题：%s\n事件级别：%s\n节点：127.0.0.1\n发生时间：%s",
+		typ, model.ELvlMajor, now)
+
+	return title, body
+}
+
+// truncate 按字符截断字符串，避免超出 varchar 字段长度导致写入失败。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// passBatchSize 批量写入白名单时每批的条数
+const passBatchSize = 500
+
+// passBeforeAt 未设置有效期时视为永久有效
+func passBeforeAt(at *time.Time) time.Time {
+	if at == nil || at.IsZero() {
+		return time.Date(9999, 12, 31, 23, 59, 59, 0, time.Local)
+	}
+	return *at
+}
+
+// passRule 白名单变更前后的快照，用于生成变更记录
+type passRule struct {
+	Value    string
+	Kind     string
+	Reason   string
+	BeforeAt time.Time
+}
+
+// passDetail 生成变更内容描述，old 为空时代表新增。
+func passDetail(old *passRule, cur passRule) string {
+	const layout = "2006-01-02 15:04:05"
+	if old == nil {
+		return "维度：" + cur.Kind + "；原因：" + cur.Reason + "；有效期：" + cur.BeforeAt.Format(layout)
+	}
+
+	var diffs []string
+	if old.Value != cur.Value {
+		diffs = append(diffs, "值："+old.Value+" → "+cur.Value)
+	}
+	if old.Kind != cur.Kind {
+		diffs = append(diffs, "维度："+old.Kind+" → "+cur.Kind)
+	}
+	if old.Reason != cur.Reason {
+		diffs = append(diffs, "原因："+old.Reason+" → "+cur.Reason)
+	}
+	if !old.BeforeAt.Equal(cur.BeforeAt) {
+		diffs = append(diffs, "有效期："+old.BeforeAt.Format(layout)+" → "+cur.BeforeAt.Format(layout))
+	}
+
+	return strings.Join(diffs, "；")
+}
+
+// passLines 逐行解析导入的文本，忽略空行与 # 注释，返回合法值与无法识别的行。
+func passLines(text string, normalize func(string) (string, bool)) ([]string, []string) {
+	var vals, invalid []string
+	for _, line := range strings.Split(text, "\n") {
+		if idx := strings.IndexByte(line, '#'); idx >= 0 {
+			line = line[:idx]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		val, ok := normalize(line)
+		if !ok {
+			if len(invalid) < 100 {
+				invalid = append(invalid, line)
+			}
+			continue
+		}
+		vals = append(vals, val)
+	}
+
+	return vals, invalid
+}
+
+// passUnique 去除重复值并保持原有顺序
+func passUnique(vals []string) []string {
+	ret := make([]string, 0, len(vals))
+	uniq := make(map[string]struct{}, len(vals))
+	for _, val := range vals {
+		if _, exist := uniq[val]; !exist {
+			uniq[val] = struct{}{}
+			ret = append(ret, val)
+		}
+	}
+
+	return ret
+}
+
+func passNormalizeIP(s string) (string, bool) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return "", false
+	}
+	return ip.String(), true
+}
+
+// passNormalizeDomain 域名统一转为小写并去掉末尾的点，允许 *. 开头的泛域名。
+func passNormalizeDomain(s string) (string, bool) {
+	domain := strings.TrimSuffix(strings.ToLower(s), ".")
+	if domain == "" || len(domain) > 255 {
+		return "", false
+	}
+	for i, label := range strings.Split(domain, ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+				return "", false
+			}
+		}
+	}
+
+	return domain, true
+}
+
+// passRow 白名单的公共字段，IP 或域名字段查询时统一别名为 value。
+type passRow struct {
+	ID       int64     `gorm:"column:id"`
+	Value    string    `gorm:"column:value"`
+	Kind     string    `gorm:"column:kind"`
+	Reason   string    `gorm:"column:reason"`
+	BeforeAt time.Time `gorm:"column:before_at"`
+}
+
+func (r *passRow) rule() *passRule {
+	return &passRule{Value: r.Value, Kind: r.Kind, Reason: r.Reason, BeforeAt: r.BeforeAt}
+}
+
+// passTable IP 与 DNS 白名单的公共逻辑，两者只有数据表、值字段与校验规则不同。
+type passTable struct {
+	db        *gorm.DB
+	pusher    push.Pusher
+	table     string                                                             // 数据表
+	column    string                                                             // 值字段
+	target    string                                                             // 变更记录中的白名单类型
+	normalize func(string) (string, bool)                                        // 校验并格式化值
+	invalid   func(...any) error                                                 // 值不合法时的错误
+	create    func(tx *gorm.DB, vals []string, cur passRule, userID int64) error // 批量新增白名单，cur 中的值字段无效
+}
+
+// values 校验并格式化所有值，有不合法的值时返回错误。
+func (pt passTable) values(vals []string) ([]string, error) {
+	ret := make([]string, 0, len(vals))
+	for _, val := range vals {
+		v, ok := pt.normalize(val)
+		if !ok {
+			return nil, pt.invalid(val)
+		}
+		ret = append(ret, v)
+	}
+
+	return ret, nil
+}
+
+func (pt passTable) rows(tx *gorm.DB, query string, args ...any) ([]*passRow, error) {
+	var rows []*passRow
+	err := tx.Table(pt.table).
+		Select("id", pt.column+" AS value", "kind", "reason", "before_at").
+		Where(query, args...).
+		Find(&rows).Error
+
+	return rows, err
+}
+
+func (pt passTable) changelog(row *passRow, action, detail string, userID int64, username string) *entity.PassChangelog {
+	return &entity.PassChangelog{
+		Target:   pt.target,
+		RuleID:   row.ID,
+		Value:    row.Value,
+		Kind:     row.Kind,
+		Action:   action,
+		Detail:   detail,
+		UserID:   userID,
+		Username: username,
+	}
+}
+
+// reset 通知 broker 刷新白名单缓存，见 push.FPPassReset。
+func (pt passTable) reset(ctx context.Context) {
+	pt.pusher.PassReset(ctx, pt.table)
+}
+
+func (pt passTable) username(ctx context.Context, userID int64) string {
+	tbl := query.User
+	u, _ := tbl.WithContext(ctx).
+		Select(tbl.Username).
+		Where(tbl.ID.Eq(userID)).
+		First()
+	if u == nil {
+		return ""
+	}
+
+	return u.Username
+}
+
+func (pt passTable) insert(ctx context.Context, vals []string, kind, reason string, beforeAt *time.Time, userID int64) error {
+	vals, err := pt.values(vals)
+	if err != nil {
+		return err
+	}
+	_, err = pt.save(ctx, vals, kind, reason, passBeforeAt(beforeAt), false, entity.PassCreate, userID)
+
+	return err
+}
+
+func (pt passTable) update(ctx context.Context, id int64, val, kind, reason string, beforeAt *time.Time, userID int64) error {
+	value, ok := pt.normalize(val)
+	if !ok {
+		return pt.invalid(val)
+	}
+	rows, err := pt.rows(pt.db.WithContext(ctx), "id = ?", id)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	old := rows[0]
+	cur := passRule{Value: value, Kind: kind, Reason: reason, BeforeAt: passBeforeAt(beforeAt)}
+	detail := passDetail(old.rule(), cur)
+	if detail == "" {
+		return nil
+	}
+	var count int64
+	if pt.db.WithContext(ctx).
+		Table(pt.table).
+		Where("id <> ? AND kind = ? AND "+pt.column+" = ?", old.ID, cur.Kind, cur.Value).
+		Count(&count); count != 0 {
+		return errcode.FmtErrPassExist.Fmt(cur.Kind, cur.Value)
+	}
+
+	row := &passRow{ID: old.ID, Value: cur.Value, Kind: cur.Kind}
+	log := pt.changelog(row, entity.PassUpdate, detail, userID, pt.username(ctx, userID))
+	if err = pt.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Table(pt.table).
+			Where("id = ?", old.ID).
+			UpdateColumns(map[string]any{
+				pt.column:    cur.Value,
+				"kind":       cur.Kind,
+				"reason":     cur.Reason,
+				"before_at":  cur.BeforeAt,
+				"updated_id": userID,
+				"updated_at": time.Now(),
+			}).Error; exx != nil {
+			return exx
+		}
+		return tx.Create(log).Error
+	}); err != nil {
+		return err
+	}
+
+	pt.reset(ctx)
+
+	return nil
+}
+
+func (pt passTable) Delete(ctx context.Context, ids []int64, userID int64) error {
+	rows, err := pt.rows(pt.db.WithContext(ctx), "id IN ?", ids)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	username := pt.username(ctx, userID)
+	logs := make([]*entity.PassChangelog, 0, len(rows))
+	for _, row := range rows {
+		detail := passDetail(nil, *row.rule())
+		logs = append(logs, pt.changelog(row, entity.PassDelete, detail, userID, username))
+	}
+	if err = pt.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Exec("DELETE FROM "+pt.table+" WHERE id IN ?", ids).Error; exx != nil {
+			return exx
+		}
+		return tx.CreateInBatches(logs, passBatchSize).Error
+	}); err != nil {
+		return err
+	}
+
+	pt.reset(ctx)
+
+	return nil
+}
+
+func (pt passTable) Expire(ctx context.Context, ids []int64, userID int64) error {
+	now := time.Now()
+	rows, err := pt.rows(pt.db.WithContext(ctx), "id IN ? AND before_at > ?", ids, now)
+	if err != nil || len(rows) == 0 {
+		return err
+	}
+
+	username := pt.username(ctx, userID)
+	expires := make([]int64, 0, len(rows))
+	logs := make([]*entity.PassChangelog, 0, len(rows))
+	for _, row := range rows {
+		expires = append(expires, row.ID)
+		cur := *row.rule()
+		cur.BeforeAt = now
+		detail := passDetail(row.rule(), cur)
+		logs = append(logs, pt.changelog(row, entity.PassExpire, detail, userID, username))
+	}
+	if err = pt.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Table(pt.table).
+			Where("id IN ?", expires).
+			UpdateColumns(map[string]any{"before_at": now, "updated_id": userID, "updated_at": now}).Error; exx != nil {
+			return exx
+		}
+		return tx.CreateInBatches(logs, passBatchSize).Error
+	}); err != nil {
+		return err
+	}
+
+	pt.reset(ctx)
+
+	return nil
+}
+
+func (pt passTable) Import(ctx context.Context, req *param.PassImport, userID int64) (*param.PassImportResult, error) {
+	vals, invalid := passLines(req.Text, pt.normalize)
+	beforeAt := passBeforeAt(req.BeforeAt)
+	res, err := pt.save(ctx, vals, req.Kind, req.Reason, beforeAt, req.Update, entity.PassImport, userID)
+	if err != nil {
+		return nil, err
+	}
+	res.Invalid = invalid
+
+	return res, nil
+}
+
+func (pt passTable) Changelog(ctx context.Context, page param.Pager, req *param.PassChangelogPage) (int64, []*entity.PassChangelog) {
+	db := pt.db.WithContext(ctx).
+		Model(&entity.PassChangelog{}).
+		Where("target = ?", pt.target)
+	if req.RuleID != 0 {
+		db = db.Where("rule_id = ?", req.RuleID)
+	}
+	if req.Action != "" {
+		db = db.Where("action = ?", req.Action)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("value LIKE ? OR username LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.PassChangelog
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+// save 批量写入白名单，值与数据维度相同的规则已存在时根据 update 决定更新或跳过。
+func (pt passTable) save(ctx context.Context, vals []string, kind, reason string, beforeAt time.Time, update bool,
+	action string, userID int64,
+) (*param.PassImportResult, error) {
+	res := new(param.PassImportResult)
+	username := pt.username(ctx, userID)
+	vals = passUnique(vals)
+	cur := passRule{Kind: kind, Reason: reason, BeforeAt: beforeAt}
+
+	err := pt.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for len(vals) != 0 {
+			size := passBatchSize
+			if size > len(vals) {
+				size = len(vals)
+			}
+			chunk := vals[:size]
+			vals = vals[size:]
+
+			olds, err := pt.rows(tx, "kind = ? AND "+pt.column+" IN ?", kind, chunk)
+			if err != nil {
+				return err
+			}
+			exists := make(map[string]*passRow, len(olds))
+			for _, old := range olds {
+				exists[old.Value] = old
+			}
+
+			now := time.Now()
+			logs := make([]*entity.PassChangelog, 0, len(chunk))
+			news := make([]string, 0, len(chunk))
+			for _, val := range chunk {
+				old, ok := exists[val]
+				if !ok {
+					news = append(news, val)
+					continue
+				}
+				if !update {
+					res.Skipped++
+					continue
+				}
+
+				cur.Value = val
+				detail := passDetail(old.rule(), cur)
+				if detail == "" {
+					res.Skipped++
+					continue
+				}
+				if err = tx.Table(pt.table).
+					Where("id = ?", old.ID).
+					UpdateColumns(map[string]any{
+						"reason":     reason,
+						"before_at":  beforeAt,
+						"updated_id": userID,
+						"updated_at": now,
+					}).Error; err != nil {
+					return err
+				}
+				res.Updated++
+				logs = append(logs, pt.changelog(old, action, detail, userID, username))
+			}
+
+			if len(news) != 0 {
+				if err = pt.create(tx, news, cur, userID); err != nil {
+					return err
+				}
+				res.Created += len(news)
+
+				// 查询新增白名单的 ID 用于记录变更
+				created, exx := pt.rows(tx, "kind = ? AND "+pt.column+" IN ?", kind, news)
+				if exx != nil {
+					return exx
+				}
+				detail := passDetail(nil, cur)
+				for _, row := range created {
+					logs = append(logs, pt.changelog(row, action, detail, userID, username))
+				}
+			}
+			if len(logs) != 0 {
+				if err = tx.Create(logs).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Created+res.Updated != 0 {
+		pt.reset(ctx)
+	}
+
+	return res, nil
+}
@@ -3,32 +3,84 @@ package service
 import (
 	"context"
 
-	"github.com/vela-ssoc/vela-common-mb/dal/model"
-	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
 )
 
 type PassDNSService interface {
-	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.PassDNS)
+	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.PassDNS)
+	Create(ctx context.Context, req *param.PassDNSCreate, userID int64) error
+	Update(ctx context.Context, req *param.PassDNSUpdate, userID int64) error
+	Delete(ctx context.Context, ids []int64, userID int64) error
+
+	// Expire 将白名单的有效期设置为当前时间，使其立即失效但保留记录
+	Expire(ctx context.Context, ids []int64, userID int64) error
+
+	// Import 从文本批量导入白名单
+	Import(ctx context.Context, req *param.PassImport, userID int64) (*param.PassImportResult, error)
+
+	// Changelog 白名单变更记录
+	Changelog(ctx context.Context, page param.Pager, req *param.PassChangelogPage) (int64, []*entity.PassChangelog)
 }
 
-func PassDNS() PassDNSService {
-	return &passDNSService{}
+func PassDNS(db *gorm.DB, pusher push.Pusher) PassDNSService {
+	return &passDNSService{
+		passTable: passTable{
+			db:        db,
+			pusher:    pusher,
+			table:     "pass_dns",
+			column:    "domain",
+			target:    entity.PassTargetDNS,
+			normalize: passNormalizeDomain,
+			invalid:   errcode.FmtErrPassDomain.Fmt,
+			create:    passCreateDNS,
+		},
+	}
 }
 
-type passDNSService struct{}
+type passDNSService struct {
+	passTable
+}
 
-func (biz *passDNSService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.PassDNS) {
-	tbl := query.PassDNS
-	db := tbl.WithContext(ctx).UnderlyingDB().Scopes(scope.Where)
+func (biz *passDNSService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.PassDNS) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.PassDNS{}).
+		Scopes(scope.Where)
 	var count int64
 	if db.Count(&count); count == 0 {
 		return 0, nil
 	}
 
-	var ret []*model.PassDNS
-	db.Scopes(page.DBScope(count)).Find(&ret)
+	var dats []*entity.PassDNS
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *passDNSService) Create(ctx context.Context, req *param.PassDNSCreate, userID int64) error {
+	return biz.insert(ctx, req.Domain, req.Kind, req.Reason, req.BeforeAt, userID)
+}
+
+func (biz *passDNSService) Update(ctx context.Context, req *param.PassDNSUpdate, userID int64) error {
+	return biz.update(ctx, req.ID, req.Domain, req.Kind, req.Reason, req.BeforeAt, userID)
+}
+
+func passCreateDNS(tx *gorm.DB, domains []string, cur passRule, userID int64) error {
+	dats := make([]*entity.PassDNS, 0, len(domains))
+	for _, domain := range domains {
+		dats = append(dats, &entity.PassDNS{
+			Domain:    domain,
+			Kind:      cur.Kind,
+			Reason:    cur.Reason,
+			BeforeAt:  cur.BeforeAt,
+			CreatedID: userID,
+			UpdatedID: userID,
+		})
+	}
 
-	return count, ret
+	return tx.Create(dats).Error
 }
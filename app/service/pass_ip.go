@@ -3,32 +3,84 @@ package service
 import (
 	"context"
 
-	"github.com/vela-ssoc/vela-common-mb/dal/model"
-	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
 )
 
 type PassIPService interface {
-	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.PassIP)
+	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.PassIP)
+	Create(ctx context.Context, req *param.PassIPCreate, userID int64) error
+	Update(ctx context.Context, req *param.PassIPUpdate, userID int64) error
+	Delete(ctx context.Context, ids []int64, userID int64) error
+
+	// Expire 将白名单的有效期设置为当前时间，使其立即失效但保留记录
+	Expire(ctx context.Context, ids []int64, userID int64) error
+
+	// Import 从文本批量导入白名单
+	Import(ctx context.Context, req *param.PassImport, userID int64) (*param.PassImportResult, error)
+
+	// Changelog 白名单变更记录
+	Changelog(ctx context.Context, page param.Pager, req *param.PassChangelogPage) (int64, []*entity.PassChangelog)
 }
 
-func PassIP() PassIPService {
-	return &passIPService{}
+func PassIP(db *gorm.DB, pusher push.Pusher) PassIPService {
+	return &passIPService{
+		passTable: passTable{
+			db:        db,
+			pusher:    pusher,
+			table:     "pass_ip",
+			column:    "ip",
+			target:    entity.PassTargetIP,
+			normalize: passNormalizeIP,
+			invalid:   errcode.FmtErrPassIP.Fmt,
+			create:    passCreateIP,
+		},
+	}
 }
 
-type passIPService struct{}
+type passIPService struct {
+	passTable
+}
 
-func (biz *passIPService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.PassIP) {
-	tbl := query.PassIP
-	db := tbl.WithContext(ctx).UnderlyingDB().Scopes(scope.Where)
+func (biz *passIPService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.PassIP) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.PassIP{}).
+		Scopes(scope.Where)
 	var count int64
 	if db.Count(&count); count == 0 {
 		return 0, nil
 	}
 
-	var ret []*model.PassIP
-	db.Scopes(page.DBScope(count)).Find(&ret)
+	var dats []*entity.PassIP
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *passIPService) Create(ctx context.Context, req *param.PassIPCreate, userID int64) error {
+	return biz.insert(ctx, req.IP, req.Kind, req.Reason, req.BeforeAt, userID)
+}
+
+func (biz *passIPService) Update(ctx context.Context, req *param.PassIPUpdate, userID int64) error {
+	return biz.update(ctx, req.ID, req.IP, req.Kind, req.Reason, req.BeforeAt, userID)
+}
+
+func passCreateIP(tx *gorm.DB, ips []string, cur passRule, userID int64) error {
+	dats := make([]*entity.PassIP, 0, len(ips))
+	for _, ip := range ips {
+		dats = append(dats, &entity.PassIP{
+			IP:        ip,
+			Kind:      cur.Kind,
+			Reason:    cur.Reason,
+			BeforeAt:  cur.BeforeAt,
+			CreatedID: userID,
+			UpdatedID: userID,
+		})
+	}
 
-	return count, ret
+	return tx.Create(dats).Error
 }
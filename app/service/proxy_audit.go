@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"gorm.io/gorm"
+)
+
+type ProxyAuditService interface {
+	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.ProxyAudit)
+	Setting(ctx context.Context) *param.ProxyAuditSetting
+	SetSetting(ctx context.Context, req *param.ProxyAuditSetting) error
+
+	// Audit 执行代理转发并记录审计日志，forward 使用传入的 ResponseWriter 写入上游响应。
+	Audit(ctx context.Context, w http.ResponseWriter, r *http.Request, acc *param.ProxyAccess, forward func(http.ResponseWriter))
+
+	// Run 批量写入审计日志
+	Run(ctx context.Context)
+}
+
+func ProxyAudit(db *gorm.DB, slog logback.Logger) ProxyAuditService {
+	return &proxyAuditService{
+		db:       db,
+		slog:     slog,
+		queue:    make(chan *entity.ProxyAudit, 4096),
+		batch:    200,
+		interval: time.Second,
+	}
+}
+
+type proxyAuditService struct {
+	db       *gorm.DB
+	slog     logback.Logger
+	mutex    sync.RWMutex
+	setting  *param.ProxyAuditSetting // 设置缓存，修改设置时刷新
+	queue    chan *entity.ProxyAudit  // 待写入的审计日志，队列满时丢弃
+	dropped  atomic.Int64             // 队列满时丢弃的条数
+	batch    int                      // 每批写入的最大条数
+	interval time.Duration            // 不满一批时的写入间隔
+}
+
+// proxyAuditID 代理审计设置在 store 中的 ID
+const proxyAuditID = "global.proxy.audit"
+
+func (biz *proxyAuditService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.ProxyAudit) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.ProxyAudit{}).
+		Scopes(scope.Where)
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR path LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ProxyAudit
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *proxyAuditService) Setting(ctx context.Context) *param.ProxyAuditSetting {
+	biz.mutex.RLock()
+	ret := biz.setting
+	biz.mutex.RUnlock()
+	if ret != nil {
+		return ret
+	}
+
+	ret = &param.ProxyAuditSetting{Enabled: true, SampleSize: 1024}
+	storeLoadJSON(ctx, proxyAuditID, ret)
+	biz.mutex.Lock()
+	biz.setting = ret
+	biz.mutex.Unlock()
+
+	return ret
+}
+
+func (biz *proxyAuditService) SetSetting(ctx context.Context, req *param.ProxyAuditSetting) error {
+	err := storeSaveJSON(ctx, proxyAuditID, "节点代理审计设置", req)
+	if err == nil {
+		biz.mutex.Lock()
+		biz.setting = req
+		biz.mutex.Unlock()
+	}
+
+	return err
+}
+
+func (biz *proxyAuditService) Audit(ctx context.Context, w http.ResponseWriter, r *http.Request, acc *param.ProxyAccess,
+	forward func(http.ResponseWriter),
+) {
+	setting := biz.Setting(ctx)
+	if !setting.Enabled {
+		forward(w)
+		return
+	}
+
+	start := time.Now()
+	aw := &auditWriter{w: w, limit: setting.SampleSize}
+	forward(aw)
+	latency := time.Since(start)
+
+	status := aw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	sample := aw.sample.Bytes()
+	if !utf8.Valid(sample) {
+		// 替换字符可能比原来的字节更长，修复后按字符边界截断到采样长度以内
+		sample = bytes.ToValidUTF8(sample, []byte("�"))
+		if n := setting.SampleSize; len(sample) > n {
+			for n > 0 && !utf8.RuneStart(sample[n]) {
+				n--
+			}
+			sample = sample[:n]
+		}
+	}
+	dat := &entity.ProxyAudit{
+		Kind:       acc.Kind,
+		UserID:     acc.UserID,
+		MinionID:   acc.MinionID,
+		BrokerID:   acc.BrokerID,
+		Inet:       acc.Inet,
+		Method:     acc.Method,
+		Path:       truncate(acc.Path, 1024),
+		Query:      truncate(r.URL.RawQuery, 2048),
+		ClientIP:   acc.ClientIP,
+		Status:     status,
+		ReqSize:    r.ContentLength,
+		RespSize:   aw.size,
+		Latency:    latency.Milliseconds(),
+		RespSample: string(sample),
+		CreatedAt:  start,
+	}
+	select {
+	case biz.queue <- dat:
+	default:
+		biz.dropped.Add(1)
+	}
+}
+
+func (biz *proxyAuditService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	dats := make([]*entity.ProxyAudit, 0, biz.batch)
+	for {
+		select {
+		case <-ctx.Done():
+			biz.flush(dats)
+			return
+		case dat := <-biz.queue:
+			if dats = append(dats, dat); len(dats) >= biz.batch {
+				biz.flush(dats)
+				dats = dats[:0]
+			}
+		case <-ticker.C:
+			biz.flush(dats)
+			dats = dats[:0]
+		}
+	}
+}
+
+// flush 写入审计日志，并报告队列满时丢弃的条数
+func (biz *proxyAuditService) flush(dats []*entity.ProxyAudit) {
+	if n := biz.dropped.Swap(0); n != 0 {
+		biz.slog.Warnf("代理审计日志队列已满，丢弃了 %d 条日志", n)
+	}
+	if len(dats) == 0 {
+		return
+	}
+	// 退出时 ctx 已经取消，使用新的 context 写入剩余的日志
+	if err := biz.db.WithContext(context.Background()).
+		CreateInBatches(dats, biz.batch).Error; err != nil {
+		biz.slog.Warnf("写入 %d 条代理审计日志出错：%s", len(dats), err)
+	}
+}
+
+// auditWriter 记录响应状态码、大小，并采样响应报文的前 limit 个字节
+type auditWriter struct {
+	w      http.ResponseWriter
+	limit  int
+	status int
+	size   int64
+	sample bytes.Buffer
+}
+
+func (aw *auditWriter) Header() http.Header {
+	return aw.w.Header()
+}
+
+func (aw *auditWriter) WriteHeader(code int) {
+	if aw.status == 0 {
+		aw.status = code
+	}
+	aw.w.WriteHeader(code)
+}
+
+func (aw *auditWriter) Write(p []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	if remain := aw.limit - aw.sample.Len(); remain > 0 {
+		if remain > len(p) {
+			remain = len(p)
+		}
+		aw.sample.Write(p[:remain])
+	}
+	n, err := aw.w.Write(p)
+	aw.size += int64(n)
+
+	return n, err
+}
+
+func (aw *auditWriter) Flush() {
+	if f, ok := aw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 获取原始的 ResponseWriter
+func (aw *auditWriter) Unwrap() http.ResponseWriter {
+	return aw.w
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+type ProxyPolicyService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.ProxyPolicy)
+	Create(ctx context.Context, req *param.ProxyPolicyCreate, userID int64) error
+	Update(ctx context.Context, req *param.ProxyPolicyUpdate) error
+	Delete(ctx context.Context, id int64) error
+	Denials(ctx context.Context, req *param.ProxyDenialPage, page param.Pager) (int64, []*entity.ProxyDenial)
+
+	// Check 检查代理请求是否被允许，拒绝的请求会被记录下来。
+	//
+	// 没有任何启用的策略时保持原有行为允许所有请求；存在启用的策略后，
+	// 请求必须命中至少一条允许策略且没有命中拒绝策略才会放行。
+	Check(ctx context.Context, req *param.ProxyAccess) error
+}
+
+func ProxyPolicy(db *gorm.DB) ProxyPolicyService {
+	return &proxyPolicyService{
+		db: db,
+	}
+}
+
+type proxyPolicyService struct {
+	db *gorm.DB
+}
+
+func (biz *proxyPolicyService) Page(ctx context.Context, page param.Pager) (int64, []*entity.ProxyPolicy) {
+	db := biz.db.WithContext(ctx).Model(&entity.ProxyPolicy{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR comment LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ProxyPolicy
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *proxyPolicyService) Create(ctx context.Context, req *param.ProxyPolicyCreate, userID int64) error {
+	now := time.Now()
+	dat := &entity.ProxyPolicy{CreatedID: userID, CreatedAt: now}
+	biz.fill(dat, req, now)
+
+	return biz.db.WithContext(ctx).Create(dat).Error
+}
+
+func (biz *proxyPolicyService) Update(ctx context.Context, req *param.ProxyPolicyUpdate) error {
+	dat := new(entity.ProxyPolicy)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(dat).Error; err != nil {
+		return err
+	}
+	biz.fill(dat, &req.ProxyPolicyCreate, time.Now())
+
+	return biz.db.WithContext(ctx).Save(dat).Error
+}
+
+func (biz *proxyPolicyService) Delete(ctx context.Context, id int64) error {
+	ret := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&entity.ProxyPolicy{})
+	if ret.Error == nil && ret.RowsAffected == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	return ret.Error
+}
+
+func (biz *proxyPolicyService) Denials(ctx context.Context, req *param.ProxyDenialPage, page param.Pager) (int64, []*entity.ProxyDenial) {
+	db := biz.db.WithContext(ctx).Model(&entity.ProxyDenial{})
+	if req.UserID != 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if req.Kind != "" {
+		db = db.Where("kind = ?", req.Kind)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR path LIKE ? OR client_ip LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ProxyDenial
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *proxyPolicyService) Check(ctx context.Context, req *param.ProxyAccess) error {
+	var policies []*entity.ProxyPolicy
+	if err := biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Where("expired_at IS NULL OR expired_at > ?", time.Now()).
+		Find(&policies).Error; err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	var tags []string
+	var idc string
+	if mid := req.MinionID; mid != 0 {
+		tagTbl := query.MinionTag
+		_ = tagTbl.WithContext(ctx).
+			Where(tagTbl.MinionID.Eq(mid)).
+			Pluck(tagTbl.Tag, &tags)
+		monTbl := query.Minion
+		if mon, _ := monTbl.WithContext(ctx).
+			Select(monTbl.IDC).
+			Where(monTbl.ID.Eq(mid)).
+			First(); mon != nil {
+			idc = mon.IDC
+		}
+	}
+
+	var allowed bool
+	for _, p := range policies {
+		if !biz.match(p, req, tags, idc) {
+			continue
+		}
+		if !p.Allow {
+			biz.deny(req, p.ID)
+			return errcode.ErrProxyDenied
+		}
+		allowed = true
+	}
+	if !allowed {
+		biz.deny(req, 0)
+		return errcode.ErrProxyDenied
+	}
+
+	return nil
+}
+
+func (biz *proxyPolicyService) fill(dat *entity.ProxyPolicy, req *param.ProxyPolicyCreate, now time.Time) {
+	dat.Name = req.Name
+	dat.UserID = req.UserID
+	dat.Allow = req.Allow
+	dat.Kinds = req.Kinds
+	dat.Tags = req.Tags
+	dat.IDCs = req.IDCs
+	dat.BrokerIDs = req.BrokerIDs
+	dat.Paths = req.Paths
+	dat.Methods = req.Methods
+	dat.Enabled = req.Enabled
+	dat.ExpiredAt = sql.NullTime{}
+	if at := req.ExpiredAt; at != nil {
+		dat.ExpiredAt = sql.NullTime{Time: *at, Valid: true}
+	}
+	dat.Comment = req.Comment
+	dat.UpdatedAt = now
+}
+
+// match 判断代理请求是否命中策略。访问 broker 的请求没有标签与 IDC，
+// 限制了标签或 IDC 的策略不会命中 broker 请求。
+func (biz *proxyPolicyService) match(p *entity.ProxyPolicy, req *param.ProxyAccess, tags []string, idc string) bool {
+	if p.UserID != 0 && p.UserID != req.UserID {
+		return false
+	}
+	if len(p.Kinds) != 0 && !biz.contains(p.Kinds, req.Kind) {
+		return false
+	}
+	if len(p.Methods) != 0 && !biz.contains(p.Methods, req.Method) {
+		return false
+	}
+	if len(p.BrokerIDs) != 0 {
+		var found bool
+		for _, bid := range p.BrokerIDs {
+			if found = bid == req.BrokerID; found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(p.IDCs) != 0 && (req.MinionID == 0 || !biz.contains(p.IDCs, idc)) {
+		return false
+	}
+	if len(p.Tags) != 0 {
+		var found bool
+		for _, tag := range tags {
+			if found = biz.contains(p.Tags, tag); found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(p.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range p.Paths {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(req.Path, prefix) {
+			return true
+		}
+		if matched, _ := path.Match(pattern, req.Path); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (*proxyPolicyService) contains(elems []string, s string) bool {
+	for _, e := range elems {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (biz *proxyPolicyService) deny(req *param.ProxyAccess, policyID int64) {
+	dat := &entity.ProxyDenial{
+		UserID:    req.UserID,
+		Kind:      req.Kind,
+		MinionID:  req.MinionID,
+		BrokerID:  req.BrokerID,
+		Inet:      req.Inet,
+		Method:    req.Method,
+		Path:      req.Path,
+		PolicyID:  policyID,
+		ClientIP:  req.ClientIP,
+		CreatedAt: time.Now(),
+	}
+	_ = biz.db.Create(dat).Error
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/gridfs"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// RetentionService 高增长数据表的保留策略，后台分批清理过期数据，清理前可以归档到 gridfs。
+type RetentionService interface {
+	Policies(ctx context.Context) []*entity.RetentionPolicy
+	Upsert(ctx context.Context, req *param.RetentionPolicyUpsert, userID int64) error
+	Runs(ctx context.Context, req *param.RetentionRunPage, page param.Pager) (int64, []*entity.RetentionRun)
+
+	// Execute 立即执行一次数据表的清理
+	Execute(ctx context.Context, table string) (*entity.RetentionRun, error)
+	Archive(ctx context.Context, runID int64) (gridfs.File, error)
+
+	// Run 定时按照保留策略清理数据
+	Run(ctx context.Context)
+}
+
+func Retention(db *gorm.DB, gfs gridfs.FS, slog logback.Logger) RetentionService {
+	return &retentionService{
+		db:        db,
+		gfs:       gfs,
+		slog:      slog,
+		interval:  time.Hour,
+		batchSize: 500,
+		maxRows:   200_000,
+		pause:     100 * time.Millisecond,
+	}
+}
+
+type retentionService struct {
+	db        *gorm.DB
+	gfs       gridfs.FS
+	slog      logback.Logger
+	mutex     sync.Mutex // 同一时刻只允许一个清理任务
+	interval  time.Duration
+	batchSize int           // 每批删除的行数，避免长时间锁表
+	maxRows   int64         // 每次清理的最大行数，剩余的数据下次再清理
+	pause     time.Duration // 每批之间的停顿
+}
+
+// retentionTables 支持保留策略的数据表及其时间字段
+var retentionTables = map[string]string{
+	"risk":           "created_at",
+	"event":          "created_at",
+	"oplog":          "created_at",
+	"minion_logon":   "created_at",
+	"pass_dns":       "updated_at",
+	"pass_ip":        "updated_at",
+	"substance_task": "created_at",
+	"dash_rollup":    "bucket",
+}
+
+func (biz *retentionService) Policies(ctx context.Context) []*entity.RetentionPolicy {
+	var dats []*entity.RetentionPolicy
+	biz.db.WithContext(ctx).Find(&dats)
+	exists := make(map[string]struct{}, len(dats))
+	for _, dat := range dats {
+		exists[dat.Table] = struct{}{}
+	}
+	// 尚未配置策略的数据表也一并返回，方便前端展示
+	for _, table := range []string{"risk", "event", "oplog", "minion_logon", "pass_dns", "pass_ip", "substance_task", "dash_rollup"} {
+		if _, ok := exists[table]; !ok {
+			dats = append(dats, &entity.RetentionPolicy{Table: table})
+		}
+	}
+
+	return dats
+}
+
+func (biz *retentionService) Upsert(ctx context.Context, req *param.RetentionPolicyUpsert, userID int64) error {
+	now := time.Now()
+	dat := new(entity.RetentionPolicy)
+	biz.db.WithContext(ctx).
+		Where("table_name = ?", req.Table).
+		Limit(1).
+		Find(dat)
+	if dat.ID == 0 {
+		dat.Table = req.Table
+		dat.CreatedAt = now
+	}
+	dat.MaxDays = req.MaxDays
+	dat.MaxRows = req.MaxRows
+	dat.Archive = req.Archive
+	dat.Enabled = req.Enabled
+	dat.UpdatedID = userID
+	dat.UpdatedAt = now
+
+	return biz.db.WithContext(ctx).Save(dat).Error
+}
+
+func (biz *retentionService) Runs(ctx context.Context, req *param.RetentionRunPage, page param.Pager) (int64, []*entity.RetentionRun) {
+	db := biz.db.WithContext(ctx).Model(&entity.RetentionRun{})
+	if req.Table != "" {
+		db = db.Where("table_name = ?", req.Table)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.RetentionRun
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *retentionService) Execute(ctx context.Context, table string) (*entity.RetentionRun, error) {
+	policy := new(entity.RetentionPolicy)
+	biz.db.WithContext(ctx).
+		Where("table_name = ?", table).
+		Limit(1).
+		Find(policy)
+	if policy.ID == 0 || (policy.MaxDays <= 0 && policy.MaxRows <= 0) {
+		return nil, errcode.ErrRetentionPolicy
+	}
+	if !biz.mutex.TryLock() {
+		return nil, errcode.ErrTaskBusy
+	}
+	defer biz.mutex.Unlock()
+
+	return biz.purge(ctx, policy, true), nil
+}
+
+func (biz *retentionService) Archive(ctx context.Context, runID int64) (gridfs.File, error) {
+	run := new(entity.RetentionRun)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", runID).
+		First(run).Error; err != nil {
+		return nil, err
+	}
+	if run.FileID == 0 {
+		return nil, errcode.ErrNoAttachment
+	}
+
+	return biz.gfs.OpenID(run.FileID)
+}
+
+func (biz *retentionService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.purgeAll(ctx)
+		}
+	}
+}
+
+func (biz *retentionService) purgeAll(ctx context.Context) {
+	if !biz.mutex.TryLock() {
+		return
+	}
+	defer biz.mutex.Unlock()
+
+	var policies []*entity.RetentionPolicy
+	biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Find(&policies)
+	for _, policy := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		if policy.MaxDays <= 0 && policy.MaxRows <= 0 {
+			continue
+		}
+		if run := biz.purge(ctx, policy, false); run.Error != "" {
+			biz.slog.Warnf("清理数据表 %s 出错：%s", policy.Table, run.Error)
+		}
+	}
+}
+
+// purge 清理一张数据表并保存清理记录，没有需要清理的数据时不保存记录。
+func (biz *retentionService) purge(ctx context.Context, policy *entity.RetentionPolicy, manual bool) *entity.RetentionRun {
+	run := &entity.RetentionRun{Table: policy.Table, Manual: manual, StartedAt: time.Now()}
+	err := biz.execute(ctx, policy, run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.EndedAt = time.Now()
+	if run.Deleted != 0 || run.Error != "" || manual {
+		_ = biz.db.WithContext(ctx).Create(run).Error
+	}
+
+	return run
+}
+
+func (biz *retentionService) execute(ctx context.Context, policy *entity.RetentionPolicy, run *entity.RetentionRun) error {
+	table := policy.Table
+	timeCol, ok := retentionTables[table]
+	if !ok {
+		return errcode.ErrRetentionPolicy
+	}
+
+	where, args := biz.condition(ctx, policy, timeCol, run)
+	if where == "" {
+		return nil
+	}
+	scope := func(db *gorm.DB) *gorm.DB {
+		return db.Table(table).Where(where, args...)
+	}
+
+	// 先确定本次清理的 ID 上限，归档与删除都以此为界，保证删除的数据都已归档。
+	var maxID int64
+	if err := biz.db.WithContext(ctx).
+		Scopes(scope).
+		Select("id").
+		Order("id").
+		Offset(int(biz.maxRows - 1)).
+		Limit(1).
+		Scan(&maxID).Error; err != nil {
+		return err
+	}
+	if maxID == 0 {
+		if err := biz.db.WithContext(ctx).
+			Scopes(scope).
+			Select("MAX(id)").
+			Scan(&maxID).Error; err != nil || maxID == 0 {
+			return err
+		}
+	}
+
+	if policy.Archive {
+		if err := biz.archive(ctx, scope, maxID, run); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var ids []int64
+		if err := biz.db.WithContext(ctx).
+			Scopes(scope).
+			Where("id <= ?", maxID).
+			Order("id").
+			Limit(biz.batchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		ret := biz.db.WithContext(ctx).
+			Exec("DELETE FROM `"+table+"` WHERE id IN ?", ids)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		run.Deleted += ret.RowsAffected
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(biz.pause):
+		}
+	}
+}
+
+// condition 根据保留天数与保留行数生成清理条件
+func (biz *retentionService) condition(ctx context.Context, policy *entity.RetentionPolicy, timeCol string,
+	run *entity.RetentionRun,
+) (string, []any) {
+	var where string
+	var args []any
+	if days := policy.MaxDays; days > 0 {
+		cutoff := time.Now().AddDate(0, 0, -days)
+		run.Cutoff = sql.NullTime{Time: cutoff, Valid: true}
+		where = "`" + timeCol + "` < ?"
+		args = append(args, cutoff)
+	}
+	if rows := policy.MaxRows; rows > 0 {
+		// 保留 ID 最大的 MaxRows 行，之前的数据都需要清理
+		var pivot int64
+		biz.db.WithContext(ctx).
+			Table(policy.Table).
+			Select("id").
+			Order("id DESC").
+			Offset(int(rows)).
+			Limit(1).
+			Scan(&pivot)
+		if pivot != 0 {
+			if where != "" {
+				where += " OR "
+			}
+			where += "id <= ?"
+			args = append(args, pivot)
+		}
+	}
+
+	return where, args
+}
+
+// archive 将需要清理的数据以 gzip 压缩的 NDJSON 格式写入 gridfs
+func (biz *retentionService) archive(ctx context.Context, scope func(*gorm.DB) *gorm.DB, maxID int64, run *entity.RetentionRun) error {
+	file, err := os.CreateTemp("", "retention-*.ndjson.gz")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	gw := gzip.NewWriter(file)
+	enc := json.NewEncoder(gw)
+	var lastID int64
+	for {
+		var rows []map[string]any
+		if err = biz.db.WithContext(ctx).
+			Scopes(scope).
+			Where("id > ? AND id <= ?", lastID, maxID).
+			Order("id").
+			Limit(biz.batchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			for k, v := range row {
+				if bs, ok := v.([]byte); ok {
+					row[k] = string(bs)
+				}
+			}
+			if err = enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		run.Archived += int64(len(rows))
+		lastID = biz.rowID(rows[len(rows)-1])
+		if lastID == 0 {
+			return errcode.ErrRetentionPolicy
+		}
+	}
+	if err = gw.Close(); err != nil {
+		return err
+	}
+	if run.Archived == 0 {
+		return nil
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("retention-%s-%s.ndjson.gz", run.Table, run.StartedAt.Format("20060102150405"))
+	gf, err := biz.gfs.Write(file, name)
+	if err != nil {
+		return err
+	}
+	run.FileID = gf.ID()
+	run.FileSize = gf.Size()
+
+	return nil
+}
+
+func (*retentionService) rowID(row map[string]any) int64 {
+	switch id := row["id"].(type) {
+	case int64:
+		return id
+	case uint64:
+		return int64(id)
+	case int32:
+		return int64(id)
+	case string:
+		var n int64
+		_, _ = fmt.Sscan(id, &n)
+		return n
+	default:
+		return 0
+	}
+}
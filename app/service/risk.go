@@ -7,26 +7,38 @@ import (
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
 	"github.com/vela-ssoc/vela-common-mb/dynsql"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/internal/sheet"
 	"github.com/vela-ssoc/vela-manager/errcode"
 )
 
 type RiskService interface {
-	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.Risk)
+	Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*param.RiskItem)
 	Attack(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*param.RiskAttack)
 	Group(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*param.NameCount)
 	Recent(ctx context.Context, day int) *param.RecentCharts
 	Delete(ctx context.Context, scope dynsql.Scope) error
-	Ignore(ctx context.Context, scope dynsql.Scope) error
-	Process(ctx context.Context, scope dynsql.Scope) error
+	Ignore(ctx context.Context, scope dynsql.Scope, reason string, userID int64) error
+	Process(ctx context.Context, scope dynsql.Scope, reason string, userID int64) error
+
+	// Export 流式导出符合条件的风险事件，超过导出上限时返回错误。
+	Export(ctx context.Context, format string, scope dynsql.Scope) (sheet.CSVStreamer, error)
 }
 
-func Risk() RiskService {
-	return &riskService{}
+func Risk(rcase RiskCaseService, attack AttackService) RiskService {
+	return &riskService{
+		rcase:       rcase,
+		attack:      attack,
+		exportLimit: 100_000,
+	}
 }
 
-type riskService struct{}
+type riskService struct {
+	rcase       RiskCaseService
+	attack      AttackService
+	exportLimit int64 // 单次导出的最大条数
+}
 
-func (rsk *riskService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*model.Risk) {
+func (rsk *riskService) Page(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*param.RiskItem) {
 	tbl := query.Risk
 	db := tbl.WithContext(ctx).
 		UnderlyingDB().
@@ -39,7 +51,7 @@ func (rsk *riskService) Page(ctx context.Context, page param.Pager, scope dynsql
 	var ret []*model.Risk
 	db.Scopes(page.DBScope(count)).Find(&ret)
 
-	return count, ret
+	return count, rsk.attack.Annotate(ctx, ret)
 }
 
 func (rsk *riskService) Attack(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*param.RiskAttack) {
@@ -108,22 +120,27 @@ func (rsk *riskService) Delete(ctx context.Context, scope dynsql.Scope) error {
 	return errcode.ErrDeleteFailed
 }
 
-func (rsk *riskService) Ignore(ctx context.Context, scope dynsql.Scope) error {
-	tbl := query.Risk
-	col := tbl.Status.ColumnName().String()
-	tbl.WithContext(ctx).
-		UnderlyingDB().
-		Scopes(scope.Where).
-		UpdateColumn(col, model.RSIgnore)
-	return nil
+func (rsk *riskService) Ignore(ctx context.Context, scope dynsql.Scope, reason string, userID int64) error {
+	return rsk.rcase.Transit(ctx, scope.Where, model.RSIgnore, reason, userID)
 }
 
-func (rsk *riskService) Process(ctx context.Context, scope dynsql.Scope) error {
-	tbl := query.Risk
-	col := tbl.Status.ColumnName().String()
-	tbl.WithContext(ctx).
+func (rsk *riskService) Process(ctx context.Context, scope dynsql.Scope, reason string, userID int64) error {
+	return rsk.rcase.Transit(ctx, scope.Where, model.RSProcessed, reason, userID)
+}
+
+func (rsk *riskService) Export(ctx context.Context, format string, scope dynsql.Scope) (sheet.CSVStreamer, error) {
+	db := query.Risk.WithContext(ctx).
 		UnderlyingDB().
-		Scopes(scope.Where).
-		UpdateColumn(col, model.RSProcessed)
-	return nil
+		Scopes(scope.Where)
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > rsk.exportLimit {
+		return nil, errcode.FmtErrExportLimit.Fmt(count, rsk.exportLimit)
+	}
+
+	read := sheet.RiskCSV(ctx, db, 500, true)
+
+	return sheet.NewStream(format, read), nil
 }
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/gridfs"
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// RiskCaseService 风险事件处置：分派、评论、状态变更记录以及处理期限。
+type RiskCaseService interface {
+	// Transit 批量修改符合条件的风险事件状态，并记录每条事件的状态变更。
+	Transit(ctx context.Context, where func(*gorm.DB) *gorm.DB, status model.RiskStatus, reason string, userID int64) error
+	Status(ctx context.Context, req *param.RiskCaseStatus, userID int64) error
+	Assign(ctx context.Context, req *param.RiskAssign, userID int64) error
+	Detail(ctx context.Context, riskID int64) (*param.RiskCaseDetail, error)
+	Comment(ctx context.Context, riskID int64, content, name string, r io.Reader, userID int64) error
+	Attachment(ctx context.Context, commentID int64) (gridfs.File, error)
+
+	// Mine 分派给我的风险事件
+	Mine(ctx context.Context, req *param.RiskCasePage, page param.Pager, userID int64) (int64, []*param.RiskCaseItem)
+
+	// Run 定时标记超过处理期限的风险事件
+	Run(ctx context.Context)
+}
+
+func RiskCase(db *gorm.DB, gfs gridfs.FS) RiskCaseService {
+	return &riskCaseService{
+		db:       db,
+		gfs:      gfs,
+		interval: 5 * time.Minute,
+	}
+}
+
+type riskCaseService struct {
+	db       *gorm.DB
+	gfs      gridfs.FS
+	interval time.Duration
+}
+
+func (biz *riskCaseService) Transit(ctx context.Context, where func(*gorm.DB) *gorm.DB, status model.RiskStatus, reason string, userID int64) error {
+	username := biz.username(ctx, userID)
+	var lastID int64
+	for {
+		// 按照 ID 游标分批处理，避免一次性加载大量风险事件
+		var risks []*model.Risk
+		if err := biz.db.WithContext(ctx).
+			Model(&model.Risk{}).
+			Select("id", "status").
+			Scopes(where).
+			Where("id > ? AND status <> ?", lastID, status).
+			Order("id").
+			Limit(500).
+			Find(&risks).Error; err != nil {
+			return err
+		}
+		if len(risks) == 0 {
+			return nil
+		}
+		lastID = risks[len(risks)-1].ID
+
+		if err := biz.transit(ctx, risks, status, reason, userID, username); err != nil {
+			return err
+		}
+	}
+}
+
+func (biz *riskCaseService) Status(ctx context.Context, req *param.RiskCaseStatus, userID int64) error {
+	rsk := new(model.Risk)
+	if err := biz.db.WithContext(ctx).
+		Select("id", "status").
+		Where("id = ?", req.ID).
+		First(rsk).Error; err != nil {
+		return err
+	}
+	if rsk.Status == req.Status {
+		return nil
+	}
+	username := biz.username(ctx, userID)
+
+	return biz.transit(ctx, []*model.Risk{rsk}, req.Status, req.Reason, userID, username)
+}
+
+func (biz *riskCaseService) Assign(ctx context.Context, req *param.RiskAssign, userID int64) error {
+	assignee := biz.username(ctx, req.AssigneeID)
+	if assignee == "" {
+		return errcode.ErrUserNotExist
+	}
+
+	var ids []int64
+	if err := biz.db.WithContext(ctx).
+		Model(&model.Risk{}).
+		Where("id IN ?", req.IDs).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	now := time.Now()
+	var dueAt sql.NullTime
+	if at := req.DueAt; at != nil {
+		dueAt = sql.NullTime{Time: *at, Valid: true}
+	}
+	breached := dueAt.Valid && dueAt.Time.Before(now)
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var olds []*entity.RiskCase
+		if err := tx.Where("risk_id IN ?", ids).Find(&olds).Error; err != nil {
+			return err
+		}
+		exists := make(map[int64]struct{}, len(olds))
+		for _, old := range olds {
+			exists[old.RiskID] = struct{}{}
+		}
+
+		if len(olds) != 0 {
+			if err := tx.Model(&entity.RiskCase{}).
+				Where("risk_id IN ?", ids).
+				UpdateColumns(map[string]any{
+					"assignee_id": req.AssigneeID,
+					"assignee":    assignee,
+					"assigner_id": userID,
+					"due_at":      dueAt,
+					"breached":    breached,
+					"updated_at":  now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		cases := make([]*entity.RiskCase, 0, len(ids))
+		for _, id := range ids {
+			if _, ok := exists[id]; ok {
+				continue
+			}
+			cases = append(cases, &entity.RiskCase{
+				RiskID:     id,
+				AssigneeID: req.AssigneeID,
+				Assignee:   assignee,
+				AssignerID: userID,
+				DueAt:      dueAt,
+				Breached:   breached,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			})
+		}
+		if len(cases) == 0 {
+			return nil
+		}
+
+		return tx.CreateInBatches(cases, 200).Error
+	})
+}
+
+func (biz *riskCaseService) Detail(ctx context.Context, riskID int64) (*param.RiskCaseDetail, error) {
+	rsk := new(model.Risk)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", riskID).
+		First(rsk).Error; err != nil {
+		return nil, err
+	}
+
+	ret := &param.RiskCaseDetail{
+		Risk:        rsk,
+		Comments:    []*entity.RiskComment{},
+		Transitions: []*entity.RiskTransition{},
+	}
+	rc := new(entity.RiskCase)
+	if biz.db.WithContext(ctx).
+		Where("risk_id = ?", riskID).
+		Limit(1).
+		Find(rc); rc.ID != 0 {
+		ret.Case = rc
+	}
+	biz.db.WithContext(ctx).
+		Where("risk_id = ?", riskID).
+		Order("id").
+		Find(&ret.Comments)
+	biz.db.WithContext(ctx).
+		Where("risk_id = ?", riskID).
+		Order("id").
+		Find(&ret.Transitions)
+
+	return ret, nil
+}
+
+func (biz *riskCaseService) Comment(ctx context.Context, riskID int64, content, name string, r io.Reader, userID int64) error {
+	var count int64
+	if biz.db.WithContext(ctx).
+		Model(&model.Risk{}).
+		Where("id = ?", riskID).
+		Count(&count); count == 0 {
+		return errcode.ErrOperateFailed
+	}
+
+	dat := &entity.RiskComment{
+		RiskID:    riskID,
+		UserID:    userID,
+		Username:  biz.username(ctx, userID),
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+	if r != nil {
+		file, err := biz.gfs.Write(r, name)
+		if err != nil {
+			return err
+		}
+		dat.FileID = file.ID()
+		dat.FileName = name
+		dat.FileSize = file.Size()
+	}
+
+	err := biz.db.WithContext(ctx).Create(dat).Error
+	if err != nil && dat.FileID != 0 {
+		_ = biz.gfs.Remove(dat.FileID)
+	}
+
+	return err
+}
+
+func (biz *riskCaseService) Attachment(ctx context.Context, commentID int64) (gridfs.File, error) {
+	dat := new(entity.RiskComment)
+	if err := biz.db.WithContext(ctx).
+		Select("file_id").
+		Where("id = ?", commentID).
+		First(dat).Error; err != nil {
+		return nil, err
+	}
+	if dat.FileID == 0 {
+		return nil, errcode.ErrNoAttachment
+	}
+
+	return biz.gfs.OpenID(dat.FileID)
+}
+
+func (biz *riskCaseService) Mine(ctx context.Context, req *param.RiskCasePage, page param.Pager, userID int64) (int64, []*param.RiskCaseItem) {
+	db := biz.db.WithContext(ctx).
+		Table("risk").
+		Joins("JOIN risk_case ON risk_case.risk_id = risk.id").
+		Where("risk_case.assignee_id = ?", userID)
+	if req.Status != 0 {
+		db = db.Where("risk.status = ?", req.Status)
+	}
+	if req.Breached {
+		db = db.Where("risk_case.breached = ?", true)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("risk.subject LIKE ? OR risk.inet LIKE ? OR risk.remote_ip LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*param.RiskCaseItem
+	db.Select("risk.*", "risk_case.assignee_id", "risk_case.due_at", "risk_case.breached").
+		Order("risk_case.due_at IS NULL, risk_case.due_at, risk.id DESC").
+		Scopes(page.DBScope(count)).
+		Find(&dats)
+
+	return count, dats
+}
+
+func (biz *riskCaseService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.breach(ctx)
+		}
+	}
+}
+
+// transit 修改风险事件状态并记录状态变更，risks 中需要包含 ID 与变更前的状态。
+func (biz *riskCaseService) transit(ctx context.Context, risks []*model.Risk, status model.RiskStatus, reason string,
+	userID int64, username string,
+) error {
+	now := time.Now()
+	ids := make([]int64, 0, len(risks))
+	logs := make([]*entity.RiskTransition, 0, len(risks))
+	for _, rsk := range risks {
+		ids = append(ids, rsk.ID)
+		logs = append(logs, &entity.RiskTransition{
+			RiskID:     rsk.ID,
+			UserID:     userID,
+			Username:   username,
+			FromStatus: rsk.Status,
+			ToStatus:   status,
+			Reason:     reason,
+			CreatedAt:  now,
+		})
+	}
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Risk{}).
+			Where("id IN ?", ids).
+			UpdateColumn("status", status).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(logs, 200).Error
+	})
+}
+
+// breach 标记超过处理期限仍未处理的风险事件
+func (biz *riskCaseService) breach(ctx context.Context) {
+	sub := biz.db.Model(&model.Risk{}).
+		Select("id").
+		Where("status = ?", model.RSUnprocessed)
+	biz.db.WithContext(ctx).
+		Model(&entity.RiskCase{}).
+		Where("breached = ? AND due_at < ?", false, time.Now()).
+		Where("risk_id IN (?)", sub).
+		UpdateColumn("breached", true)
+}
+
+func (biz *riskCaseService) username(ctx context.Context, userID int64) string {
+	if userID == 0 {
+		return ""
+	}
+	tbl := query.User
+	u, _ := tbl.WithContext(ctx).
+		Select(tbl.Username).
+		Where(tbl.ID.Eq(userID)).
+		First()
+	if u == nil {
+		return ""
+	}
+
+	return u.Username
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vela-ssoc/vela-common-mb/dal/gridfs"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"gorm.io/gorm"
+)
+
+type SessionRecordService interface {
+	Page(ctx context.Context, req *param.SessionRecordPage, page param.Pager) (int64, []*entity.SessionRecord)
+
+	// Replay 打开录像，返回解压后的 NDJSON 帧数据
+	Replay(ctx context.Context, id int64) (io.ReadCloser, error)
+	Delete(ctx context.Context, id int64) error
+	Retention(ctx context.Context) *param.SessionRetention
+	SetRetention(ctx context.Context, req *param.SessionRetention) error
+
+	// Pipe 双向转发 websocket 数据并录制会话，未开启录像时只转发数据
+	Pipe(ctx context.Context, up, down *websocket.Conn, rec *entity.SessionRecord)
+
+	// Run 定时清理过期的录像
+	Run(ctx context.Context)
+}
+
+func SessionRecord(db *gorm.DB, gfs gridfs.FS) SessionRecordService {
+	return &sessionRecordService{
+		db:       db,
+		gfs:      gfs,
+		interval: time.Hour,
+	}
+}
+
+type sessionRecordService struct {
+	db       *gorm.DB
+	gfs      gridfs.FS
+	interval time.Duration
+}
+
+// sessionRetentionID 录像保留设置在 store 中的 ID
+const sessionRetentionID = "global.session.retention"
+
+func (biz *sessionRecordService) Page(ctx context.Context, req *param.SessionRecordPage, page param.Pager) (int64, []*entity.SessionRecord) {
+	db := biz.db.WithContext(ctx).Model(&entity.SessionRecord{})
+	if req.Kind != "" {
+		db = db.Where("kind = ?", req.Kind)
+	}
+	if req.UserID != 0 {
+		db = db.Where("user_id = ?", req.UserID)
+	}
+	if req.MinionID != 0 {
+		db = db.Where("minion_id = ?", req.MinionID)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("username LIKE ? OR inet LIKE ? OR path LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.SessionRecord
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *sessionRecordService) Replay(ctx context.Context, id int64) (io.ReadCloser, error) {
+	rec := new(entity.SessionRecord)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(rec).Error; err != nil {
+		return nil, err
+	}
+	file, err := biz.gfs.OpenID(rec.FileID)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &gzipFile{Reader: gr, file: file}, nil
+}
+
+func (biz *sessionRecordService) Delete(ctx context.Context, id int64) error {
+	rec := new(entity.SessionRecord)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(rec).Error; err != nil {
+		return err
+	}
+	if err := biz.db.WithContext(ctx).Delete(rec).Error; err != nil {
+		return err
+	}
+	_ = biz.gfs.Remove(rec.FileID)
+
+	return nil
+}
+
+func (biz *sessionRecordService) Retention(ctx context.Context) *param.SessionRetention {
+	ret := &param.SessionRetention{Enabled: true, Days: 90, MaxSize: 64}
+	storeLoadJSON(ctx, sessionRetentionID, ret)
+
+	return ret
+}
+
+func (biz *sessionRecordService) SetRetention(ctx context.Context, req *param.SessionRetention) error {
+	return storeSaveJSON(ctx, sessionRetentionID, "会话录像保留设置", req)
+}
+
+func (biz *sessionRecordService) Pipe(ctx context.Context, up, down *websocket.Conn, rec *entity.SessionRecord) {
+	ret := biz.Retention(ctx)
+	var rcd *sessionRecorder
+	if ret.Enabled {
+		rcd, _ = newSessionRecorder(int64(ret.MaxSize) << 20)
+	}
+
+	rec.StartedAt = time.Now()
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rec.Output = biz.copy(down, up, rcd, "o")
+		_ = down.Close()
+	}()
+	rec.Input = biz.copy(up, down, rcd, "i")
+	_ = up.Close()
+	wg.Wait()
+	rec.EndedAt = time.Now()
+
+	if rcd != nil {
+		biz.save(rec, rcd)
+	}
+}
+
+func (biz *sessionRecordService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.cleanup(ctx)
+		}
+	}
+}
+
+// copy 将 src 的 websocket 消息转发到 dst，返回转发的字节数
+func (biz *sessionRecordService) copy(dst, src *websocket.Conn, rcd *sessionRecorder, direction string) int64 {
+	var size int64
+	for {
+		mt, data, err := src.ReadMessage()
+		if err != nil {
+			return size
+		}
+		size += int64(len(data))
+		if rcd != nil {
+			rcd.frame(direction, mt, data)
+		}
+		if err = dst.WriteMessage(mt, data); err != nil {
+			return size
+		}
+	}
+}
+
+// save 将录像写入 gridfs 并保存记录
+func (biz *sessionRecordService) save(rec *entity.SessionRecord, rcd *sessionRecorder) {
+	defer rcd.remove()
+	if err := rcd.close(); err != nil || rcd.frames == 0 {
+		return
+	}
+	if _, err := rcd.file.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+
+	ctx := context.Background()
+	if rec.UserID != 0 {
+		tbl := query.User
+		if u, _ := tbl.WithContext(ctx).
+			Select(tbl.Username).
+			Where(tbl.ID.Eq(rec.UserID)).
+			First(); u != nil {
+			rec.Username = u.Username
+		}
+	}
+
+	name := rec.Kind + "-" + rec.StartedAt.Format("20060102150405") + ".ndjson.gz"
+	file, err := biz.gfs.Write(rcd.file, name)
+	if err != nil {
+		return
+	}
+	rec.FileID = file.ID()
+	rec.Size = file.Size()
+	rec.Frames = rcd.frames
+	rec.Truncated = rcd.truncated
+	rec.CreatedAt = time.Now()
+	if err = biz.db.WithContext(ctx).Create(rec).Error; err != nil {
+		_ = biz.gfs.Remove(rec.FileID)
+	}
+}
+
+// cleanup 删除超过保留天数的录像
+func (biz *sessionRecordService) cleanup(ctx context.Context) {
+	ret := biz.Retention(ctx)
+	before := time.Now().AddDate(0, 0, -ret.Days)
+	for {
+		var dats []*entity.SessionRecord
+		biz.db.WithContext(ctx).
+			Select("id", "file_id").
+			Where("created_at < ?", before).
+			Order("id").
+			Limit(100).
+			Find(&dats)
+		if len(dats) == 0 {
+			return
+		}
+
+		ids := make([]int64, 0, len(dats))
+		for _, dat := range dats {
+			ids = append(ids, dat.ID)
+			_ = biz.gfs.Remove(dat.FileID)
+		}
+		if err := biz.db.WithContext(ctx).
+			Where("id IN ?", ids).
+			Delete(&entity.SessionRecord{}).Error; err != nil {
+			return
+		}
+	}
+}
+
+// sessionFrame 录像中的一帧，每帧是 NDJSON 中的一行
+type sessionFrame struct {
+	Offset    int64  `json:"t"` // 距离会话开始的毫秒数
+	Direction string `json:"d"` // i-控制台发送给节点 o-节点发送给控制台
+	Type      int    `json:"k"` // websocket 消息类型
+	Data      []byte `json:"b"` // 消息内容
+}
+
+// sessionRecorder 会话录制过程中先写入临时文件，会话结束后再写入 gridfs，避免长会话占用内存。
+type sessionRecorder struct {
+	mutex     sync.Mutex
+	file      *os.File
+	gw        *gzip.Writer
+	enc       *json.Encoder
+	start     time.Time
+	limit     int64
+	size      int64
+	frames    int
+	truncated bool
+}
+
+func newSessionRecorder(limit int64) (*sessionRecorder, error) {
+	file, err := os.CreateTemp("", "session-*.ndjson.gz")
+	if err != nil {
+		return nil, err
+	}
+	gw := gzip.NewWriter(file)
+
+	return &sessionRecorder{
+		file:  file,
+		gw:    gw,
+		enc:   json.NewEncoder(gw),
+		start: time.Now(),
+		limit: limit,
+	}, nil
+}
+
+func (sr *sessionRecorder) frame(direction string, mt int, data []byte) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	if sr.truncated {
+		return
+	}
+	if sr.size += int64(len(data)); sr.size > sr.limit {
+		sr.truncated = true
+		return
+	}
+
+	fm := &sessionFrame{
+		Offset:    time.Since(sr.start).Milliseconds(),
+		Direction: direction,
+		Type:      mt,
+		Data:      data,
+	}
+	if err := sr.enc.Encode(fm); err == nil {
+		sr.frames++
+	}
+}
+
+func (sr *sessionRecorder) close() error {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+	return sr.gw.Close()
+}
+
+func (sr *sessionRecorder) remove() {
+	_ = sr.file.Close()
+	_ = os.Remove(sr.file.Name())
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file gridfs.File
+}
+
+func (gf *gzipFile) Close() error {
+	_ = gf.Reader.Close()
+	return gf.file.Close()
+}
@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/internal/siem"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+)
+
+// SiemService 将新增的 risk event oplog 数据以 RFC 5424 syslog 的方式转发到 SIEM，
+// 每个数据源的转发进度保存在数据库中，重启后从游标处继续转发。
+// 每次转发前通过 cursorSeen 补发提交延迟的数据。
+type SiemService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*param.SiemForwarderItem)
+	Create(ctx context.Context, req *param.SiemForwarderCreate, userID int64) error
+	Update(ctx context.Context, req *param.SiemForwarderUpdate) error
+	Delete(ctx context.Context, id int64) error
+
+	// Test 向接收端发送一条测试消息
+	Test(ctx context.Context, id int64) error
+
+	// Run 定时转发新增的数据
+	Run(ctx context.Context)
+}
+
+func Siem(db *gorm.DB, slog logback.Logger) SiemService {
+	hostname, _ := os.Hostname()
+	return &siemService{
+		db:       db,
+		slog:     slog,
+		hostname: hostname,
+		interval: 5 * time.Second,
+		timeout:  5 * time.Second,
+		batch:    500,
+		rounds:   20,
+		writers:  make(map[int64]*siemWriter, 8),
+		seen:     cursorSeen{db: db},
+	}
+}
+
+type siemService struct {
+	db       *gorm.DB
+	slog     logback.Logger
+	hostname string
+	interval time.Duration
+	timeout  time.Duration
+	batch    int // 每批读取的条数
+	rounds   int // 每次转发的最大批次，避免单个数据源积压影响其它转发器
+	writers  map[int64]*siemWriter
+	seen     cursorSeen
+}
+
+// siemWriter 缓存转发器的连接，连接参数变化后重新连接。
+type siemWriter struct {
+	*siem.Writer
+	key string
+}
+
+// siemSource 数据源，按照 ID 顺序读取 db 条件下的数据并转换为 siem.Record
+type siemSource struct {
+	table string
+	read  func(ctx context.Context, db *gorm.DB, limit int) ([]*siem.Record, error)
+}
+
+var siemSources = map[string]siemSource{
+	"risk":  {table: "risk", read: siemRisks},
+	"event": {table: "event", read: siemEvents},
+	"oplog": {table: "oplog", read: siemOplogs},
+}
+
+func (biz *siemService) Page(ctx context.Context, page param.Pager) (int64, []*param.SiemForwarderItem) {
+	db := biz.db.WithContext(ctx).Model(&entity.SiemForwarder{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR address LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var fwds []*entity.SiemForwarder
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&fwds)
+	ids := make([]int64, 0, len(fwds))
+	for _, fwd := range fwds {
+		ids = append(ids, fwd.ID)
+	}
+	var cursors []*entity.SiemCursor
+	biz.db.WithContext(ctx).
+		Where("forwarder_id IN ?", ids).
+		Find(&cursors)
+	hm := make(map[int64][]*entity.SiemCursor, len(fwds))
+	for _, cur := range cursors {
+		hm[cur.ForwarderID] = append(hm[cur.ForwarderID], cur)
+	}
+
+	dats := make([]*param.SiemForwarderItem, 0, len(fwds))
+	for _, fwd := range fwds {
+		curs := hm[fwd.ID]
+		if curs == nil {
+			curs = []*entity.SiemCursor{}
+		}
+		dats = append(dats, &param.SiemForwarderItem{SiemForwarder: fwd, Cursors: curs})
+	}
+
+	return count, dats
+}
+
+func (biz *siemService) Create(ctx context.Context, req *param.SiemForwarderCreate, userID int64) error {
+	now := time.Now()
+	dat := &entity.SiemForwarder{CreatedID: userID, CreatedAt: now}
+	biz.fill(dat, req, now)
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dat).Error; err != nil {
+			return err
+		}
+		return biz.initCursors(tx, dat)
+	})
+}
+
+func (biz *siemService) Update(ctx context.Context, req *param.SiemForwarderUpdate) error {
+	dat := new(entity.SiemForwarder)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(dat).Error; err != nil {
+		return err
+	}
+	biz.fill(dat, &req.SiemForwarderCreate, time.Now())
+
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(dat).Error; err != nil {
+			return err
+		}
+		return biz.initCursors(tx, dat)
+	})
+}
+
+func (biz *siemService) Delete(ctx context.Context, id int64) error {
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret := tx.Where("id = ?", id).Delete(&entity.SiemForwarder{})
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if ret.RowsAffected == 0 {
+			return errcode.ErrOperateFailed
+		}
+		return tx.Where("forwarder_id = ?", id).Delete(&entity.SiemCursor{}).Error
+	})
+}
+
+func (biz *siemService) Test(ctx context.Context, id int64) error {
+	fwd := new(entity.SiemForwarder)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(fwd).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rec := &siem.Record{
+		Source:    "test",
+		Signature: "test",
+		Name:      "SIEM 转发测试消息",
+		Time:      now,
+		Fields:    []siem.Field{{Key: "msg", Value: "forwarder " + fwd.Name}},
+	}
+	msg, err := biz.message(fwd, rec)
+	if err != nil {
+		return err
+	}
+	w, err := siem.Dial(fwd.Network, fwd.Address, fwd.Insecure, biz.timeout)
+	if err != nil {
+		return errcode.FmtErrSiemSend.Fmt(err)
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer w.Close()
+	if err = w.Write(msg); err != nil {
+		return errcode.FmtErrSiemSend.Fmt(err)
+	}
+
+	return nil
+}
+
+func (biz *siemService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer func() {
+		ticker.Stop()
+		for id, w := range biz.writers {
+			_ = w.Close()
+			delete(biz.writers, id)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.forwardAll(ctx)
+			if err := biz.seen.prune(ctx); err != nil {
+				biz.slog.Warnf("清理 SIEM 回扫记录出错：%s", err)
+			}
+		}
+	}
+}
+
+func (biz *siemService) fill(dat *entity.SiemForwarder, req *param.SiemForwarderCreate, now time.Time) {
+	dat.Name = req.Name
+	dat.Network = req.Network
+	dat.Address = req.Address
+	dat.Insecure = req.Insecure
+	dat.Format = req.Format
+	dat.Sources = req.Sources
+	dat.Facility = req.Facility
+	dat.Enabled = req.Enabled
+	dat.UpdatedAt = now
+}
+
+// initCursors 为新增的数据源创建游标，新游标从当前最大 ID 开始，不转发历史数据。
+func (biz *siemService) initCursors(tx *gorm.DB, fwd *entity.SiemForwarder) error {
+	var olds []string
+	if err := tx.Model(&entity.SiemCursor{}).
+		Where("forwarder_id = ?", fwd.ID).
+		Pluck("source", &olds).Error; err != nil {
+		return err
+	}
+	exists := make(map[string]struct{}, len(olds))
+	for _, old := range olds {
+		exists[old] = struct{}{}
+	}
+
+	now := time.Now()
+	for _, name := range fwd.Sources {
+		src, ok := siemSources[name]
+		if !ok {
+			continue
+		}
+		if _, ok = exists[name]; ok {
+			continue
+		}
+		var maxID int64
+		if err := tx.Table(src.table).
+			Select("COALESCE(MAX(id), 0)").
+			Scan(&maxID).Error; err != nil {
+			return err
+		}
+		cur := &entity.SiemCursor{ForwarderID: fwd.ID, Source: name, FloorID: maxID, LastID: maxID, UpdatedAt: now}
+		if err := tx.Create(cur).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (biz *siemService) forwardAll(ctx context.Context) {
+	var fwds []*entity.SiemForwarder
+	biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Find(&fwds)
+
+	actives := make(map[int64]struct{}, len(fwds))
+	for _, fwd := range fwds {
+		if ctx.Err() != nil {
+			return
+		}
+		actives[fwd.ID] = struct{}{}
+		err := biz.forward(ctx, fwd)
+		biz.report(ctx, fwd, err)
+	}
+
+	// 关闭已经删除或禁用的转发器连接
+	for id, w := range biz.writers {
+		if _, ok := actives[id]; !ok {
+			_ = w.Close()
+			delete(biz.writers, id)
+		}
+	}
+}
+
+func (biz *siemService) forward(ctx context.Context, fwd *entity.SiemForwarder) error {
+	var cursors []*entity.SiemCursor
+	biz.db.WithContext(ctx).
+		Where("forwarder_id = ?", fwd.ID).
+		Find(&cursors)
+	for _, cur := range cursors {
+		if !fwd.Sources.Contains(cur.Source) {
+			continue
+		}
+		if err := biz.forwardSource(ctx, fwd, cur); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forwardSource 先补发提交延迟的数据，再按照游标分批转发，每批发送完毕后保存游标，
+// 发送失败时游标停留在最后一条发送成功的数据。
+func (biz *siemService) forwardSource(ctx context.Context, fwd *entity.SiemForwarder, cur *entity.SiemCursor) error {
+	src, ok := siemSources[cur.Source]
+	if !ok {
+		return nil
+	}
+
+	key := "siem:" + strconv.FormatInt(cur.ID, 10)
+	ids, err := biz.seen.late(ctx, key, src.table, cur.FloorID, cur.LastID, biz.batch)
+	if err != nil {
+		return err
+	}
+	if len(ids) != 0 {
+		db := biz.db.WithContext(ctx).Where("id IN ?", ids)
+		recs, err := src.read(ctx, db, biz.batch)
+		if err != nil {
+			return err
+		}
+		n, err := biz.write(fwd, recs)
+		if exx := biz.saveCursor(ctx, key, cur, cur.LastID, recs[:n]); exx != nil {
+			return exx
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < biz.rounds; i++ {
+		db := biz.db.WithContext(ctx).Where("id > ?", cur.LastID)
+		recs, err := src.read(ctx, db, biz.batch)
+		if err != nil || len(recs) == 0 {
+			return err
+		}
+
+		n, err := biz.write(fwd, recs)
+		if n != 0 {
+			if exx := biz.saveCursor(ctx, key, cur, recs[n-1].ID, recs[:n]); exx != nil {
+				return exx
+			}
+		}
+		if err != nil || len(recs) < biz.batch {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write 依次发送数据，返回发送成功的条数。
+func (biz *siemService) write(fwd *entity.SiemForwarder, recs []*siem.Record) (int, error) {
+	if len(recs) == 0 {
+		return 0, nil
+	}
+	w, err := biz.writer(fwd)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, rec := range recs {
+		msg, err := biz.message(fwd, rec)
+		if err != nil {
+			return i, err
+		}
+		if err = w.Write(msg); err != nil {
+			biz.closeWriter(fwd.ID)
+			return i, err
+		}
+	}
+
+	return len(recs), nil
+}
+
+// saveCursor 在同一事务中保存游标与已转发的数据 ID。
+func (biz *siemService) saveCursor(ctx context.Context, key string, cur *entity.SiemCursor, lastID int64, sent []*siem.Record) error {
+	if len(sent) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(sent))
+	for _, rec := range sent {
+		ids = append(ids, rec.ID)
+	}
+
+	now := time.Now()
+	if err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := biz.seen.mark(tx, key, ids, now); err != nil {
+			return err
+		}
+		return tx.Model(cur).
+			UpdateColumns(map[string]any{
+				"last_id":    lastID,
+				"sent":       gorm.Expr("sent + ?", len(sent)),
+				"updated_at": now,
+			}).Error
+	}); err != nil {
+		return err
+	}
+	cur.LastID = lastID
+
+	return nil
+}
+
+func (biz *siemService) message(fwd *entity.SiemForwarder, rec *siem.Record) (string, error) {
+	msg, err := siem.Format(fwd.Format, rec)
+	if err != nil {
+		return "", err
+	}
+	msgID := rec.Source
+	facility := fwd.Facility
+	if facility == 0 {
+		facility = 16 // local0
+	}
+
+	return siem.Syslog(facility, rec.Severity, biz.hostname, msgID, msg, rec.Time), nil
+}
+
+func (biz *siemService) writer(fwd *entity.SiemForwarder) (*siemWriter, error) {
+	key := fwd.Network + "|" + fwd.Address + "|" + strconv.FormatBool(fwd.Insecure)
+	if w := biz.writers[fwd.ID]; w != nil {
+		if w.key == key {
+			return w, nil
+		}
+		biz.closeWriter(fwd.ID)
+	}
+
+	sw, err := siem.Dial(fwd.Network, fwd.Address, fwd.Insecure, biz.timeout)
+	if err != nil {
+		return nil, err
+	}
+	w := &siemWriter{Writer: sw, key: key}
+	biz.writers[fwd.ID] = w
+
+	return w, nil
+}
+
+func (biz *siemService) closeWriter(id int64) {
+	if w := biz.writers[id]; w != nil {
+		_ = w.Close()
+		delete(biz.writers, id)
+	}
+}
+
+// report 保存转发结果，只在状态变化或成功转发后更新。
+func (biz *siemService) report(ctx context.Context, fwd *entity.SiemForwarder, err error) {
+	cols := make(map[string]any, 2)
+	if err != nil {
+		if fwd.LastError == err.Error() {
+			return
+		}
+		biz.slog.Warnf("SIEM 转发器 %s 转发出错：%s", fwd.Name, err)
+		cols["last_error"] = err.Error()
+	} else {
+		cols["last_error"] = ""
+		cols["last_sent_at"] = time.Now()
+	}
+	biz.db.WithContext(ctx).
+		Model(fwd).
+		UpdateColumns(cols)
+}
+
+func siemRisks(ctx context.Context, db *gorm.DB, limit int) ([]*siem.Record, error) {
+	var risks []*model.Risk
+	if err := db.WithContext(ctx).
+		Order("id").
+		Limit(limit).
+		Find(&risks).Error; err != nil {
+		return nil, err
+	}
+
+	recs := make([]*siem.Record, 0, len(risks))
+	for _, rsk := range risks {
+		severity := 3
+		switch rsk.Level {
+		case model.RLvlCritical:
+			severity = 10
+		case model.RLvlHigh:
+			severity = 8
+		case model.RLvlMiddle:
+			severity = 5
+		}
+		recs = append(recs, &siem.Record{
+			Source:    "risk",
+			ID:        rsk.ID,
+			Signature: rsk.RiskType,
+			Name:      rsk.Subject,
+			Severity:  severity,
+			Time:      rsk.OccurAt,
+			Fields: []siem.Field{
+				{Key: "dvc", Value: rsk.Inet},
+				{Key: "deviceExternalId", Value: strconv.FormatInt(rsk.MinionID, 10)},
+				{Key: "src", Value: rsk.RemoteIP},
+				{Key: "spt", Value: siemPort(rsk.RemotePort)},
+				{Key: "dst", Value: rsk.LocalIP},
+				{Key: "dpt", Value: siemPort(rsk.LocalPort)},
+				{Key: "cs1Label", Value: "level"},
+				{Key: "cs1", Value: rsk.Level.String()},
+				{Key: "cs2Label", Value: "from_code"},
+				{Key: "cs2", Value: rsk.FromCode},
+				{Key: "cs3Label", Value: "region"},
+				{Key: "cs3", Value: rsk.Region},
+				{Key: "msg", Value: rsk.Payload},
+			},
+		})
+	}
+
+	return recs, nil
+}
+
+func siemEvents(ctx context.Context, db *gorm.DB, limit int) ([]*siem.Record, error) {
+	var evts []*model.Event
+	if err := db.WithContext(ctx).
+		Order("id").
+		Limit(limit).
+		Find(&evts).Error; err != nil {
+		return nil, err
+	}
+
+	recs := make([]*siem.Record, 0, len(evts))
+	for _, evt := range evts {
+		severity := 3
+		switch evt.Level {
+		case model.ELvlCritical:
+			severity = 10
+		case model.ELvlMajor:
+			severity = 7
+		case model.ELvlMinor:
+			severity = 5
+		}
+		recs = append(recs, &siem.Record{
+			Source:    "event",
+			ID:        evt.ID,
+			Signature: evt.Typeof,
+			Name:      evt.Subject,
+			Severity:  severity,
+			Time:      evt.OccurAt,
+			Fields: []siem.Field{
+				{Key: "dvc", Value: evt.Inet},
+				{Key: "deviceExternalId", Value: strconv.FormatInt(evt.MinionID, 10)},
+				{Key: "src", Value: evt.RemoteAddr},
+				{Key: "spt", Value: siemPort(evt.RemotePort)},
+				{Key: "suser", Value: evt.User},
+				{Key: "cs1Label", Value: "level"},
+				{Key: "cs1", Value: evt.Level.String()},
+				{Key: "cs2Label", Value: "from_code"},
+				{Key: "cs2", Value: evt.FromCode},
+				{Key: "cs3Label", Value: "region"},
+				{Key: "cs3", Value: evt.Region},
+				{Key: "msg", Value: evt.Msg},
+			},
+		})
+	}
+
+	return recs, nil
+}
+
+func siemOplogs(ctx context.Context, db *gorm.DB, limit int) ([]*siem.Record, error) {
+	// 请求报文可能很大，不转发
+	var logs []*model.Oplog
+	if err := db.WithContext(ctx).
+		Omit("content").
+		Order("id").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	recs := make([]*siem.Record, 0, len(logs))
+	for _, log := range logs {
+		severity, outcome := 1, "success"
+		if log.Failed {
+			severity, outcome = 5, "failure"
+		}
+		recs = append(recs, &siem.Record{
+			Source:    "oplog",
+			ID:        log.ID,
+			Signature: log.Method,
+			Name:      log.Name,
+			Severity:  severity,
+			Time:      log.RequestAt,
+			Fields: []siem.Field{
+				{Key: "suser", Value: log.Username},
+				{Key: "src", Value: log.ClientAddr},
+				{Key: "requestMethod", Value: log.Method},
+				{Key: "request", Value: log.Path},
+				{Key: "outcome", Value: outcome},
+				{Key: "reason", Value: log.Cause},
+			},
+		})
+	}
+
+	return recs, nil
+}
+
+func siemPort(port int) string {
+	if port <= 0 {
+		return ""
+	}
+	return strconv.Itoa(port)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
@@ -121,3 +122,35 @@ func (biz *storeService) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// storeLoadJSON 读取 store 中保存的 JSON 设置，不存在或者格式错误时 v 保持默认值。
+func storeLoadJSON(ctx context.Context, id string, v any) {
+	tbl := query.Store
+	if dat, err := tbl.WithContext(ctx).
+		Where(tbl.ID.Eq(id)).
+		First(); err == nil {
+		_ = json.Unmarshal(dat.Value, v)
+	}
+}
+
+// storeSaveJSON 将设置以 JSON 格式保存到 store 中，不存在时新增，存在时覆盖并递增版本号。
+func storeSaveJSON(ctx context.Context, id, desc string, v any) error {
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tbl := query.Store
+	if _, err = tbl.WithContext(ctx).Where(tbl.ID.Eq(id)).First(); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		dat := &model.Store{ID: id, Value: val, Desc: desc}
+		return tbl.WithContext(ctx).Create(dat)
+	}
+	_, err = tbl.WithContext(ctx).
+		Where(tbl.ID.Eq(id)).
+		UpdateSimple(tbl.Value.Value(val), tbl.Version.Add(1))
+
+	return err
+}
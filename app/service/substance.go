@@ -7,6 +7,7 @@ import (
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
 	"github.com/vela-ssoc/vela-manager/app/internal/transact"
 	"github.com/vela-ssoc/vela-manager/bridge/push"
@@ -22,14 +23,15 @@ type SubstanceService interface {
 	Delete(ctx context.Context, id int64) error
 	Reload(ctx context.Context, mid, sid int64) error
 	Resync(ctx context.Context, mid int64) error
-	Command(ctx context.Context, mid int64, cmd string) error
+	Command(ctx context.Context, mid int64, cmd string, userID int64) (*entity.CommandExecItem, error)
 }
 
-func Substance(pusher push.Pusher, digest DigestService, sequence SequenceService) SubstanceService {
+func Substance(pusher push.Pusher, digest DigestService, sequence SequenceService, command CommandExecService) SubstanceService {
 	return &substanceService{
 		pusher:   pusher,
 		digest:   digest,
 		sequence: sequence,
+		command:  command,
 	}
 }
 
@@ -39,6 +41,7 @@ type substanceService struct {
 	pusher   push.Pusher
 	digest   DigestService
 	sequence SequenceService
+	command  CommandExecService
 }
 
 func (biz *substanceService) Indices(ctx context.Context, idx param.Indexer) []*param.IDName {
@@ -345,25 +348,9 @@ func (biz *substanceService) Resync(ctx context.Context, mid int64) error {
 	return nil
 }
 
-// Command 向指定节点发送指令
-func (biz *substanceService) Command(ctx context.Context, mid int64, cmd string) error {
-	// 检查 minion 节点
-	monTbl := query.Minion
-	mon, err := monTbl.WithContext(ctx).
-		Select(monTbl.ID, monTbl.Status, monTbl.BrokerID).
-		Where(monTbl.ID.Eq(mid)).
-		First()
-	if err != nil {
-		return err
-	}
-	status := mon.Status
-	if status != model.MSOnline && status != model.MSOffline {
-		return errcode.ErrNodeStatus
-	}
-
-	biz.pusher.Command(ctx, mon.BrokerID, mid, cmd)
-
-	return nil
+// Command 向指定节点发送指令，并记录执行结果
+func (biz *substanceService) Command(ctx context.Context, mid int64, cmd string, userID int64) (*entity.CommandExecItem, error) {
+	return biz.command.Exec(ctx, mid, cmd, userID)
 }
 
 // existTask 是否有正在运行的任务
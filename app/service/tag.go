@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/vela-ssoc/vela-common-mb/dal/model"
 	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
 	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/app/internal/transact"
 	"github.com/vela-ssoc/vela-manager/bridge/push"
 	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -15,16 +20,32 @@ type TagService interface {
 	Indices(ctx context.Context, idx param.Indexer) []string
 	Update(ctx context.Context, id int64, tags []string) error
 	Sidebar(ctx context.Context) []*param.NameCount
+
+	// Rename 全局重命名标签，新标签不能已经存在
+	Rename(ctx context.Context, from, to string) (*param.TagAdminResult, error)
+
+	// Merge 将标签 from 合并到已经存在的标签 to
+	Merge(ctx context.Context, from, to string) (*param.TagAdminResult, error)
+
+	// Delete 全局删除标签
+	Delete(ctx context.Context, tag string) (*param.TagAdminResult, error)
 }
 
-func Tag(pusher push.Pusher) TagService {
+func Tag(db *gorm.DB, pusher push.Pusher, seq SequenceService) TagService {
 	return &tagService{
-		pusher: pusher,
+		db:      db,
+		pusher:  pusher,
+		seq:     seq,
+		timeout: 10 * time.Minute,
 	}
 }
 
 type tagService struct {
-	pusher push.Pusher
+	db      *gorm.DB
+	pusher  push.Pusher
+	seq     SequenceService
+	timeout time.Duration
+	mutex   sync.Mutex
 }
 
 func (biz *tagService) Indices(ctx context.Context, idx param.Indexer) []string {
@@ -66,6 +87,16 @@ func (biz *tagService) Update(ctx context.Context, id int64, tags []string) erro
 		return err
 	}
 	news := model.MinionTags(olds).Manual(id, tags)
+	// 自动标签规则生成的标签只能由规则维护
+	hm := make(map[string]struct{}, len(news))
+	for _, nt := range news {
+		hm[nt.Tag] = struct{}{}
+	}
+	for _, old := range olds {
+		if _, ok := hm[old.Tag]; !ok && old.Kind == entity.TkRule {
+			news = append(news, old)
+		}
+	}
 	err = query.Q.Transaction(func(tx *query.Query) error {
 		table := tx.WithContext(ctx).MinionTag
 		if _, exx := table.Where(tbl.MinionID.Eq(id)).
@@ -101,3 +132,280 @@ func (biz *tagService) Sidebar(ctx context.Context) []*param.NameCount {
 
 	return ret
 }
+
+func (biz *tagService) Rename(ctx context.Context, from, to string) (*param.TagAdminResult, error) {
+	return biz.rewrite(ctx, from, to, false)
+}
+
+func (biz *tagService) Merge(ctx context.Context, from, to string) (*param.TagAdminResult, error) {
+	return biz.rewrite(ctx, from, to, true)
+}
+
+func (biz *tagService) Delete(ctx context.Context, tag string) (*param.TagAdminResult, error) {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+
+	mids, effs, err := biz.impacted(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(mids) == 0 && len(effs) == 0 {
+		return nil, errcode.FmtErrTagNotExist.Fmt(tag)
+	}
+	var rule entity.TagRule
+	if err = biz.db.WithContext(ctx).
+		Model(&entity.TagRule{}).
+		Where("tag = ? AND enabled = ?", tag, true).
+		Limit(1).
+		Find(&rule).Error; err != nil {
+		return nil, err
+	}
+	if rule.ID != 0 {
+		return nil, errcode.FmtErrTagRuled.Fmt(tag, rule.Name)
+	}
+
+	// 删除后发布配置不能没有标签
+	effTbl := query.Effect
+	subIDs := biz.submitIDs(effs)
+	if len(subIDs) != 0 {
+		var others []int64
+		if err = effTbl.WithContext(ctx).
+			Distinct(effTbl.SubmitID).
+			Where(effTbl.SubmitID.In(subIDs...), effTbl.Tag.Neq(tag)).
+			Scan(&others); err != nil {
+			return nil, err
+		}
+		hm := make(map[int64]struct{}, len(others))
+		for _, id := range others {
+			hm[id] = struct{}{}
+		}
+		for _, eff := range effs {
+			if _, ok := hm[eff.SubmitID]; !ok {
+				return nil, errcode.FmtErrTagOnlyOne.Fmt(tag, eff.Name)
+			}
+		}
+	}
+	if biz.runningTask(ctx) {
+		return nil, errcode.ErrTaskBusy
+	}
+
+	tagTbl := query.MinionTag
+	if err = query.Q.Transaction(func(tx *query.Query) error {
+		if _, exx := tx.MinionTag.WithContext(ctx).
+			Where(tagTbl.Tag.Eq(tag)).
+			Delete(); exx != nil {
+			return exx
+		}
+		if len(subIDs) == 0 {
+			return nil
+		}
+		if _, exx := tx.Effect.WithContext(ctx).
+			Where(effTbl.Tag.Eq(tag)).
+			Delete(); exx != nil {
+			return exx
+		}
+		_, exx := tx.Effect.WithContext(ctx).
+			Where(effTbl.SubmitID.In(subIDs...)).
+			UpdateSimple(effTbl.Version.Add(1))
+		return exx
+	}); err != nil {
+		return nil, err
+	}
+
+	return biz.publish(ctx, mids, effs), nil
+}
+
+// rewrite 将标签 from 全局改写为 to，节点与发布配置的标签在同一个事务中修改。
+func (biz *tagService) rewrite(ctx context.Context, from, to string, merge bool) (*param.TagAdminResult, error) {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+
+	mids, effs, err := biz.impacted(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(mids) == 0 && len(effs) == 0 {
+		return nil, errcode.FmtErrTagNotExist.Fmt(from)
+	}
+	toMids, toEffs, err := biz.impacted(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	exist := len(toMids) != 0 || len(toEffs) != 0
+	if !merge && exist {
+		return nil, errcode.FmtErrTagExist.Fmt(to)
+	}
+	if merge && !exist {
+		return nil, errcode.FmtErrTagNotExist.Fmt(to)
+	}
+	if biz.runningTask(ctx) {
+		return nil, errcode.ErrTaskBusy
+	}
+
+	// 已经同时拥有 from 与 to 标签的节点只需要删除 from 标签
+	both := make([]int64, 0, len(toMids))
+	hm := make(map[int64]struct{}, len(mids))
+	for _, mid := range mids {
+		hm[mid] = struct{}{}
+	}
+	for _, mid := range toMids {
+		if _, ok := hm[mid]; ok {
+			both = append(both, mid)
+		}
+	}
+	// 同一次提交中已经存在 to 标签的发布配置只需要删除 from 标签
+	dups := make([]int64, 0, len(effs))
+	toEffMap := make(map[[2]int64]struct{}, len(toEffs))
+	for _, eff := range toEffs {
+		toEffMap[[2]int64{eff.SubmitID, eff.EffectID}] = struct{}{}
+	}
+	for _, eff := range effs {
+		if _, ok := toEffMap[[2]int64{eff.SubmitID, eff.EffectID}]; ok {
+			dups = append(dups, eff.ID)
+		}
+	}
+
+	tagTbl, effTbl := query.MinionTag, query.Effect
+	subIDs := biz.submitIDs(effs)
+	if err = biz.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		tx := query.Use(db)
+		if len(both) != 0 {
+			if _, exx := tx.MinionTag.WithContext(ctx).
+				Where(tagTbl.Tag.Eq(from), tagTbl.MinionID.In(both...)).
+				Delete(); exx != nil {
+				return exx
+			}
+		}
+		if _, exx := tx.MinionTag.WithContext(ctx).
+			Where(tagTbl.Tag.Eq(from)).
+			UpdateSimple(tagTbl.Tag.Value(to)); exx != nil {
+			return exx
+		}
+
+		// 自动标签规则同步修改，否则下次执行规则又会生成旧标签
+		if exx := db.Model(&entity.TagRule{}).
+			Where("tag = ?", from).
+			UpdateColumn("tag", to).Error; exx != nil {
+			return exx
+		}
+
+		if len(subIDs) == 0 {
+			return nil
+		}
+		if len(dups) != 0 {
+			if _, exx := tx.Effect.WithContext(ctx).
+				Where(effTbl.ID.In(dups...)).
+				Delete(); exx != nil {
+				return exx
+			}
+		}
+		if _, exx := tx.Effect.WithContext(ctx).
+			Where(effTbl.Tag.Eq(from)).
+			UpdateSimple(effTbl.Tag.Value(to)); exx != nil {
+			return exx
+		}
+		_, exx := tx.Effect.WithContext(ctx).
+			Where(effTbl.SubmitID.In(subIDs...)).
+			UpdateSimple(effTbl.Version.Add(1))
+		return exx
+	}); err != nil {
+		return nil, err
+	}
+
+	// 合并后原来拥有 to 标签的节点也会加载 from 标签关联的配置，
+	// 原来拥有 from 标签的节点也会加载 to 标签关联的配置。
+	if len(effs) != 0 {
+		mids = append(mids, toMids...)
+	}
+	mids = biz.uniqueIDs(mids)
+
+	return biz.publish(ctx, mids, append(effs, toEffs...)), nil
+}
+
+// impacted 查询拥有该标签的节点 ID 与关联的发布配置
+func (biz *tagService) impacted(ctx context.Context, tag string) ([]int64, []*model.Effect, error) {
+	tagTbl := query.MinionTag
+	olds, err := tagTbl.WithContext(ctx).
+		Select(tagTbl.MinionID, tagTbl.Kind).
+		Where(tagTbl.Tag.Eq(tag)).
+		Find()
+	if err != nil {
+		return nil, nil, err
+	}
+	mids := make([]int64, 0, len(olds))
+	for _, old := range olds {
+		if old.Kind.Lifelong() {
+			return nil, nil, errcode.ErrTagLifelong
+		}
+		mids = append(mids, old.MinionID)
+	}
+
+	effTbl := query.Effect
+	effs, err := effTbl.WithContext(ctx).
+		Where(effTbl.Tag.Eq(tag)).
+		Find()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return biz.uniqueIDs(mids), effs, nil
+}
+
+// publish 标签修改后为受影响的节点创建一次合并的配置下发任务，
+// 只有已启用的发布配置才需要重新下发。
+func (biz *tagService) publish(ctx context.Context, mids []int64, effs []*model.Effect) *param.TagAdminResult {
+	ret := &param.TagAdminResult{Minions: len(mids), Effects: len(biz.submitIDs(effs))}
+	var enabled bool
+	for _, eff := range effs {
+		if eff.Enable {
+			enabled = true
+			break
+		}
+	}
+	if !enabled || len(mids) == 0 {
+		return ret
+	}
+
+	taskID := biz.seq.Generate()
+	ret.TaskID = taskID
+	go func() {
+		brkIDs, err := transact.MinionTaskTx(ctx, taskID, mids)
+		if err == nil {
+			biz.pusher.TaskTable(context.Background(), brkIDs, taskID)
+		}
+	}()
+
+	return ret
+}
+
+// runningTask 是否有正在执行的配置下发任务
+func (biz *tagService) runningTask(ctx context.Context) bool {
+	before := time.Now().Add(-biz.timeout)
+	tbl := query.SubstanceTask
+	count, _ := tbl.WithContext(ctx).
+		Where(tbl.Executed.Is(false)).
+		Where(tbl.CreatedAt.Gte(before)).
+		Count()
+
+	return count != 0
+}
+
+func (*tagService) uniqueIDs(ids []int64) []int64 {
+	hm := make(map[int64]struct{}, len(ids))
+	ret := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := hm[id]; !ok {
+			hm[id] = struct{}{}
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
+
+func (biz *tagService) submitIDs(effs []*model.Effect) []int64 {
+	ids := make([]int64, 0, len(effs))
+	for _, eff := range effs {
+		ids = append(ids, eff.SubmitID)
+	}
+	return biz.uniqueIDs(ids)
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRuleService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.TagRule)
+	Fields() []string
+	Create(ctx context.Context, req *param.TagRuleCreate, userID int64) error
+	Update(ctx context.Context, req *param.TagRuleUpdate) error
+	Delete(ctx context.Context, id int64) error
+
+	// Apply 对全部节点执行自动标签规则
+	Apply(ctx context.Context) (*param.TagRuleApply, error)
+
+	// Evaluate 对指定节点执行自动标签规则，用于新增节点与刷新 CMDB 信息后
+	Evaluate(ctx context.Context, mids []int64) (*param.TagRuleApply, error)
+}
+
+func TagRule(db *gorm.DB, pusher push.Pusher) TagRuleService {
+	return &tagRuleService{
+		db:     db,
+		pusher: pusher,
+		limit:  500,
+	}
+}
+
+type tagRuleService struct {
+	db     *gorm.DB
+	pusher push.Pusher
+	limit  int
+	mutex  sync.Mutex // 全量执行规则时加锁，避免重复执行
+}
+
+// tagRuleFields 规则支持匹配的字段
+var tagRuleFields = map[string]func(*model.Minion, *model.Cmdb) string{
+	"inet":                    func(m *model.Minion, _ *model.Cmdb) string { return m.Inet },
+	"goos":                    func(m *model.Minion, _ *model.Cmdb) string { return m.Goos },
+	"arch":                    func(m *model.Minion, _ *model.Cmdb) string { return m.Arch },
+	"edition":                 func(m *model.Minion, _ *model.Cmdb) string { return m.Edition },
+	"idc":                     func(m *model.Minion, _ *model.Cmdb) string { return m.IDC },
+	"ibu":                     func(m *model.Minion, _ *model.Cmdb) string { return m.IBu },
+	"category":                func(m *model.Minion, _ *model.Cmdb) string { return m.Category },
+	"org_path":                func(m *model.Minion, _ *model.Cmdb) string { return m.OrgPath },
+	"op_duty":                 func(m *model.Minion, _ *model.Cmdb) string { return m.OpDuty },
+	"cmdb.appname":            func(_ *model.Minion, c *model.Cmdb) string { return c.AppName },
+	"cmdb.area":               func(_ *model.Minion, c *model.Cmdb) string { return c.Area },
+	"cmdb.business_scope":     func(_ *model.Minion, c *model.Cmdb) string { return c.BusinessScope },
+	"cmdb.category_branch":    func(_ *model.Minion, c *model.Cmdb) string { return c.CategoryBranch },
+	"cmdb.cost_bu":            func(_ *model.Minion, c *model.Cmdb) string { return c.CostBu },
+	"cmdb.env":                func(_ *model.Minion, c *model.Cmdb) string { return c.Env },
+	"cmdb.net_open":           func(_ *model.Minion, c *model.Cmdb) string { return c.NetOpen },
+	"cmdb.os_version":         func(_ *model.Minion, c *model.Cmdb) string { return c.OsVersion },
+	"cmdb.private_cloud_type": func(_ *model.Minion, c *model.Cmdb) string { return c.PrivateCloudType },
+	"cmdb.rd_duty":            func(_ *model.Minion, c *model.Cmdb) string { return c.RdDuty },
+	"cmdb.server_room":        func(_ *model.Minion, c *model.Cmdb) string { return c.ServerRoom },
+	"cmdb.status":             func(_ *model.Minion, c *model.Cmdb) string { return c.Status },
+	"cmdb.sys_duty":           func(_ *model.Minion, c *model.Cmdb) string { return c.SysDuty },
+}
+
+func (biz *tagRuleService) Page(ctx context.Context, page param.Pager) (int64, []*entity.TagRule) {
+	db := biz.db.WithContext(ctx).Model(&entity.TagRule{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR tag LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.TagRule
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *tagRuleService) Fields() []string {
+	ret := make([]string, 0, len(tagRuleFields))
+	for k := range tagRuleFields {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func (biz *tagRuleService) Create(ctx context.Context, req *param.TagRuleCreate, userID int64) error {
+	conds, err := biz.conditions(req.Conditions)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dat := &entity.TagRule{
+		Name:       req.Name,
+		Tag:        req.Tag,
+		Conditions: conds,
+		Enabled:    req.Enabled,
+		Comment:    req.Comment,
+		CreatedID:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err = biz.db.WithContext(ctx).Create(dat).Error; err != nil {
+		return err
+	}
+	if dat.Enabled {
+		go biz.reapply()
+	}
+
+	return nil
+}
+
+func (biz *tagRuleService) Update(ctx context.Context, req *param.TagRuleUpdate) error {
+	conds, err := biz.conditions(req.Conditions)
+	if err != nil {
+		return err
+	}
+
+	old := new(entity.TagRule)
+	if err = biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(old).Error; err != nil {
+		return err
+	}
+
+	old.Name, old.Tag, old.Conditions = req.Name, req.Tag, conds
+	old.Comment, old.UpdatedAt = req.Comment, time.Now()
+	enabled := old.Enabled || req.Enabled
+	old.Enabled = req.Enabled
+	if err = biz.db.WithContext(ctx).Save(old).Error; err != nil {
+		return err
+	}
+	// 启用前后只要有一次处于启用状态，就可能影响节点标签
+	if enabled {
+		go biz.reapply()
+	}
+
+	return nil
+}
+
+func (biz *tagRuleService) Delete(ctx context.Context, id int64) error {
+	old := new(entity.TagRule)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(old).Error; err != nil {
+		return err
+	}
+	if err := biz.db.WithContext(ctx).Delete(old).Error; err != nil {
+		return err
+	}
+	if old.Enabled {
+		go biz.reapply()
+	}
+
+	return nil
+}
+
+func (biz *tagRuleService) Apply(ctx context.Context) (*param.TagRuleApply, error) {
+	if !biz.mutex.TryLock() {
+		return nil, errcode.ErrTaskBusy
+	}
+	defer biz.mutex.Unlock()
+
+	return biz.applyAll(ctx)
+}
+
+func (biz *tagRuleService) Evaluate(ctx context.Context, mids []int64) (*param.TagRuleApply, error) {
+	rules, err := biz.enabledRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return biz.evaluate(ctx, rules, mids)
+}
+
+// reapply 规则发生变化后在后台重新执行全部规则
+func (biz *tagRuleService) reapply() {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+	_, _ = biz.applyAll(context.Background())
+}
+
+func (biz *tagRuleService) applyAll(ctx context.Context) (*param.TagRuleApply, error) {
+	rules, err := biz.enabledRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := new(param.TagRuleApply)
+	tbl := query.Minion
+	deleted := uint8(model.MSDelete)
+	var lastID int64
+	for {
+		var mids []int64
+		if err = tbl.WithContext(ctx).
+			Where(tbl.ID.Gt(lastID), tbl.Status.Neq(deleted)).
+			Order(tbl.ID).
+			Limit(biz.limit).
+			Pluck(tbl.ID, &mids); err != nil || len(mids) == 0 {
+			break
+		}
+		lastID = mids[len(mids)-1]
+
+		res, exx := biz.evaluate(ctx, rules, mids)
+		if exx != nil {
+			return ret, exx
+		}
+		ret.Scanned += res.Scanned
+		ret.Affected += res.Affected
+	}
+
+	return ret, err
+}
+
+func (biz *tagRuleService) enabledRules(ctx context.Context) ([]*tagMatcher, error) {
+	var rules []*entity.TagRule
+	if err := biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+
+	ret := make([]*tagMatcher, 0, len(rules))
+	for _, rule := range rules {
+		tm, err := newTagMatcher(rule)
+		if err != nil {
+			continue // 规则在保存时已经校验，此处出错说明数据被篡改，直接忽略
+		}
+		ret = append(ret, tm)
+	}
+
+	return ret, nil
+}
+
+// evaluate 计算节点应有的规则标签，与现有的规则标签比较后增删差异部分，
+// 并只对标签发生变化的在线节点下发配置同步。
+func (biz *tagRuleService) evaluate(ctx context.Context, rules []*tagMatcher, mids []int64) (*param.TagRuleApply, error) {
+	ret := new(param.TagRuleApply)
+	if len(mids) == 0 {
+		return ret, nil
+	}
+
+	monTbl := query.Minion
+	deleted := uint8(model.MSDelete)
+	mons, err := monTbl.WithContext(ctx).
+		Where(monTbl.ID.In(mids...), monTbl.Status.Neq(deleted)).
+		Find()
+	if err != nil || len(mons) == 0 {
+		return ret, err
+	}
+	ret.Scanned = len(mons)
+
+	cmdbTbl := query.Cmdb
+	cmdbs, _ := cmdbTbl.WithContext(ctx).Where(cmdbTbl.ID.In(mids...)).Find()
+	cmdbMap := make(map[int64]*model.Cmdb, len(cmdbs))
+	for _, c := range cmdbs {
+		cmdbMap[c.ID] = c
+	}
+
+	tagTbl := query.MinionTag
+	olds, err := tagTbl.WithContext(ctx).Where(tagTbl.MinionID.In(mids...)).Find()
+	if err != nil {
+		return ret, err
+	}
+	ruleTags := make(map[int64]map[string]int64, len(mons)) // 现有的规则标签
+	otherTags := make(map[int64]map[string]struct{}, len(mons))
+	for _, old := range olds {
+		mid := old.MinionID
+		if old.Kind == entity.TkRule {
+			if ruleTags[mid] == nil {
+				ruleTags[mid] = make(map[string]int64, 4)
+			}
+			ruleTags[mid][old.Tag] = old.ID
+		} else {
+			if otherTags[mid] == nil {
+				otherTags[mid] = make(map[string]struct{}, 8)
+			}
+			otherTags[mid][old.Tag] = struct{}{}
+		}
+	}
+
+	var deletes []int64
+	var creates []*model.MinionTag
+	affected := make([]*model.Minion, 0, 16)
+	for _, mon := range mons {
+		cdb := cmdbMap[mon.ID]
+		if cdb == nil {
+			cdb = new(model.Cmdb)
+		}
+		wants := make(map[string]struct{}, 4)
+		for _, rule := range rules {
+			if rule.match(mon, cdb) {
+				wants[rule.tag] = struct{}{}
+			}
+		}
+
+		var changed bool
+		current := ruleTags[mon.ID]
+		for tag, id := range current {
+			if _, ok := wants[tag]; !ok {
+				changed = true
+				deletes = append(deletes, id)
+			}
+		}
+		for tag := range wants {
+			if _, ok := current[tag]; ok {
+				continue
+			}
+			// 已经存在的手动标签或系统标签不做变更
+			if _, ok := otherTags[mon.ID][tag]; ok {
+				continue
+			}
+			changed = true
+			creates = append(creates, &model.MinionTag{Tag: tag, MinionID: mon.ID, Kind: entity.TkRule})
+		}
+		if changed {
+			affected = append(affected, mon)
+		}
+	}
+	if len(affected) == 0 {
+		return ret, nil
+	}
+
+	if err = query.Q.Transaction(func(tx *query.Query) error {
+		table := tx.WithContext(ctx).MinionTag
+		if len(deletes) != 0 {
+			if _, exx := table.Where(tagTbl.ID.In(deletes...)).Delete(); exx != nil {
+				return exx
+			}
+		}
+		if len(creates) == 0 {
+			return nil
+		}
+		return table.Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(creates, 200)
+	}); err != nil {
+		return ret, err
+	}
+	ret.Affected = len(affected)
+
+	// 标签发生变化意味着关联的配置发生变化
+	for _, mon := range affected {
+		if mon.Status == model.MSOnline {
+			biz.pusher.TaskSync(ctx, mon.BrokerID, mon.ID, mon.Inet)
+		}
+	}
+
+	return ret, nil
+}
+
+func (biz *tagRuleService) conditions(reqs []*param.TagRuleCondition) (entity.TagConditions, error) {
+	ret := make(entity.TagConditions, 0, len(reqs))
+	for _, req := range reqs {
+		if _, ok := tagRuleFields[req.Field]; !ok {
+			return nil, errcode.FmtErrTagRuleField.Fmt(req.Field)
+		}
+		if req.Operator == "cidr" {
+			for _, v := range req.Values {
+				if parseCIDR(v) == nil {
+					return nil, errcode.FmtErrTagRuleCIDR.Fmt(v)
+				}
+			}
+		}
+		ret = append(ret, &entity.TagCondition{Field: req.Field, Operator: req.Operator, Values: req.Values})
+	}
+
+	return ret, nil
+}
+
+type tagMatcher struct {
+	tag   string
+	conds []*tagCondMatcher
+}
+
+type tagCondMatcher struct {
+	field  func(*model.Minion, *model.Cmdb) string
+	op     string
+	values []string
+	nets   []*net.IPNet
+}
+
+func newTagMatcher(rule *entity.TagRule) (*tagMatcher, error) {
+	tm := &tagMatcher{tag: rule.Tag, conds: make([]*tagCondMatcher, 0, len(rule.Conditions))}
+	for _, cond := range rule.Conditions {
+		fn, ok := tagRuleFields[cond.Field]
+		if !ok {
+			return nil, errcode.FmtErrTagRuleField.Fmt(cond.Field)
+		}
+		cm := &tagCondMatcher{field: fn, op: cond.Operator, values: cond.Values}
+		if cond.Operator == "cidr" {
+			for _, v := range cond.Values {
+				ipnet := parseCIDR(v)
+				if ipnet == nil {
+					return nil, errcode.FmtErrTagRuleCIDR.Fmt(v)
+				}
+				cm.nets = append(cm.nets, ipnet)
+			}
+		}
+		tm.conds = append(tm.conds, cm)
+	}
+
+	return tm, nil
+}
+
+// match 全部条件都满足才算匹配
+func (tm *tagMatcher) match(mon *model.Minion, cdb *model.Cmdb) bool {
+	for _, cond := range tm.conds {
+		if !cond.match(cond.field(mon, cdb)) {
+			return false
+		}
+	}
+	return len(tm.conds) != 0
+}
+
+// match 多个值之间是或的关系，ne 操作符要求与所有值都不相等
+func (cm *tagCondMatcher) match(str string) bool {
+	switch cm.op {
+	case "cidr":
+		ip := net.ParseIP(str)
+		if ip == nil {
+			return false
+		}
+		for _, ipnet := range cm.nets {
+			if ipnet.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	case "ne":
+		for _, v := range cm.values {
+			if str == v {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range cm.values {
+		switch cm.op {
+		case "eq":
+			if str == v {
+				return true
+			}
+		case "contains":
+			if strings.Contains(str, v) {
+				return true
+			}
+		case "prefix":
+			if strings.HasPrefix(str, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseCIDR 解析 CIDR，单个 IP 视作掩码全满的网段
+func parseCIDR(s string) *net.IPNet {
+	if _, ipnet, err := net.ParseCIDR(s); err == nil {
+		return ipnet
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
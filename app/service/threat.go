@@ -0,0 +1,575 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/intel"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThreatService 离线威胁情报：导入情报文件，并与风险事件、IP 白名单、域名白名单进行匹配。
+type ThreatService interface {
+	Indicators(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.ThreatIndicator)
+	Imports(ctx context.Context, page param.Pager) (int64, []*entity.ThreatImport)
+	Import(ctx context.Context, req *param.ThreatImport, r io.Reader, userID int64) (*entity.ThreatImport, error)
+	Matches(ctx context.Context, req *param.ThreatMatchPage, page param.Pager) (int64, []*entity.ThreatMatch)
+	Ack(ctx context.Context, req *param.ThreatMatchAck) error
+	Feed(ctx context.Context) *param.ThreatFeed
+	SetFeed(ctx context.Context, req *param.ThreatFeed) error
+
+	// Run 定时导入情报目录中的新文件、清理过期指标并执行匹配
+	Run(ctx context.Context)
+}
+
+func Threat(db *gorm.DB, slog logback.Logger) ThreatService {
+	return &threatService{
+		db:       db,
+		slog:     slog,
+		interval: 10 * time.Minute,
+		maxSize:  64 << 20,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+type threatService struct {
+	db       *gorm.DB
+	slog     logback.Logger
+	interval time.Duration
+	maxSize  int64         // 单个情报文件的大小上限
+	trigger  chan struct{} // 导入完成后触发一次匹配
+}
+
+// threatValueMax 指标值的最大长度，与 threat_indicator.value 的字段长度保持一致，
+// utf8mb4 下 (type, value) 唯一索引不能超过 InnoDB 3072 字节的限制。
+const threatValueMax = 512
+
+// threatFeedID 情报目录设置在 store 中的 ID
+const threatFeedID = "global.threat.feed"
+
+// threatOption 一次导入的默认参数，情报文件中没有指定的字段使用默认值
+type threatOption struct {
+	source     string
+	format     string
+	kind       string
+	confidence int
+	ttl        int
+	filename   string
+}
+
+func (biz *threatService) Indicators(ctx context.Context, page param.Pager, scope dynsql.Scope) (int64, []*entity.ThreatIndicator) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.ThreatIndicator{}).
+		Scopes(scope.Where)
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("value LIKE ? OR description LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ThreatIndicator
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *threatService) Imports(ctx context.Context, page param.Pager) (int64, []*entity.ThreatImport) {
+	db := biz.db.WithContext(ctx).Model(&entity.ThreatImport{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("source LIKE ? OR filename LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ThreatImport
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *threatService) Import(ctx context.Context, req *param.ThreatImport, r io.Reader, userID int64) (*entity.ThreatImport, error) {
+	name := req.File.Filename
+	format := req.Format
+	if format == "" {
+		format = intel.Detect(name)
+	}
+	if format == "" {
+		return nil, errcode.ErrThreatFormat
+	}
+	data, err := biz.readAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &threatOption{
+		source:     req.Source,
+		format:     format,
+		kind:       req.Kind,
+		confidence: req.Confidence,
+		ttl:        req.TTL,
+		filename:   name,
+	}
+
+	return biz.ingest(ctx, opt, data, userID)
+}
+
+func (biz *threatService) Matches(ctx context.Context, req *param.ThreatMatchPage, page param.Pager) (int64, []*entity.ThreatMatch) {
+	db := biz.db.WithContext(ctx).Model(&entity.ThreatMatch{})
+	if req.Target != "" {
+		db = db.Where("target = ?", req.Target)
+	}
+	if req.Unread {
+		db = db.Where("acked = ?", false)
+	}
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("value LIKE ? OR matched LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.ThreatMatch
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *threatService) Ack(ctx context.Context, req *param.ThreatMatchAck) error {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.ThreatMatch{}).
+		Where("acked = ?", false)
+	if len(req.IDs) != 0 {
+		db = db.Where("id IN ?", []int64(req.IDs))
+	}
+
+	return db.UpdateColumn("acked", true).Error
+}
+
+func (biz *threatService) Feed(ctx context.Context) *param.ThreatFeed {
+	ret := &param.ThreatFeed{Source: "本地目录", Confidence: 50, TTL: 720}
+	storeLoadJSON(ctx, threatFeedID, ret)
+
+	return ret
+}
+
+func (biz *threatService) SetFeed(ctx context.Context, req *param.ThreatFeed) error {
+	if req.Enabled {
+		if stat, err := os.Stat(req.Dir); err != nil || !stat.IsDir() {
+			return errcode.FmtErrThreatDir.Fmt(req.Dir)
+		}
+	}
+	return storeSaveJSON(ctx, threatFeedID, "威胁情报目录设置", req)
+}
+
+func (biz *threatService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.scanDir(ctx)
+			biz.expire(ctx)
+		case <-biz.trigger:
+		}
+		if err := biz.match(ctx); err != nil {
+			biz.slog.Warnf("威胁情报匹配出错：%s", err)
+		}
+	}
+}
+
+func (biz *threatService) readAll(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, biz.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > biz.maxSize {
+		return nil, errcode.ErrThreatTooLarge
+	}
+
+	return data, nil
+}
+
+// ingest 解析并导入情报文件，同一指标重复导入时以最新导入的数据为准。
+func (biz *threatService) ingest(ctx context.Context, opt *threatOption, data []byte, userID int64) (*entity.ThreatImport, error) {
+	now := time.Now()
+	rec := &entity.ThreatImport{
+		Source:    opt.source,
+		Filename:  opt.filename,
+		Format:    opt.format,
+		CreatedID: userID,
+		CreatedAt: now,
+	}
+
+	res, err := intel.Parse(opt.format, bytes.NewReader(data))
+	if err == nil {
+		rec.Invalid = res.Invalid
+		rec.Total, err = biz.upsert(ctx, opt, res.Indicators, now)
+	}
+	// 导入失败也记录文件摘要，内容不变的文件不会反复导入，修改文件或者手动导入后重试
+	sum := sha1.Sum(data)
+	rec.Digest = hex.EncodeToString(sum[:])
+	if err != nil {
+		rec.Error = truncate(err.Error(), 1024)
+	}
+	if exx := biz.db.WithContext(ctx).Create(rec).Error; exx != nil && err == nil {
+		err = exx
+	}
+	if err != nil {
+		return rec, err
+	}
+
+	select {
+	case biz.trigger <- struct{}{}:
+	default:
+	}
+
+	return rec, nil
+}
+
+func (biz *threatService) upsert(ctx context.Context, opt *threatOption, inds []*intel.Indicator, now time.Time) (int, error) {
+	var defaultExpired sql.NullTime
+	if opt.ttl > 0 {
+		defaultExpired = sql.NullTime{Time: now.Add(time.Duration(opt.ttl) * time.Hour), Valid: true}
+	}
+
+	uniq := make(map[string]struct{}, len(inds))
+	dats := make([]*entity.ThreatIndicator, 0, len(inds))
+	var riskIPs []*model.RiskIP
+	var riskDNSs []*model.RiskDNS
+	for _, ind := range inds {
+		key := ind.Type + " " + ind.Value
+		if _, ok := uniq[key]; ok || utf8.RuneCountInString(ind.Value) > threatValueMax {
+			continue
+		}
+		uniq[key] = struct{}{}
+
+		dat := &entity.ThreatIndicator{
+			Type:        ind.Type,
+			Value:       ind.Value,
+			Kind:        ind.Kind,
+			Source:      opt.source,
+			Confidence:  ind.Confidence,
+			Description: ind.Description,
+			ExpiredAt:   defaultExpired,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		if dat.Kind == "" {
+			dat.Kind = opt.kind
+		}
+		if dat.Confidence <= 0 || dat.Confidence > 100 {
+			dat.Confidence = opt.confidence
+		}
+		if !ind.ValidUntil.IsZero() {
+			dat.ExpiredAt = sql.NullTime{Time: ind.ValidUntil, Valid: true}
+		}
+		dats = append(dats, dat)
+
+		// 同步到风险 IP 与风险域名，供原有的风险库查询使用
+		beforeAt := now.AddDate(10, 0, 0)
+		if dat.ExpiredAt.Valid {
+			beforeAt = dat.ExpiredAt.Time
+		}
+		kind := dat.Kind
+		if kind == "" {
+			kind = "威胁情报"
+		}
+		switch ind.Type {
+		case intel.TypeIP:
+			riskIPs = append(riskIPs, &model.RiskIP{IP: ind.Value, Kind: kind, Origin: opt.source, BeforeAt: beforeAt})
+		case intel.TypeDomain:
+			riskDNSs = append(riskDNSs, &model.RiskDNS{Domain: ind.Value, Kind: kind, Origin: opt.source, BeforeAt: beforeAt})
+		}
+	}
+	if len(dats) == 0 {
+		return 0, nil
+	}
+
+	err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		conflict := clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{
+				"kind", "source", "confidence", "description", "expired_at", "updated_at",
+			}),
+		}
+		if err := tx.Clauses(conflict).CreateInBatches(dats, 500).Error; err != nil {
+			return err
+		}
+
+		update := clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"origin", "before_at", "updated_at"})}
+		if len(riskIPs) != 0 {
+			if err := tx.Clauses(update).CreateInBatches(riskIPs, 500).Error; err != nil {
+				return err
+			}
+		}
+		if len(riskDNSs) != 0 {
+			if err := tx.Clauses(update).CreateInBatches(riskDNSs, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return len(dats), err
+}
+
+// scanDir 导入情报目录中尚未导入过的文件，通过文件摘要判断是否已经导入，导入失败的文件内容不变时同样跳过。
+func (biz *threatService) scanDir(ctx context.Context) {
+	feed := biz.Feed(ctx)
+	if !feed.Enabled || feed.Dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(feed.Dir)
+	if err != nil {
+		biz.slog.Warnf("读取威胁情报目录 %s 出错：%s", feed.Dir, err)
+		return
+	}
+
+	for _, ent := range entries {
+		name := ent.Name()
+		format := intel.Detect(name)
+		if ent.IsDir() || format == "" {
+			continue
+		}
+		data, exx := biz.readFile(filepath.Join(feed.Dir, name))
+		if exx != nil {
+			biz.slog.Warnf("读取威胁情报文件 %s 出错：%s", name, exx)
+			continue
+		}
+
+		sum := sha1.Sum(data)
+		var count int64
+		if biz.db.WithContext(ctx).
+			Model(&entity.ThreatImport{}).
+			Where("digest = ?", hex.EncodeToString(sum[:])).
+			Count(&count); count != 0 {
+			continue
+		}
+
+		opt := &threatOption{
+			source:     feed.Source,
+			format:     format,
+			confidence: feed.Confidence,
+			ttl:        feed.TTL,
+			filename:   name,
+		}
+		if _, exx = biz.ingest(ctx, opt, data, 0); exx != nil {
+			biz.slog.Warnf("导入威胁情报文件 %s 出错：%s", name, exx)
+		}
+	}
+}
+
+func (biz *threatService) readFile(name string) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer file.Close()
+
+	return biz.readAll(file)
+}
+
+// expire 删除过期的指标，命中记录保留
+func (biz *threatService) expire(ctx context.Context) {
+	biz.db.WithContext(ctx).
+		Where("expired_at < ?", time.Now()).
+		Delete(&entity.ThreatIndicator{})
+}
+
+// threatHit 匹配查询的结果
+type threatHit struct {
+	IndicatorID int64  `gorm:"column:indicator_id"`
+	Type        string `gorm:"column:type"`
+	Value       string `gorm:"column:value"`
+	TargetID    int64  `gorm:"column:target_id"`
+	Matched     string `gorm:"column:matched"`
+}
+
+// match 将有效的指标与最近 7 天的风险事件、IP 白名单、域名白名单进行匹配，新的命中记录为未查看状态。
+func (biz *threatService) match(ctx context.Context) error {
+	now := time.Now()
+	targets := []struct {
+		target string
+		table  string
+		column string
+		typ    string
+		where  string
+		args   []any
+	}{
+		{target: "risk", table: "risk", column: "remote_ip", typ: intel.TypeIP, where: "t.occur_at >= ?", args: []any{now.AddDate(0, 0, -7)}},
+		{target: "pass_ip", table: "pass_ip", column: "ip", typ: intel.TypeIP},
+		{target: "pass_dns", table: "pass_dns", column: "domain", typ: intel.TypeDomain},
+	}
+
+	for _, t := range targets {
+		for {
+			db := biz.db.WithContext(ctx).
+				Table(t.table+" AS t").
+				Select("ti.id AS indicator_id", "ti.type", "ti.value", "t.id AS target_id", "t."+t.column+" AS matched").
+				Joins("JOIN threat_indicator ti ON ti.type = ? AND ti.value = t."+t.column, t.typ).
+				Where("ti.expired_at IS NULL OR ti.expired_at > ?", now).
+				Where("NOT EXISTS (SELECT 1 FROM threat_match tm WHERE tm.indicator_id = ti.id "+
+					"AND tm.target = ? AND tm.target_id = t.id)", t.target)
+			if t.where != "" {
+				db = db.Where(t.where, t.args...)
+			}
+			var hits []*threatHit
+			if err := db.Limit(1000).Find(&hits).Error; err != nil {
+				return err
+			}
+			if len(hits) == 0 {
+				break
+			}
+			if err := biz.saveHits(ctx, t.target, hits, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	return biz.matchCIDR(ctx, now)
+}
+
+// matchCIDR 网段指标无法直接通过 SQL 等值匹配，在内存中逐一比对 IP 白名单与最近 7 天风险事件的远程 IP。
+func (biz *threatService) matchCIDR(ctx context.Context, now time.Time) error {
+	var inds []*entity.ThreatIndicator
+	if err := biz.db.WithContext(ctx).
+		Where("type = ?", intel.TypeCIDR).
+		Where("expired_at IS NULL OR expired_at > ?", now).
+		Find(&inds).Error; err != nil || len(inds) == 0 {
+		return err
+	}
+	nets := make([]*net.IPNet, 0, len(inds))
+	for _, ind := range inds {
+		_, ipnet, _ := net.ParseCIDR(ind.Value)
+		nets = append(nets, ipnet)
+	}
+	contains := func(id int64, addr string) []*threatHit {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil
+		}
+		var hits []*threatHit
+		for i, ipnet := range nets {
+			if ipnet != nil && ipnet.Contains(ip) {
+				ind := inds[i]
+				hits = append(hits, &threatHit{
+					IndicatorID: ind.ID,
+					Type:        ind.Type,
+					Value:       ind.Value,
+					TargetID:    id,
+					Matched:     addr,
+				})
+			}
+		}
+		return hits
+	}
+
+	var passes []*model.PassIP
+	if err := biz.db.WithContext(ctx).
+		Select("id", "ip").
+		Find(&passes).Error; err != nil {
+		return err
+	}
+	var hits []*threatHit
+	for _, pass := range passes {
+		hits = append(hits, contains(pass.ID, pass.IP)...)
+	}
+	if len(hits) != 0 {
+		if err := biz.saveHits(ctx, "pass_ip", hits, now); err != nil {
+			return err
+		}
+	}
+
+	return biz.matchRiskCIDR(ctx, now, contains)
+}
+
+// matchRiskCIDR 风险事件数据量较大，先对去重后的远程 IP 比对网段，再查询命中 IP 对应的风险事件。
+func (biz *threatService) matchRiskCIDR(ctx context.Context, now time.Time, contains func(int64, string) []*threatHit) error {
+	since := now.AddDate(0, 0, -7)
+	var addrs []string
+	if err := biz.db.WithContext(ctx).
+		Model(&model.Risk{}).
+		Distinct("remote_ip").
+		Where("occur_at >= ?", since).
+		Pluck("remote_ip", &addrs).Error; err != nil {
+		return err
+	}
+	matched := make([]string, 0, 16)
+	for _, addr := range addrs {
+		if len(contains(0, addr)) != 0 {
+			matched = append(matched, addr)
+		}
+	}
+
+	const limit = 1000
+	for len(matched) != 0 {
+		batch := matched
+		if len(batch) > limit {
+			batch = batch[:limit]
+		}
+		matched = matched[len(batch):]
+
+		var risks []*model.Risk
+		if err := biz.db.WithContext(ctx).
+			Select("id", "remote_ip").
+			Where("occur_at >= ? AND remote_ip IN ?", since, batch).
+			Find(&risks).Error; err != nil {
+			return err
+		}
+		var hits []*threatHit
+		for _, risk := range risks {
+			hits = append(hits, contains(risk.ID, risk.RemoteIP)...)
+		}
+		if len(hits) == 0 {
+			continue
+		}
+		if err := biz.saveHits(ctx, "risk", hits, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (biz *threatService) saveHits(ctx context.Context, target string, hits []*threatHit, now time.Time) error {
+	dats := make([]*entity.ThreatMatch, 0, len(hits))
+	for _, h := range hits {
+		dats = append(dats, &entity.ThreatMatch{
+			IndicatorID: h.IndicatorID,
+			Type:        h.Type,
+			Value:       h.Value,
+			Target:      target,
+			TargetID:    h.TargetID,
+			Matched:     h.Matched,
+			CreatedAt:   now,
+		})
+	}
+
+	return biz.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(dats, 500).Error
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/dal/query"
+	"github.com/vela-ssoc/vela-common-mb/dynsql"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/bridge/push"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+type UpgradeCampaignService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.UpgradeCampaign)
+	Create(ctx context.Context, req *param.UpgradeCampaignCreate, scope dynsql.Scope, likes []gen.Condition, userID int64) (int64, error)
+	Report(ctx context.Context, id int64) (*param.UpgradeCampaignReport, error)
+	Items(ctx context.Context, id int64, page param.Pager) (int64, []*entity.UpgradeCampaignItem)
+	Cancel(ctx context.Context, id int64) error
+
+	// Reset 将 manager 重启前未执行完毕的升级活动标记为中断
+	Reset(ctx context.Context) error
+}
+
+func UpgradeCampaign(db *gorm.DB, pusher push.Pusher) UpgradeCampaignService {
+	return &upgradeCampaignService{
+		db:       db,
+		pusher:   pusher,
+		interval: 5 * time.Second,
+		cancels:  make(map[int64]context.CancelFunc, 4),
+	}
+}
+
+type upgradeCampaignService struct {
+	db       *gorm.DB
+	pusher   push.Pusher
+	interval time.Duration // 检查节点版本变化的间隔
+	mutex    sync.Mutex
+	cancels  map[int64]context.CancelFunc
+}
+
+func (biz *upgradeCampaignService) Page(ctx context.Context, page param.Pager) (int64, []*entity.UpgradeCampaign) {
+	db := biz.db.WithContext(ctx).Model(&entity.UpgradeCampaign{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR semver LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.UpgradeCampaign
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *upgradeCampaignService) Create(ctx context.Context, req *param.UpgradeCampaignCreate, scope dynsql.Scope,
+	likes []gen.Condition, userID int64,
+) (int64, error) {
+	biz.mutex.Lock()
+	defer biz.mutex.Unlock()
+
+	if len(biz.cancels) != 0 {
+		return 0, errcode.ErrTaskBusy
+	}
+
+	binTbl := query.MinionBin
+	bin, err := binTbl.WithContext(ctx).Where(binTbl.ID.Eq(req.BinaryID)).First()
+	if err != nil {
+		return 0, err
+	}
+	if bin.Deprecated {
+		return 0, errcode.ErrDeprecated
+	}
+
+	items, err := biz.selectMinions(ctx, bin, scope, likes)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, errcode.ErrNoMatchedNode
+	}
+
+	filters, _ := json.Marshal(req.Input)
+	now := time.Now()
+	camp := &entity.UpgradeCampaign{
+		Name:        req.Name,
+		BinaryID:    bin.ID,
+		Goos:        bin.Goos,
+		Arch:        bin.Arch,
+		Semver:      bin.Semver,
+		Filters:     filters,
+		Keyword:     req.Keyword,
+		BatchSize:   req.BatchSize,
+		BrokerLimit: req.BrokerLimit,
+		Timeout:     time.Duration(req.Timeout) * time.Second,
+		Status:      entity.CSRunning,
+		Total:       len(items),
+		CreatedID:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	err = biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if exx := tx.Create(camp).Error; exx != nil {
+			return exx
+		}
+		for _, item := range items {
+			item.CampaignID = camp.ID
+		}
+		return tx.CreateInBatches(items, 200).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// 升级活动执行时间较长，不能使用 HTTP 请求的 context
+	runCtx, cancel := context.WithCancel(context.Background())
+	biz.cancels[camp.ID] = cancel
+	go biz.run(runCtx, camp)
+
+	return camp.ID, nil
+}
+
+func (biz *upgradeCampaignService) Report(ctx context.Context, id int64) (*param.UpgradeCampaignReport, error) {
+	camp := new(entity.UpgradeCampaign)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(camp).Error; err != nil {
+		return nil, err
+	}
+
+	stats := make([]*param.CampaignBrokerStat, 0, 16)
+	biz.db.WithContext(ctx).
+		Model(&entity.UpgradeCampaignItem{}).
+		Select("broker_id", "broker_name", "status", "COUNT(*) AS count").
+		Where("campaign_id = ?", id).
+		Group("broker_id, broker_name, status").
+		Order("broker_id").
+		Scan(&stats)
+
+	ret := &param.UpgradeCampaignReport{UpgradeCampaign: camp, Brokers: stats}
+	for _, st := range stats {
+		switch st.Status {
+		case entity.CISPending:
+			ret.Pending += st.Count
+		case entity.CISPushed:
+			ret.Pushed += st.Count
+		case entity.CISSkipped:
+			ret.Skipped += st.Count
+		}
+	}
+
+	return ret, nil
+}
+
+func (biz *upgradeCampaignService) Items(ctx context.Context, id int64, page param.Pager) (int64, []*entity.UpgradeCampaignItem) {
+	db := biz.db.WithContext(ctx).
+		Model(&entity.UpgradeCampaignItem{}).
+		Where("campaign_id = ?", id)
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("inet LIKE ? OR broker_name LIKE ? OR reason LIKE ?", kw, kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.UpgradeCampaignItem
+	db.Order("id").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *upgradeCampaignService) Cancel(_ context.Context, id int64) error {
+	biz.mutex.Lock()
+	cancel, ok := biz.cancels[id]
+	biz.mutex.Unlock()
+	if !ok {
+		return errcode.ErrOperateFailed
+	}
+	cancel()
+
+	return nil
+}
+
+func (biz *upgradeCampaignService) Reset(ctx context.Context) error {
+	return biz.db.WithContext(ctx).
+		Model(&entity.UpgradeCampaign{}).
+		Where("status = ?", entity.CSRunning).
+		UpdateColumn("status", entity.CSInterrupted).
+		Error
+}
+
+// selectMinions 查询符合条件且与发行版操作系统和架构一致的节点
+func (biz *upgradeCampaignService) selectMinions(ctx context.Context, bin *model.MinionBin, scope dynsql.Scope,
+	likes []gen.Condition,
+) (entity.UpgradeCampaignItems, error) {
+	const limit = 500
+	db := minionFilterDB(ctx, scope, likes)
+	monTbl := query.Minion
+	deleted := uint8(model.MSDelete)
+	now := time.Now()
+	ret := make(entity.UpgradeCampaignItems, 0, limit)
+
+	for offset := 0; ; offset += limit {
+		var mids []int64
+		if err := db.Offset(offset).Limit(limit).Scan(&mids).Error; err != nil {
+			return nil, err
+		}
+		if len(mids) == 0 {
+			break
+		}
+
+		mons, err := monTbl.WithContext(ctx).
+			Select(monTbl.ID, monTbl.Inet, monTbl.Edition, monTbl.BrokerID, monTbl.BrokerName).
+			Where(monTbl.ID.In(mids...)).
+			Where(monTbl.Goos.Eq(bin.Goos), monTbl.Arch.Eq(bin.Arch)).
+			Where(monTbl.Status.Neq(deleted)).
+			Find()
+		if err != nil {
+			return nil, err
+		}
+		for _, mon := range mons {
+			ret = append(ret, &entity.UpgradeCampaignItem{
+				MinionID:    mon.ID,
+				Inet:        mon.Inet,
+				BrokerID:    mon.BrokerID,
+				BrokerName:  mon.BrokerName,
+				FromEdition: mon.Edition,
+				Status:      entity.CISPending,
+				CreatedAt:   now,
+			})
+		}
+		if len(mids) < limit {
+			break
+		}
+	}
+
+	return ret, nil
+}
+
+func (biz *upgradeCampaignService) run(ctx context.Context, camp *entity.UpgradeCampaign) {
+	defer func() {
+		biz.mutex.Lock()
+		delete(biz.cancels, camp.ID)
+		biz.mutex.Unlock()
+	}()
+
+	status := entity.CSFinished
+	for {
+		if ctx.Err() != nil {
+			status = entity.CSCancelled
+			break
+		}
+		batch := biz.nextBatch(camp)
+		if len(batch) == 0 {
+			break
+		}
+		biz.rollout(ctx, camp, batch)
+		biz.summary(camp, entity.CSRunning)
+	}
+
+	if status == entity.CSCancelled {
+		biz.db.Model(&entity.UpgradeCampaignItem{}).
+			Where("campaign_id = ? AND status = ?", camp.ID, entity.CISPending).
+			UpdateColumns(map[string]any{
+				"status":      entity.CISSkipped,
+				"reason":      "升级活动已取消",
+				"finished_at": time.Now(),
+			})
+	}
+	biz.summary(camp, status)
+}
+
+// nextBatch 取出下一批次要升级的节点，每个 broker 最多取出 BrokerLimit 个节点，
+// 每批次最多取出 BatchSize 个节点。
+func (biz *upgradeCampaignService) nextBatch(camp *entity.UpgradeCampaign) entity.UpgradeCampaignItems {
+	var bids []int64
+	biz.db.Model(&entity.UpgradeCampaignItem{}).
+		Distinct("broker_id").
+		Where("campaign_id = ? AND status = ?", camp.ID, entity.CISPending).
+		Order("broker_id").
+		Scan(&bids)
+
+	ret := make(entity.UpgradeCampaignItems, 0, camp.BatchSize)
+	for _, bid := range bids {
+		remain := camp.BatchSize - len(ret)
+		if remain <= 0 {
+			break
+		}
+		limit := camp.BrokerLimit
+		if limit > remain {
+			limit = remain
+		}
+
+		var items entity.UpgradeCampaignItems
+		biz.db.Where("campaign_id = ? AND status = ? AND broker_id = ?", camp.ID, entity.CISPending, bid).
+			Order("id").
+			Limit(limit).
+			Find(&items)
+		ret = append(ret, items...)
+	}
+
+	return ret
+}
+
+// rollout 向一个批次的节点下发升级指令，并等待节点上报的版本变化。
+func (biz *upgradeCampaignService) rollout(ctx context.Context, camp *entity.UpgradeCampaign, batch entity.UpgradeCampaignItems) {
+	semver := string(camp.Semver)
+	mids := make([]int64, 0, len(batch))
+	for _, item := range batch {
+		mids = append(mids, item.MinionID)
+	}
+
+	// 下发指令前再次确认节点的状态与版本
+	monTbl := query.Minion
+	mons, _ := monTbl.WithContext(ctx).
+		Select(monTbl.ID, monTbl.Status, monTbl.Edition, monTbl.BrokerID).
+		Where(monTbl.ID.In(mids...)).
+		Find()
+	monMap := make(map[int64]*model.Minion, len(mons))
+	for _, mon := range mons {
+		monMap[mon.ID] = mon
+	}
+
+	wg := new(sync.WaitGroup)
+	for bid, items := range batch.BrokerMap() {
+		wg.Add(1)
+		go func(bid int64, items entity.UpgradeCampaignItems) {
+			defer wg.Done()
+			for _, item := range items {
+				mon := monMap[item.MinionID]
+				switch {
+				case mon == nil || mon.Status == model.MSDelete:
+					biz.finish(item, entity.CISFailed, "", "节点已删除")
+				case mon.Edition == semver:
+					biz.finish(item, entity.CISSucceed, mon.Edition, "")
+				case mon.Status != model.MSOnline:
+					biz.finish(item, entity.CISFailed, mon.Edition, "节点不在线")
+				default:
+					if err := biz.pusher.Upgrade(ctx, mon.BrokerID, mon.ID, semver); err != nil {
+						biz.finish(item, entity.CISFailed, mon.Edition, err.Error())
+						continue
+					}
+					item.Status = entity.CISPushed
+					biz.db.Model(item).UpdateColumns(map[string]any{
+						"status":    entity.CISPushed,
+						"pushed_at": time.Now(),
+					})
+				}
+			}
+		}(bid, items)
+	}
+	wg.Wait()
+
+	pushed := make(map[int64]*entity.UpgradeCampaignItem, len(batch))
+	for _, item := range batch {
+		if item.Status == entity.CISPushed {
+			pushed[item.MinionID] = item
+		}
+	}
+	biz.await(ctx, camp, pushed)
+}
+
+// await 等待节点版本变化为目标版本，超时或活动被取消后不再等待。
+func (biz *upgradeCampaignService) await(ctx context.Context, camp *entity.UpgradeCampaign, pushed map[int64]*entity.UpgradeCampaignItem) {
+	semver := string(camp.Semver)
+	timer := time.NewTimer(camp.Timeout)
+	ticker := time.NewTicker(biz.interval)
+	defer func() {
+		timer.Stop()
+		ticker.Stop()
+	}()
+
+	monTbl := query.Minion
+	for len(pushed) != 0 {
+		select {
+		case <-ctx.Done():
+			for _, item := range pushed {
+				biz.finish(item, entity.CISSkipped, "", "升级活动已取消，未确认升级结果")
+			}
+			return
+		case <-timer.C:
+			editions := biz.editions(pushed)
+			for mid, item := range pushed {
+				biz.finish(item, entity.CISTimeout, editions[mid], "等待版本变化超时")
+			}
+			return
+		case <-ticker.C:
+		}
+
+		mids := make([]int64, 0, len(pushed))
+		for mid := range pushed {
+			mids = append(mids, mid)
+		}
+		mons, _ := monTbl.WithContext(context.Background()).
+			Select(monTbl.ID).
+			Where(monTbl.ID.In(mids...), monTbl.Edition.Eq(semver)).
+			Find()
+		for _, mon := range mons {
+			if item := pushed[mon.ID]; item != nil {
+				biz.finish(item, entity.CISSucceed, semver, "")
+				delete(pushed, mon.ID)
+			}
+		}
+	}
+}
+
+func (biz *upgradeCampaignService) editions(items map[int64]*entity.UpgradeCampaignItem) map[int64]string {
+	mids := make([]int64, 0, len(items))
+	for mid := range items {
+		mids = append(mids, mid)
+	}
+	ret := make(map[int64]string, len(mids))
+	monTbl := query.Minion
+	mons, _ := monTbl.WithContext(context.Background()).
+		Select(monTbl.ID, monTbl.Edition).
+		Where(monTbl.ID.In(mids...)).
+		Find()
+	for _, mon := range mons {
+		ret[mon.ID] = mon.Edition
+	}
+
+	return ret
+}
+
+func (biz *upgradeCampaignService) finish(item *entity.UpgradeCampaignItem, status entity.CampaignItemStatus, edition, reason string) {
+	item.Status = status
+	biz.db.Model(item).UpdateColumns(map[string]any{
+		"status":      status,
+		"to_edition":  edition,
+		"reason":      reason,
+		"finished_at": sql.NullTime{Time: time.Now(), Valid: true},
+	})
+}
+
+// summary 统计升级结果并更新活动状态
+func (biz *upgradeCampaignService) summary(camp *entity.UpgradeCampaign, status entity.CampaignStatus) {
+	var stats []*param.CampaignBrokerStat
+	biz.db.Model(&entity.UpgradeCampaignItem{}).
+		Select("status", "COUNT(*) AS count").
+		Where("campaign_id = ?", camp.ID).
+		Group("status").
+		Scan(&stats)
+
+	var succeed, failed, timedout int
+	for _, st := range stats {
+		switch st.Status {
+		case entity.CISSucceed:
+			succeed = st.Count
+		case entity.CISFailed:
+			failed = st.Count
+		case entity.CISTimeout:
+			timedout = st.Count
+		}
+	}
+
+	now := time.Now()
+	assigns := map[string]any{
+		"status":     status,
+		"succeed":    succeed,
+		"failed":     failed,
+		"timedout":   timedout,
+		"updated_at": now,
+	}
+	if status != entity.CSRunning {
+		assigns["finished_at"] = now
+	}
+	biz.db.Model(camp).UpdateColumns(assigns)
+}
@@ -0,0 +1,773 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/vela-ssoc/vela-common-mb/dal/model"
+	"github.com/vela-ssoc/vela-common-mb/logback"
+	"github.com/vela-ssoc/vela-manager/app/internal/entity"
+	"github.com/vela-ssoc/vela-manager/app/internal/param"
+	"github.com/vela-ssoc/vela-manager/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookService 通用的 webhook 推送，定时收集安全事件、风险事件、配置下发任务、节点与 broker 状态变化，
+// 按照订阅渲染推送内容并签名，推送失败时按照指数退避重试。
+type WebhookService interface {
+	Page(ctx context.Context, page param.Pager) (int64, []*entity.Webhook)
+	Create(ctx context.Context, req *param.WebhookCreate, userID int64) error
+	Update(ctx context.Context, req *param.WebhookUpdate) error
+	Delete(ctx context.Context, id int64) error
+
+	Deliveries(ctx context.Context, req *param.WebhookDeliveryPage, page param.Pager) (int64, []*entity.WebhookDelivery)
+
+	// Redeliver 使用原推送内容重新推送
+	Redeliver(ctx context.Context, deliveryID int64) error
+
+	// Test 同步推送一条测试消息并返回推送结果
+	Test(ctx context.Context, id int64) (*entity.WebhookDelivery, error)
+
+	// Run 定时收集事件并推送
+	Run(ctx context.Context)
+}
+
+func Webhook(db *gorm.DB, slog logback.Logger) WebhookService {
+	return &webhookService{
+		db:       db,
+		slog:     slog,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		interval: 10 * time.Second,
+		backoff:  30 * time.Second,
+		limit:    500,
+		cursors:  make(map[string]*webhookCursor, 4),
+		seen:     cursorSeen{db: db},
+	}
+}
+
+type webhookService struct {
+	db       *gorm.DB
+	slog     logback.Logger
+	client   *http.Client
+	interval time.Duration
+	backoff  time.Duration // 第一次重试的间隔，之后每次翻倍
+	limit    int           // 每次收集的最大条数
+	seen     cursorSeen    // 补收提交延迟的安全事件与风险事件
+
+	// 以下状态只在 Run 协程中访问
+	cursors map[string]*webhookCursor    // 安全事件与风险事件的收集进度，与 webhook_cursor 表同步
+	tasks   map[int64]struct{}           // 未执行完毕的配置下发任务
+	minions map[int64]model.MinionStatus // 节点状态快照
+	brokers map[int64]bool               // broker 状态快照
+}
+
+// webhookCursor 收集进度，floor 为重新开始收集时的 ID，回扫不早于此 ID
+type webhookCursor struct {
+	floor int64
+	last  int64
+}
+
+// webhookMessage 推送模板的渲染数据
+type webhookMessage struct {
+	Topic   string    `json:"topic"`
+	Summary string    `json:"summary"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data"`
+	id      int64     // 按游标收集的数据 ID，用于回扫去重
+}
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+}
+
+func (biz *webhookService) Page(ctx context.Context, page param.Pager) (int64, []*entity.Webhook) {
+	db := biz.db.WithContext(ctx).Model(&entity.Webhook{})
+	if kw := page.Keyword(); kw != "" {
+		db = db.Where("name LIKE ? OR url LIKE ?", kw, kw)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.Webhook
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+	for _, dat := range dats {
+		dat.Signed = dat.Secret != ""
+	}
+
+	return count, dats
+}
+
+func (biz *webhookService) Create(ctx context.Context, req *param.WebhookCreate, userID int64) error {
+	if _, err := biz.parse(req.Template); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dat := &entity.Webhook{CreatedID: userID, CreatedAt: now}
+	biz.fill(dat, req, now)
+
+	return biz.db.WithContext(ctx).Create(dat).Error
+}
+
+func (biz *webhookService) Update(ctx context.Context, req *param.WebhookUpdate) error {
+	if _, err := biz.parse(req.Template); err != nil {
+		return err
+	}
+
+	dat := new(entity.Webhook)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", req.ID).
+		First(dat).Error; err != nil {
+		return err
+	}
+	secret := dat.Secret
+	biz.fill(dat, &req.WebhookCreate, time.Now())
+	// 前端拿不到原密钥，为空时保留原密钥
+	if dat.Secret == "" {
+		dat.Secret = secret
+	}
+
+	return biz.db.WithContext(ctx).Save(dat).Error
+}
+
+func (biz *webhookService) Delete(ctx context.Context, id int64) error {
+	return biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret := tx.Where("id = ?", id).Delete(&entity.Webhook{})
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if ret.RowsAffected == 0 {
+			return errcode.ErrOperateFailed
+		}
+		return tx.Where("webhook_id = ?", id).Delete(&entity.WebhookDelivery{}).Error
+	})
+}
+
+func (biz *webhookService) Deliveries(ctx context.Context, req *param.WebhookDeliveryPage, page param.Pager) (int64, []*entity.WebhookDelivery) {
+	db := biz.db.WithContext(ctx).Model(&entity.WebhookDelivery{})
+	if req.WebhookID != 0 {
+		db = db.Where("webhook_id = ?", req.WebhookID)
+	}
+	if req.Topic != "" {
+		db = db.Where("topic = ?", req.Topic)
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	var count int64
+	if db.Count(&count); count == 0 {
+		return 0, nil
+	}
+
+	var dats []*entity.WebhookDelivery
+	db.Order("id DESC").Scopes(page.DBScope(count)).Find(&dats)
+
+	return count, dats
+}
+
+func (biz *webhookService) Redeliver(ctx context.Context, deliveryID int64) error {
+	old := new(entity.WebhookDelivery)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", deliveryID).
+		First(old).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	dat := &entity.WebhookDelivery{
+		WebhookID: old.WebhookID,
+		Topic:     old.Topic,
+		Payload:   old.Payload,
+		Status:    entity.WhsPending,
+		NextAt:    sql.NullTime{Time: now, Valid: true},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return biz.db.WithContext(ctx).Create(dat).Error
+}
+
+func (biz *webhookService) Test(ctx context.Context, id int64) (*entity.WebhookDelivery, error) {
+	hook := new(entity.Webhook)
+	if err := biz.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(hook).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	msg := &webhookMessage{
+		Topic:   entity.WebhookTest,
+		Summary: "这是一条测试消息",
+		Time:    now,
+		Data:    map[string]any{"webhook_id": strconv.FormatInt(hook.ID, 10), "name": hook.Name},
+	}
+	payload, err := biz.render(hook.Template, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	dat := &entity.WebhookDelivery{
+		WebhookID: hook.ID,
+		Topic:     entity.WebhookTest,
+		Payload:   payload,
+		Status:    entity.WhsPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err = biz.db.WithContext(ctx).Create(dat).Error; err != nil {
+		return nil, err
+	}
+	// 测试消息不重试
+	hook.MaxRetries = 0
+	biz.deliver(ctx, hook, dat)
+
+	return dat, nil
+}
+
+func (biz *webhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(biz.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			biz.collect(ctx)
+			biz.dispatch(ctx)
+			if err := biz.seen.prune(ctx); err != nil {
+				biz.slog.Warnf("清理 webhook 回扫记录出错：%s", err)
+			}
+		}
+	}
+}
+
+func (biz *webhookService) fill(dat *entity.Webhook, req *param.WebhookCreate, now time.Time) {
+	dat.Name = req.Name
+	dat.URL = req.URL
+	dat.Secret = req.Secret
+	dat.Topics = req.Topics
+	dat.Template = req.Template
+	dat.MaxRetries = req.MaxRetries
+	dat.Enabled = req.Enabled
+	dat.UpdatedAt = now
+}
+
+func (biz *webhookService) parse(tpl string) (*template.Template, error) {
+	if tpl == "" {
+		return nil, nil
+	}
+	t, err := template.New("webhook").Funcs(webhookFuncs).Parse(tpl)
+	if err != nil {
+		return nil, errcode.FmtErrWebhookTemplate.Fmt(err)
+	}
+
+	return t, nil
+}
+
+// render 渲染推送内容，模板为空时推送 JSON 格式的消息。
+func (biz *webhookService) render(tpl string, msg *webhookMessage) (string, error) {
+	t, err := biz.parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	if t == nil {
+		raw, exx := json.Marshal(msg)
+		return string(raw), exx
+	}
+
+	buf := new(bytes.Buffer)
+	if err = t.Execute(buf, msg); err != nil {
+		return "", errcode.FmtErrWebhookTemplate.Fmt(err)
+	}
+
+	return buf.String(), nil
+}
+
+// collect 收集上一次收集之后发生的事件。
+// 安全事件与风险事件按持久化的游标收集，任务、节点与 broker 状态变化对比内存中的快照，
+// 快照为空（首次运行或之前没有订阅）时只记录当前状态。
+func (biz *webhookService) collect(ctx context.Context) {
+	hooks := biz.subscribers(ctx)
+	var msgs []*webhookMessage
+	msgs = append(msgs, biz.collectEvents(ctx, hooks)...)
+	msgs = append(msgs, biz.collectRisks(ctx, hooks)...)
+	msgs = append(msgs, biz.collectTasks(ctx, hooks)...)
+	msgs = append(msgs, biz.collectMinions(ctx, hooks)...)
+	msgs = append(msgs, biz.collectBrokers(ctx, hooks)...)
+
+	now := time.Now()
+	var dats []*entity.WebhookDelivery
+	seens := make(map[string][]int64, 2)
+	for _, msg := range msgs {
+		if msg.id != 0 {
+			seens[msg.Topic] = append(seens[msg.Topic], msg.id)
+		}
+		for _, hook := range hooks[msg.Topic] {
+			dat := &entity.WebhookDelivery{
+				WebhookID: hook.ID,
+				Topic:     msg.Topic,
+				Status:    entity.WhsPending,
+				NextAt:    sql.NullTime{Time: now, Valid: true},
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			payload, err := biz.render(hook.Template, msg)
+			if err != nil {
+				dat.Status = entity.WhsFailed
+				dat.Error = err.Error()
+				dat.NextAt = sql.NullTime{}
+				dat.DoneAt = sql.NullTime{Time: now, Valid: true}
+			}
+			dat.Payload = payload
+			dats = append(dats, dat)
+		}
+	}
+
+	// 推送记录、已收集的数据 ID 与游标在同一事务中保存，保存失败时丢弃内存中的游标，下次从数据库中的游标重新收集
+	if err := biz.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(dats) != 0 {
+			if err := tx.CreateInBatches(dats, 200).Error; err != nil {
+				return err
+			}
+		}
+		for topic, ids := range seens {
+			if err := biz.seen.mark(tx, "webhook:"+topic, ids, now); err != nil {
+				return err
+			}
+		}
+		return biz.saveCursors(tx, now)
+	}); err != nil {
+		biz.cursors = make(map[string]*webhookCursor, 4)
+		biz.slog.Warnf("保存 webhook 推送记录出错：%s", err)
+	}
+}
+
+// lastID 返回事件主题的收集进度。没有订阅或者没有保存过游标时，游标移动到当前最大 ID，
+// 跳过积压的数据，并返回 false。
+// pending 返回需要收集的数据 ID：回扫窗口内提交延迟的数据与游标之后的新增数据。
+// 没有订阅者或者没有保存过游标时，游标跳到当前最大 ID，不收集历史数据。
+func (biz *webhookService) pending(ctx context.Context, topic, table string, subscribed bool) []int64 {
+	cur := biz.cursors[topic]
+	if cur == nil {
+		var dat entity.WebhookCursor
+		if biz.db.WithContext(ctx).
+			Where("topic = ?", topic).
+			Limit(1).
+			Find(&dat); dat.ID != 0 {
+			cur = &webhookCursor{floor: dat.FloorID, last: dat.LastID}
+		}
+	}
+	if !subscribed || cur == nil {
+		var last int64
+		biz.db.WithContext(ctx).Table(table).Select("COALESCE(MAX(id), 0)").Scan(&last)
+		biz.cursors[topic] = &webhookCursor{floor: last, last: last}
+		return nil
+	}
+	biz.cursors[topic] = cur
+
+	ids, err := biz.seen.late(ctx, "webhook:"+topic, table, cur.floor, cur.last, biz.limit)
+	if err != nil {
+		biz.slog.Warnf("回扫 webhook %s 数据出错：%s", topic, err)
+	}
+	var news []int64
+	biz.db.WithContext(ctx).
+		Table(table).
+		Where("id > ?", cur.last).
+		Order("id").
+		Limit(biz.limit).
+		Pluck("id", &news)
+	if n := len(news); n != 0 {
+		cur.last = news[n-1]
+	}
+
+	return append(ids, news...)
+}
+
+func (biz *webhookService) saveCursors(tx *gorm.DB, now time.Time) error {
+	if len(biz.cursors) == 0 {
+		return nil
+	}
+	dats := make([]*entity.WebhookCursor, 0, len(biz.cursors))
+	for topic, cur := range biz.cursors {
+		dats = append(dats, &entity.WebhookCursor{Topic: topic, FloorID: cur.floor, LastID: cur.last, UpdatedAt: now})
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "topic"}},
+		DoUpdates: clause.AssignmentColumns([]string{"floor_id", "last_id", "updated_at"}),
+	}).Create(dats).Error
+}
+
+func (biz *webhookService) subscribers(ctx context.Context) map[string][]*entity.Webhook {
+	var hooks []*entity.Webhook
+	biz.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Find(&hooks)
+	ret := make(map[string][]*entity.Webhook, 8)
+	for _, hook := range hooks {
+		for _, topic := range hook.Topics {
+			ret[topic] = append(ret[topic], hook)
+		}
+	}
+
+	return ret
+}
+
+func (biz *webhookService) collectEvents(ctx context.Context, hooks map[string][]*entity.Webhook) []*webhookMessage {
+	topic := entity.WebhookEvent
+	ids := biz.pending(ctx, topic, "event", len(hooks[topic]) != 0)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var evts []*model.Event
+	biz.db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&evts)
+	msgs := make([]*webhookMessage, 0, len(evts))
+	for _, evt := range evts {
+		msgs = append(msgs, &webhookMessage{
+			id:      evt.ID,
+			Topic:   topic,
+			Summary: fmt.Sprintf("节点 %s 发生安全事件：%s", evt.Inet, evt.Subject),
+			Time:    evt.OccurAt,
+			Data:    evt,
+		})
+	}
+
+	return msgs
+}
+
+func (biz *webhookService) collectRisks(ctx context.Context, hooks map[string][]*entity.Webhook) []*webhookMessage {
+	topic := entity.WebhookRisk
+	ids := biz.pending(ctx, topic, "risk", len(hooks[topic]) != 0)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var risks []*model.Risk
+	biz.db.WithContext(ctx).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&risks)
+	msgs := make([]*webhookMessage, 0, len(risks))
+	for _, rsk := range risks {
+		msgs = append(msgs, &webhookMessage{
+			id:      rsk.ID,
+			Topic:   topic,
+			Summary: fmt.Sprintf("节点 %s 发现%s风险：%s", rsk.Inet, rsk.Level, rsk.Subject),
+			Time:    rsk.OccurAt,
+			Data:    rsk,
+		})
+	}
+
+	return msgs
+}
+
+// collectTasks 上次收集时仍有节点未执行完毕，本次全部执行完毕的配置下发任务视为完成。
+func (biz *webhookService) collectTasks(ctx context.Context, hooks map[string][]*entity.Webhook) []*webhookMessage {
+	// 没有订阅时不扫描，清空快照，有订阅后第一次扫描只记录当前状态
+	if len(hooks[entity.WebhookTask]) == 0 {
+		biz.tasks = nil
+		return nil
+	}
+
+	var ids []int64
+	biz.db.WithContext(ctx).
+		Model(&model.SubstanceTask{}).
+		Distinct("task_id").
+		Where("executed = ?", false).
+		Pluck("task_id", &ids)
+	running := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		running[id] = struct{}{}
+	}
+
+	var finished []int64
+	for id := range biz.tasks {
+		if _, ok := running[id]; !ok {
+			finished = append(finished, id)
+		}
+	}
+	biz.tasks = running
+	if len(finished) == 0 {
+		return nil
+	}
+
+	var stats []*struct {
+		TaskID int64 `json:"task_id,string"`
+		Total  int   `json:"total"`
+		Failed int   `json:"failed"`
+	}
+	biz.db.WithContext(ctx).
+		Model(&model.SubstanceTask{}).
+		Select("task_id", "COUNT(*) AS total", "SUM(failed) AS failed").
+		Where("task_id IN ?", finished).
+		Group("task_id").
+		Scan(&stats)
+	now := time.Now()
+	msgs := make([]*webhookMessage, 0, len(stats))
+	for _, st := range stats {
+		msgs = append(msgs, &webhookMessage{
+			Topic:   entity.WebhookTask,
+			Summary: fmt.Sprintf("配置下发任务 %d 执行完毕，共 %d 个节点，失败 %d 个", st.TaskID, st.Total, st.Failed),
+			Time:    now,
+			Data:    st,
+		})
+	}
+
+	return msgs
+}
+
+func (biz *webhookService) collectMinions(ctx context.Context, hooks map[string][]*entity.Webhook) []*webhookMessage {
+	if len(hooks[entity.WebhookMinion]) == 0 {
+		biz.minions = nil
+		return nil
+	}
+
+	var mons []*model.Minion
+	biz.db.WithContext(ctx).
+		Select("id", "inet", "status").
+		Find(&mons)
+	if len(mons) == 0 {
+		return nil
+	}
+	snapshot := make(map[int64]model.MinionStatus, len(mons))
+	for _, mon := range mons {
+		snapshot[mon.ID] = mon.Status
+	}
+	olds := biz.minions
+	biz.minions = snapshot
+	if olds == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var msgs []*webhookMessage
+	for _, mon := range mons {
+		old, ok := olds[mon.ID]
+		if !ok || old == mon.Status {
+			continue
+		}
+		msgs = append(msgs, &webhookMessage{
+			Topic:   entity.WebhookMinion,
+			Summary: fmt.Sprintf("节点 %s 状态由%s变为%s", mon.Inet, old, mon.Status),
+			Time:    now,
+			Data: map[string]any{
+				"id":          strconv.FormatInt(mon.ID, 10),
+				"inet":        mon.Inet,
+				"from_status": old,
+				"to_status":   mon.Status,
+			},
+		})
+	}
+
+	return msgs
+}
+
+func (biz *webhookService) collectBrokers(ctx context.Context, hooks map[string][]*entity.Webhook) []*webhookMessage {
+	if len(hooks[entity.WebhookBroker]) == 0 {
+		biz.brokers = nil
+		return nil
+	}
+
+	var brks []*model.Broker
+	biz.db.WithContext(ctx).
+		Select("id", "name", "status").
+		Find(&brks)
+	if len(brks) == 0 {
+		return nil
+	}
+	snapshot := make(map[int64]bool, len(brks))
+	for _, brk := range brks {
+		snapshot[brk.ID] = brk.Status
+	}
+	olds := biz.brokers
+	biz.brokers = snapshot
+	if olds == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var msgs []*webhookMessage
+	for _, brk := range brks {
+		if brk.Status || !olds[brk.ID] {
+			continue
+		}
+		msgs = append(msgs, &webhookMessage{
+			Topic:   entity.WebhookBroker,
+			Summary: fmt.Sprintf("broker 节点 %s 断开连接", brk.Name),
+			Time:    now,
+			Data: map[string]any{
+				"id":   strconv.FormatInt(brk.ID, 10),
+				"name": brk.Name,
+			},
+		})
+	}
+
+	return msgs
+}
+
+// dispatch 推送到期的推送记录
+func (biz *webhookService) dispatch(ctx context.Context) {
+	var dats []*entity.WebhookDelivery
+	biz.db.WithContext(ctx).
+		Where("status = ? AND next_at <= ?", entity.WhsPending, time.Now()).
+		Order("id").
+		Limit(biz.limit).
+		Find(&dats)
+	if len(dats) == 0 {
+		return
+	}
+
+	hookIDs := make([]int64, 0, len(dats))
+	for _, dat := range dats {
+		hookIDs = append(hookIDs, dat.WebhookID)
+	}
+	var hooks []*entity.Webhook
+	biz.db.WithContext(ctx).
+		Where("id IN ?", hookIDs).
+		Find(&hooks)
+	hookMap := make(map[int64]*entity.Webhook, len(hooks))
+	for _, hook := range hooks {
+		hookMap[hook.ID] = hook
+	}
+
+	// 限制并发，避免个别响应慢的接收端拖慢整体推送
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 8)
+	for _, dat := range dats {
+		hook := hookMap[dat.WebhookID]
+		if hook == nil || !hook.Enabled {
+			biz.finish(ctx, dat, entity.WhsFailed, "订阅不存在或已禁用")
+			continue
+		}
+
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(hook *entity.Webhook, dat *entity.WebhookDelivery) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			biz.deliver(ctx, hook, dat)
+		}(hook, dat)
+	}
+	wg.Wait()
+}
+
+// deliver 推送一次并保存推送结果，失败后按照指数退避计算下次推送时间。
+func (biz *webhookService) deliver(ctx context.Context, hook *entity.Webhook, dat *entity.WebhookDelivery) {
+	code, resp, err := biz.send(ctx, hook, dat)
+	now := time.Now()
+	dat.Attempts++
+	dat.StatusCode = code
+	dat.Response = resp
+	dat.Error = ""
+	dat.UpdatedAt = now
+	switch {
+	case err == nil:
+		dat.Status = entity.WhsSucceed
+		dat.NextAt = sql.NullTime{}
+		dat.DoneAt = sql.NullTime{Time: now, Valid: true}
+	case dat.Attempts > hook.MaxRetries:
+		dat.Status = entity.WhsFailed
+		dat.Error = err.Error()
+		dat.NextAt = sql.NullTime{}
+		dat.DoneAt = sql.NullTime{Time: now, Valid: true}
+	default:
+		delay := biz.backoff << (dat.Attempts - 1)
+		if delay > time.Hour {
+			delay = time.Hour
+		}
+		dat.Error = err.Error()
+		dat.NextAt = sql.NullTime{Time: now.Add(delay), Valid: true}
+	}
+
+	if exx := biz.db.WithContext(ctx).
+		Model(dat).
+		Select("status", "attempts", "status_code", "response", "error", "next_at", "done_at", "updated_at").
+		Updates(dat).Error; exx != nil {
+		biz.slog.Warnf("保存 webhook 推送结果出错：%s", exx)
+	}
+}
+
+func (biz *webhookService) finish(ctx context.Context, dat *entity.WebhookDelivery, status entity.WebhookStatus, reason string) {
+	now := time.Now()
+	biz.db.WithContext(ctx).
+		Model(dat).
+		UpdateColumns(map[string]any{
+			"status":     status,
+			"error":      reason,
+			"next_at":    nil,
+			"done_at":    now,
+			"updated_at": now,
+		})
+}
+
+// send 推送消息，请求头中携带签名：
+//
+//	X-Webhook-Signature: sha256=HEX(HMAC-SHA256(secret, timestamp + "." + body))
+func (biz *webhookService) send(ctx context.Context, hook *entity.Webhook, dat *entity.WebhookDelivery) (int, string, error) {
+	body := []byte(dat.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	if json.Valid(body) {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	} else {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	req.Header.Set("User-Agent", "ssoc-manager-webhook")
+	req.Header.Set("X-Webhook-ID", strconv.FormatInt(dat.ID, 10))
+	req.Header.Set("X-Webhook-Topic", dat.Topic)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if secret := hook.Secret; secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp))
+		mac.Write([]byte("."))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := biz.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	resp := strings.ToValidUTF8(string(raw), "")
+	code := res.StatusCode
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		return code, resp, fmt.Errorf("接收端响应状态码 %d", code)
+	}
+
+	return code, resp, nil
+}
@@ -17,12 +17,37 @@ type Pusher interface {
 	ThirdDelete(ctx context.Context, name string)
 	ElasticReset(ctx context.Context)
 	EmcReset(ctx context.Context)
+	EmailReset(ctx context.Context)
 	StoreReset(ctx context.Context, id string)
 	NotifierReset(ctx context.Context)
+	PassReset(ctx context.Context, table string)
 	Startup(ctx context.Context, bid, mid int64)
-	Upgrade(ctx context.Context, bid, mid int64, semver string)
+	Upgrade(ctx context.Context, bid, mid int64, semver string) error
 	Command(ctx context.Context, bid, mid int64, cmd string)
-	Offline(ctx context.Context, bid, mid int64)
+	Offline(ctx context.Context, bid, mid int64) error
+
+	// Exec 通过 broker 在节点上执行指令，并等待节点响应执行结果
+	Exec(ctx context.Context, bid, mid int64, cmd string) (*CommandReply, error)
+}
+
+// FPCommandExec 执行指令并等待节点响应执行结果，请求体为 accord.Command，broker
+// 将指令转发给节点，节点执行完毕后 broker 以 CommandReply 作为响应体返回。
+const FPCommandExec = accord.PathPrefix + "/command/exec"
+
+// FPPassReset IP/域名白名单变更。accord 中目前没有白名单相关的路径，这是 manager
+// 新定义的路径，broker 需要实现该路径才能在白名单变更时刷新缓存，未实现的 broker 会响应 404。
+const FPPassReset = accord.PathPrefix + "/pass/reset"
+
+// PassReset 白名单变更的数据表
+type PassReset struct {
+	Table string `json:"table"` // pass_ip 或 pass_dns
+}
+
+// CommandReply 节点执行指令的结果
+type CommandReply struct {
+	Succeed bool   `json:"succeed"` // 节点是否执行成功
+	Output  string `json:"output"`  // 输出
+	Error   string `json:"error"`   // 执行失败的原因
 }
 
 func NewPush(hub linkhub.Huber) Pusher {
@@ -87,6 +112,11 @@ func (pi *pushImpl) EmcReset(ctx context.Context) {
 	pi.hub.Broadcast(nil, accord.FPEmcReset, nil)
 }
 
+// EmailReset broker 在告警通知配置重置时重新读取邮件服务器配置
+func (pi *pushImpl) EmailReset(ctx context.Context) {
+	pi.hub.Broadcast(nil, accord.FPNotifierReset, nil)
+}
+
 func (pi *pushImpl) StoreReset(ctx context.Context, id string) {
 	req := &accord.StoreRestRequest{ID: id}
 	pi.hub.Broadcast(nil, accord.FPStoreReset, req)
@@ -96,14 +126,19 @@ func (pi *pushImpl) NotifierReset(ctx context.Context) {
 	pi.hub.Broadcast(nil, accord.FPNotifierReset, nil)
 }
 
+func (pi *pushImpl) PassReset(ctx context.Context, table string) {
+	req := &PassReset{Table: table}
+	pi.hub.Broadcast(nil, FPPassReset, req)
+}
+
 func (pi *pushImpl) Startup(ctx context.Context, bid int64, mid int64) {
 	req := accord.Startup{ID: mid}
 	_ = pi.hub.Oneway(nil, bid, accord.FPStartup, req)
 }
 
-func (pi *pushImpl) Upgrade(ctx context.Context, bid int64, mid int64, semver string) {
+func (pi *pushImpl) Upgrade(ctx context.Context, bid int64, mid int64, semver string) error {
 	req := accord.Upgrade{ID: mid, Semver: semver}
-	_ = pi.hub.Oneway(nil, bid, accord.FPUpgrade, req)
+	return pi.hub.Oneway(nil, bid, accord.FPUpgrade, req)
 }
 
 func (pi *pushImpl) Command(ctx context.Context, bid int64, mid int64, cmd string) {
@@ -111,7 +146,18 @@ func (pi *pushImpl) Command(ctx context.Context, bid int64, mid int64, cmd strin
 	_ = pi.hub.Oneway(nil, bid, accord.FPCommand, req)
 }
 
-func (pi *pushImpl) Offline(ctx context.Context, bid, mid int64) {
+func (pi *pushImpl) Offline(ctx context.Context, bid, mid int64) error {
+	req := accord.Command{ID: mid, Cmd: "offline"}
+	return pi.hub.Oneway(nil, bid, accord.FPCommand, req)
+}
+
+func (pi *pushImpl) Exec(ctx context.Context, bid, mid int64, cmd string) (*CommandReply, error) {
+	req := accord.Command{ID: mid, Cmd: cmd}
+	ret := new(CommandReply)
+	if err := pi.hub.Unicast(nil, bid, FPCommandExec, req, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
 }
 
 func (pi *pushImpl) thirdDiff(ctx context.Context, name, event string) {
@@ -5,6 +5,8 @@ import "github.com/xgfone/ship/v5"
 var (
 	ErrUnauthorized = ship.ErrUnauthorized.Newf("认证无效")
 	ErrForbidden    = ship.ErrForbidden.Newf("禁止操作")
+	ErrProxyDenied  = ship.ErrForbidden.Newf("访问策略不允许代理该节点的请求")
+	ErrProxyPath    = ship.ErrBadRequest.Newf("代理路径不合法")
 
 	ErrUnsupportedWebSocket = ship.ErrBadRequest.Newf("该接口接口不支持 websocket 请求")
 	ErrRequiredWebSocket    = ship.ErrBadRequest.Newf("该接口必须是 websocket 协议的请求")
@@ -32,6 +34,24 @@ var (
 	ErrInetAddress          = ship.ErrBadRequest.Newf("inet 地址无效")
 	ErrAlreadyExist         = ship.ErrBadRequest.Newf("数据已存在")
 	ErrInvalidData          = ship.ErrBadRequest.Newf("数据验证无效")
+	ErrNoMatchedNode        = ship.ErrBadRequest.Newf("没有符合条件的节点")
+	ErrImportFormat         = ship.ErrBadRequest.Newf("仅支持导入 csv 或 xlsx 文件")
+	ErrImportEmpty          = ship.ErrBadRequest.Newf("导入文件没有数据")
+	ErrImportTooMany        = ship.ErrBadRequest.Newf("导入文件数据行数过多")
+	ErrImportHeader         = ship.ErrBadRequest.Newf("导入文件缺少 IPv4 列")
+	ErrTagLifelong          = ship.ErrBadRequest.Newf("系统永久标签不允许修改")
+	ErrUserNotExist         = ship.ErrBadRequest.Newf("用户不存在")
+	ErrNoAttachment         = ship.ErrBadRequest.Newf("没有附件")
+	ErrThreatFormat         = ship.ErrBadRequest.Newf("仅支持导入 csv、txt 或 STIX 2.1 JSON 格式的情报文件")
+	ErrThreatTooLarge       = ship.ErrBadRequest.Newf("情报文件过大")
+	ErrIncidentSplit        = ship.ErrBadRequest.Newf("拆分的风险必须属于该事件，且不能拆分全部风险")
+	ErrRetentionPolicy      = ship.ErrBadRequest.Newf("该数据表没有配置有效的保留策略")
+	ErrRequiredPassword     = ship.ErrBadRequest.Newf("密码必须填写")
+	ErrEmailDisabled        = ship.ErrBadRequest.Newf("没有启用的邮件服务器")
+	ErrEmailDecrypt         = ship.ErrBadRequest.Newf("邮箱密码解密失败，请重新填写密码")
+	ErrEmailSecret          = ship.ErrBadRequest.Newf("没有配置加密密钥 section.secret，无法保存邮箱密码")
+	ErrNotifierWays         = ship.ErrBadRequest.Newf("该告警人没有配置通知方式")
+	ErrDashDimension        = ship.ErrBadRequest.Newf("只有风险事件支持按风险类型细分")
 )
 
 type Errorf interface {
@@ -45,6 +65,27 @@ func (f formatError) Fmt(a ...any) error {
 }
 
 const (
-	FmtErrNameExist = formatError("名字 %s 已经存在")
-	FmtErrInetExist = formatError("inet %s 已经存在")
+	FmtErrNameExist       = formatError("名字 %s 已经存在")
+	FmtErrInetExist       = formatError("inet %s 已经存在")
+	FmtErrImportRepeat    = formatError("与第 %d 行的 inet 重复")
+	FmtErrTagRuleField    = formatError("不支持的匹配字段 %s")
+	FmtErrTagRuleCIDR     = formatError("%s 不是有效的 CIDR")
+	FmtErrTagNotExist     = formatError("标签 %s 不存在")
+	FmtErrTagExist        = formatError("标签 %s 已经存在，请使用合并")
+	FmtErrTagOnlyOne      = formatError("标签 %s 是发布配置 %s 唯一的标签，不允许删除")
+	FmtErrTagRuled        = formatError("标签 %s 由自动标签规则 %s 维护，请先修改规则")
+	FmtErrAlertField      = formatError("数据源 %s 不支持字段 %s")
+	FmtErrAlertCond       = formatError("数据源 %s 不支持 %s 条件")
+	FmtErrThreatDir       = formatError("情报目录 %s 不存在")
+	FmtErrExportLimit     = formatError("符合条件的数据有 %d 条，超过了单次导出上限 %d 条，请缩小筛选范围")
+	FmtErrWebhookTemplate = formatError("推送模板错误：%v")
+	FmtErrSiemSend        = formatError("发送到 SIEM 失败：%v")
+	FmtErrEmailSend       = formatError("邮件发送失败：%v")
+	FmtErrPassExist       = formatError("维度 %s 下已存在白名单 %s")
+	FmtErrPassIP          = formatError("%s 不是有效的 IP 地址")
+	FmtErrPassDomain      = formatError("%s 不是有效的域名")
+	FmtErrAttackBundle    = formatError("ATT&CK 数据解析失败：%v")
+	FmtErrAttackTechnique = formatError("ATT&CK 技术 %s 不存在，请先导入 ATT&CK 数据")
+	FmtErrAttackMapping   = formatError("%s 为 %s 的映射已经存在")
+	FmtErrAggregateColumn = formatError("不支持按字段 %s 统计")
 )
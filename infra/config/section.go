@@ -6,4 +6,6 @@ type Section struct {
 	Dong bool          `json:"dong" yaml:"dong"` // 是否发送咚咚验证码
 	CDN  string        `json:"cdn"  yaml:"cdn"`  // 文件下载缓存目录
 	Sess time.Duration `json:"sess" yaml:"sess"` // session 间隔
+	// Secret 邮箱密码等敏感配置的加密密钥，为空时不能保存邮箱密码，修改后已保存的密码需要重新填写
+	Secret string `json:"secret" yaml:"secret"`
 }
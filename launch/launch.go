@@ -2,7 +2,6 @@ package launch
 
 import (
 	"context"
-	"net/http"
 	"strings"
 	"time"
 
@@ -11,6 +10,7 @@ import (
 	"github.com/vela-ssoc/vela-common-mb/dbms"
 	"github.com/vela-ssoc/vela-common-mb/gopool"
 	"github.com/vela-ssoc/vela-common-mb/integration/cmdb"
+	"github.com/vela-ssoc/vela-common-mb/integration/devops"
 	"github.com/vela-ssoc/vela-common-mb/integration/dong"
 	"github.com/vela-ssoc/vela-common-mb/integration/elastic"
 	"github.com/vela-ssoc/vela-common-mb/integration/ssoauth"
@@ -19,6 +19,7 @@ import (
 	"github.com/vela-ssoc/vela-common-mb/storage"
 	"github.com/vela-ssoc/vela-common-mb/validate"
 	"github.com/vela-ssoc/vela-common-mba/netutil"
+	"github.com/vela-ssoc/vela-manager/app/brkapi"
 	"github.com/vela-ssoc/vela-manager/app/mgtapi"
 	"github.com/vela-ssoc/vela-manager/app/middle"
 	"github.com/vela-ssoc/vela-manager/app/route"
@@ -95,7 +96,15 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	pool := gopool.New(1024, 1024, 10*time.Minute)
 
 	// ==========[ broker begin ] ==========
-	huber := linkhub.New(http.NewServeMux(), pool, cfg) // 将连接中心注入到 broker 接入网关中
+	// broker 通过连接通道调用 manager 的接口
+	brkSh := ship.Default()
+	brkSh.Logger = slog
+	brkSh.Validator = valid
+	brkSh.NotFound = prob.NotFound
+	brkSh.HandleError = prob.HandleError
+	brk := brkSh.Group(base)
+
+	huber := linkhub.New(brkSh, pool, cfg) // 将连接中心注入到 broker 接入网关中
 	pusher := push.NewPush(huber)
 	brkHandle := blink.New(huber)        // 将 broker 网关注入到 blink service 中
 	blinkREST := mgtapi.Blink(brkHandle) // 构造 REST 层
@@ -132,20 +141,65 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 
 	cmdbCfg := cmdb.NewConfigure(store)
 	cmdbClient := cmdb.NewClient(cmdbCfg, client, slog)
-	minionService := service.Minion(cmdbClient, pusher)
+
+	tagRuleService := service.TagRule(db, pusher)
+	tagRuleREST := mgtapi.TagRule(tagRuleService)
+	tagRuleREST.Route(anon, bearer, basic)
+
+	minionOfflineService := service.MinionOffline(db, pusher)
+	go minionOfflineService.Run(ctx)
+	minionOfflineREST := mgtapi.MinionOffline(minionOfflineService)
+	minionOfflineREST.Route(anon, bearer, basic)
+
+	minionService := service.Minion(cmdbClient, pusher, minionOfflineService, tagRuleService)
 	minionREST := mgtapi.Minion(huber, minionService)
 	minionREST.Route(anon, bearer, basic)
 
-	intoService := service.Into(huber)
+	minionPurgeService := service.MinionPurge(db)
+	if err = minionPurgeService.Reset(ctx); err != nil {
+		return nil, err
+	}
+	minionPurgeREST := mgtapi.MinionPurge(minionPurgeService)
+	minionPurgeREST.Route(anon, bearer, basic)
+
+	minionImportService := service.MinionImport(db, cmdbClient, valid, tagRuleService)
+	if err = minionImportService.Reset(ctx); err != nil {
+		return nil, err
+	}
+	minionImportREST := mgtapi.MinionImport(minionImportService)
+	minionImportREST.Route(anon, bearer, basic)
+
+	sessionRecordService := service.SessionRecord(db, gfs)
+	go sessionRecordService.Run(ctx)
+	sessionRecordREST := mgtapi.SessionRecord(sessionRecordService)
+	sessionRecordREST.Route(anon, bearer, basic)
+
+	proxyPolicyService := service.ProxyPolicy(db)
+	proxyPolicyREST := mgtapi.ProxyPolicy(proxyPolicyService)
+	proxyPolicyREST.Route(anon, bearer, basic)
+
+	proxyAuditService := service.ProxyAudit(db, slog)
+	go proxyAuditService.Run(ctx)
+	proxyAuditREST := mgtapi.ProxyAudit(proxyAuditService)
+	proxyAuditREST.Route(anon, bearer, basic)
+
+	intoService := service.Into(huber, sessionRecordService, proxyPolicyService, proxyAuditService)
 	intoREST := mgtapi.Into(intoService, headerKey, queryKey)
 	intoREST.Route(anon, bearer, basic)
 
-	tagService := service.Tag(pusher)
+	tagService := service.Tag(db, pusher, sequenceService)
 	tagREST := mgtapi.Tag(tagService)
 	tagREST.Route(anon, bearer, basic)
 
 	// -----[ 配置与发布 ]-----
-	substanceService := service.Substance(pusher, digestService, sequenceService)
+	commandExecService := service.CommandExec(db, pusher)
+	if err = commandExecService.Reset(ctx); err != nil {
+		return nil, err
+	}
+	commandExecREST := mgtapi.CommandExec(commandExecService)
+	commandExecREST.Route(anon, bearer, basic)
+
+	substanceService := service.Substance(pusher, digestService, sequenceService, commandExecService)
 	substanceREST := mgtapi.Substance(substanceService)
 	substanceREST.Route(anon, bearer, basic)
 
@@ -160,8 +214,10 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	elasticREST := mgtapi.Elastic(elasticService, headerKey, queryKey)
 	elasticREST.Route(anon, bearer, basic)
 
+	aggregateService := service.Aggregate(db)
+
 	processService := service.Process()
-	processREST := mgtapi.Process(processService)
+	processREST := mgtapi.Process(processService, aggregateService)
 	processREST.Route(anon, bearer, basic)
 
 	accountService := service.Account()
@@ -172,26 +228,56 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	oplogREST := mgtapi.Oplog(oplogService)
 	oplogREST.Route(anon, bearer, basic)
 
-	notifierService := service.Notifier(pusher)
+	emailService := service.Email(db, pusher, secCfg.Secret)
+	if err = emailService.Seal(ctx); err != nil {
+		return nil, err
+	}
+	emailREST := mgtapi.Email(emailService)
+	emailREST.Route(anon, bearer, basic)
+	emailBRK := brkapi.Email(emailService)
+	emailBRK.Route(brk)
+
+	devopsCli := devops.NewClient(devops.NewConfig(store), client)
+	notifierService := service.Notifier(db, pusher, dongCli, devopsCli, emailService)
 	notifierREST := mgtapi.Notifier(notifierService)
 	notifierREST.Route(anon, bearer, basic)
+	notifierBRK := brkapi.Notifier(notifierService)
+	notifierBRK.Route(brk)
+
+	alarmService := service.Alarm(db, notifierService, slog)
+	go alarmService.Run(ctx)
+	alarmREST := mgtapi.Alarm(alarmService)
+	alarmREST.Route(anon, bearer, basic)
 
 	minionTaskService := service.MinionTask()
 	minionTaskREST := mgtapi.MinionTask(minionTaskService)
 	minionTaskREST.Route(anon, bearer, basic)
 
 	minionLogonService := service.MinionLogon()
-	minionLogonREST := mgtapi.MinionLogon(minionLogonService)
+	minionLogonREST := mgtapi.MinionLogon(minionLogonService, aggregateService)
 	minionLogonREST.Route(anon, bearer, basic)
 
-	riskService := service.Risk()
-	riskREST := mgtapi.Risk(riskService)
+	riskCaseService := service.RiskCase(db, gfs)
+	go riskCaseService.Run(ctx)
+	riskCaseREST := mgtapi.RiskCase(riskCaseService)
+	riskCaseREST.Route(anon, bearer, basic)
+
+	incidentService := service.Incident(db, riskCaseService, slog)
+	go incidentService.Run(ctx)
+	incidentREST := mgtapi.Incident(incidentService)
+	incidentREST.Route(anon, bearer, basic)
+
+	attackService := service.Attack(db)
+	attackREST := mgtapi.Attack(attackService)
+	attackREST.Route(anon, bearer, basic)
+	riskService := service.Risk(riskCaseService, attackService)
+	riskREST := mgtapi.Risk(riskService, aggregateService)
 	riskREST.Route(anon, bearer, basic)
 
-	passDNSService := service.PassDNS()
+	passDNSService := service.PassDNS(db, pusher)
 	passDNSREST := mgtapi.PassDNS(passDNSService)
 	passDNSREST.Route(anon, bearer, basic)
-	passIPService := service.PassIP()
+	passIPService := service.PassIP(db, pusher)
 	passIPREST := mgtapi.PassIP(passIPService)
 	passIPREST.Route(anon, bearer, basic)
 	riskDNSService := service.RiskDNS()
@@ -204,7 +290,7 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	storeService := service.Store(pusher, store)
 
 	eventService := service.Event(store)
-	eventREST := mgtapi.Event(eventService)
+	eventREST := mgtapi.Event(eventService, aggregateService)
 	eventREST.Route(anon, bearer, basic)
 	storeREST := mgtapi.Store(storeService)
 	storeREST.Route(anon, bearer, basic)
@@ -223,11 +309,12 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	vipREST := mgtapi.VIP(vipService)
 	vipREST.Route(anon, bearer, basic)
 
-	cmdbService := service.Cmdb()
+	cmdbService := service.Cmdb(cmdbClient, tagRuleService)
 	cmdbREST := mgtapi.Cmdb(cmdbService)
 	cmdbREST.Route(anon, bearer, basic)
 
-	dashService := service.Dash()
+	dashService := service.Dash(db, slog)
+	go dashService.Run(ctx)
 	dashREST := mgtapi.Dash(dashService)
 	dashREST.Route(anon, bearer, basic)
 
@@ -243,8 +330,15 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	minionBinaryREST := mgtapi.MinionBinary(minionBinaryService)
 	minionBinaryREST.Route(anon, bearer, basic)
 
+	upgradeCampaignService := service.UpgradeCampaign(db, pusher)
+	if err = upgradeCampaignService.Reset(ctx); err != nil {
+		return nil, err
+	}
+	upgradeCampaignREST := mgtapi.UpgradeCampaign(upgradeCampaignService)
+	upgradeCampaignREST.Route(anon, bearer, basic)
+
 	minionListenService := service.MinionListen()
-	minionListenREST := mgtapi.MinionListen(minionListenService)
+	minionListenREST := mgtapi.MinionListen(minionListenService, aggregateService)
 	minionListenREST.Route(anon, bearer, basic)
 
 	minionAccountService := service.MinionAccount()
@@ -263,9 +357,25 @@ func newApp(ctx context.Context, cfg config.Config, slog logback.Logger) (*appli
 	riskIPREST := mgtapi.RiskIP(riskIPService)
 	riskIPREST.Route(anon, bearer, basic)
 
-	emailService := service.Email()
-	emailREST := mgtapi.Email(emailService)
-	emailREST.Route(anon, bearer, basic)
+	threatService := service.Threat(db, slog)
+	go threatService.Run(ctx)
+	threatREST := mgtapi.Threat(threatService)
+	threatREST.Route(anon, bearer, basic)
+
+	retentionService := service.Retention(db, gfs, slog)
+	go retentionService.Run(ctx)
+	retentionREST := mgtapi.Retention(retentionService)
+	retentionREST.Route(anon, bearer, basic)
+
+	webhookService := service.Webhook(db, slog)
+	go webhookService.Run(ctx)
+	webhookREST := mgtapi.Webhook(webhookService)
+	webhookREST.Route(anon, bearer, basic)
+
+	siemService := service.Siem(db, slog)
+	go siemService.Run(ctx)
+	siemREST := mgtapi.Siem(siemService)
+	siemREST.Route(anon, bearer, basic)
 
 	startupService := service.Startup(store, pusher)
 	startupREST := mgtapi.Startup(startupService)
@@ -6,3 +6,681 @@ alter table gridfs_chunk
 
 alter table minion
     add clam TINYINT(1) default 0 not null;
+
+create table upgrade_campaign
+(
+    id           bigint                         not null primary key,
+    name         varchar(50)                    not null comment '活动名称',
+    binary_id    bigint                         not null comment '目标发行版 ID',
+    goos         varchar(10)                    not null comment '目标操作系统',
+    arch         varchar(10)                    not null comment '目标系统架构',
+    semver       varchar(50)                    not null comment '目标版本',
+    filters      json                           null comment '选择节点的 dynsql 条件',
+    keyword      varchar(100) default ''        not null comment '选择节点的关键字',
+    batch_size   int                            not null comment '每批次最多升级的节点数',
+    broker_limit int                            not null comment '每批次每个 broker 最多升级的节点数',
+    timeout      bigint                         not null comment '单批次等待版本变化的超时时间',
+    status       tinyint                        not null comment '活动状态',
+    total        int          default 0         not null comment '节点总数',
+    succeed      int          default 0         not null comment '升级成功数',
+    failed       int          default 0         not null comment '升级失败数',
+    timedout     int          default 0         not null comment '升级超时数',
+    created_id   bigint                         not null comment '创建者 ID',
+    finished_at  datetime(3)                    null comment '结束时间',
+    created_at   datetime(3)                    not null comment '创建时间',
+    updated_at   datetime(3)                    not null comment '更新时间'
+) comment '节点批量升级活动';
+
+create table upgrade_campaign_item
+(
+    id           bigint                         not null primary key,
+    campaign_id  bigint                         not null comment '活动 ID',
+    minion_id    bigint                         not null comment '节点 ID',
+    inet         varchar(50)                    not null comment '节点 IP',
+    broker_id    bigint                         not null comment 'broker ID',
+    broker_name  varchar(50)  default ''        not null comment 'broker 名字',
+    from_edition varchar(50)  default ''        not null comment '升级前的版本',
+    to_edition   varchar(50)  default ''        not null comment '升级后观察到的版本',
+    status       tinyint                        not null comment '升级状态',
+    reason       varchar(255) default ''        not null comment '失败原因',
+    pushed_at    datetime(3)                    null comment '下发升级指令时间',
+    finished_at  datetime(3)                    null comment '结束时间',
+    created_at   datetime(3)                    not null comment '创建时间'
+) comment '升级活动节点记录';
+
+create index upgrade_campaign_item_campaign_id_status_index
+    on upgrade_campaign_item (campaign_id, status, broker_id);
+
+create table minion_offline
+(
+    id          bigint                         not null primary key,
+    minion_id   bigint                         not null comment '节点 ID',
+    inet        varchar(50)                    not null comment '节点 IP',
+    broker_id   bigint                         not null comment 'broker ID',
+    broker_name varchar(50)  default ''        not null comment 'broker 名字',
+    status      tinyint                        not null comment '通知状态',
+    retries     int          default 0         not null comment '已重试次数',
+    reason      varchar(255) default ''        not null comment '失败原因',
+    notified_at datetime(3)                    null comment '通知成功时间',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间',
+    constraint minion_offline_minion_id_uindex
+        unique (minion_id)
+) comment '节点下线通知记录';
+
+create table minion_purge
+(
+    id          bigint                         not null primary key,
+    total       int                            not null comment '清理的节点数',
+    status      tinyint                        not null comment '任务状态',
+    removed     bigint       default 0         not null comment '累计删除的数据行数',
+    failed      int          default 0         not null comment '清理失败的数据表数',
+    created_id  bigint                         not null comment '创建者 ID',
+    finished_at datetime(3)                    null comment '结束时间',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间'
+) comment '节点物理删除清理任务';
+
+create table minion_purge_step
+(
+    id         bigint                         not null primary key,
+    purge_id   bigint                         not null comment '任务 ID',
+    name       varchar(50)                    not null comment '数据表名',
+    removed    bigint       default 0         not null comment '删除的数据行数',
+    failed     tinyint(1)   default 0         not null comment '是否清理失败',
+    reason     varchar(255) default ''        not null comment '失败原因',
+    elapsed    bigint       default 0         not null comment '耗时（毫秒）',
+    created_at datetime(3)                    not null comment '创建时间'
+) comment '节点物理删除清理任务的数据表清理结果';
+
+create index minion_purge_step_purge_id_index
+    on minion_purge_step (purge_id);
+
+create table minion_import
+(
+    id          bigint                         not null primary key,
+    filename    varchar(255)                   not null comment '上传的文件名',
+    status      tinyint                        not null comment '任务状态',
+    total       int          default 0         not null comment '数据总行数',
+    succeed     int          default 0         not null comment '导入成功数',
+    failed      int          default 0         not null comment '导入失败数',
+    created_id  bigint                         not null comment '创建者 ID',
+    finished_at datetime(3)                    null comment '结束时间',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间'
+) comment '批量导入节点任务';
+
+create table minion_import_item
+(
+    id         bigint                         not null primary key,
+    import_id  bigint                         not null comment '任务 ID',
+    `row`      int                            not null comment '文件中的行号',
+    inet       varchar(50)  default ''        not null comment '节点 IP',
+    minion_id  bigint       default 0         not null comment '导入成功后的节点 ID',
+    succeed    tinyint(1)   default 0         not null comment '是否导入成功',
+    reason     varchar(512) default ''        not null comment '失败原因',
+    created_at datetime(3)                    not null comment '创建时间'
+) comment '批量导入节点任务的行结果';
+
+create index minion_import_item_import_id_index
+    on minion_import_item (import_id);
+
+create table tag_rule
+(
+    id         bigint                         not null primary key,
+    name       varchar(50)                    not null comment '规则名称',
+    tag        varchar(50)                    not null comment '满足条件时打上的标签',
+    conditions json                           not null comment '匹配条件',
+    enabled    tinyint(1)   default 0         not null comment '是否启用',
+    comment    varchar(255) default ''        not null comment '说明',
+    created_id bigint                         not null comment '创建者 ID',
+    created_at datetime(3)                    not null comment '创建时间',
+    updated_at datetime(3)                    not null comment '更新时间'
+) comment '自动标签规则';
+
+create table command_exec
+(
+    id          bigint                         not null primary key,
+    cmd         varchar(255)                   not null comment '指令',
+    filters     json                           null comment '选择节点的 dynsql 条件',
+    keyword     varchar(100) default ''        not null comment '选择节点的关键字',
+    status      tinyint                        not null comment '执行状态',
+    total       int          default 0         not null comment '节点总数',
+    succeed     int          default 0         not null comment '执行成功数',
+    failed      int          default 0         not null comment '执行失败数',
+    created_id  bigint                         not null comment '执行者 ID',
+    finished_at datetime(3)                    null comment '结束时间',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间'
+) comment '远程指令执行记录';
+
+create table command_exec_item
+(
+    id          bigint                         not null primary key,
+    exec_id     bigint                         not null comment '执行 ID',
+    minion_id   bigint                         not null comment '节点 ID',
+    inet        varchar(50)  default ''        not null comment '节点 IP',
+    broker_id   bigint       default 0         not null comment 'broker ID',
+    broker_name varchar(50)  default ''        not null comment 'broker 名字',
+    status      tinyint                        not null comment '执行状态',
+    output      mediumtext                     null comment '节点响应的输出',
+    digest      char(40)     default ''        not null comment '输出的 SHA-1',
+    reason      varchar(512) default ''        not null comment '失败原因',
+    finished_at datetime(3)                    null comment '结束时间',
+    created_at  datetime(3)                    not null comment '创建时间'
+) comment '远程指令在每个节点上的执行结果';
+
+create index command_exec_item_exec_id_digest_index
+    on command_exec_item (exec_id, digest);
+
+create table session_record
+(
+    id         bigint                         not null primary key,
+    kind       varchar(10)                    not null comment '通道类型：aws bws',
+    user_id    bigint                         not null comment '用户 ID',
+    username   varchar(50)  default ''        not null comment '用户名',
+    minion_id  bigint       default 0         not null comment '节点 ID',
+    broker_id  bigint       default 0         not null comment 'broker ID',
+    inet       varchar(50)  default ''        not null comment '节点 IP',
+    path       varchar(255) default ''        not null comment '请求路径',
+    file_id    bigint                         not null comment 'gridfs 文件 ID',
+    size       bigint       default 0         not null comment '压缩后的录像大小',
+    frames     int          default 0         not null comment '录制的帧数',
+    input      bigint       default 0         not null comment '控制台发送给节点的字节数',
+    output     bigint       default 0         not null comment '节点发送给控制台的字节数',
+    truncated  tinyint(1)   default 0         not null comment '是否超过大小限制被截断',
+    started_at datetime(3)                    not null comment '会话开始时间',
+    ended_at   datetime(3)                    not null comment '会话结束时间',
+    created_at datetime(3)                    not null comment '创建时间'
+) comment 'websocket 通道会话录像';
+
+create index session_record_created_at_index
+    on session_record (created_at);
+
+create table proxy_policy
+(
+    id         bigint                         not null primary key,
+    name       varchar(50)                    not null comment '策略名称',
+    user_id    bigint       default 0         not null comment '适用的用户，0 代表所有用户',
+    allow      tinyint(1)   default 0         not null comment '1-允许 0-拒绝',
+    kinds      json                           not null comment '通道类型',
+    tags       json                           not null comment '节点标签',
+    idcs       json                           not null comment '节点 IDC',
+    broker_ids json                           not null comment 'broker ID',
+    paths      json                           not null comment '请求路径',
+    methods    json                           not null comment '请求方法',
+    enabled    tinyint(1)   default 0         not null comment '是否启用',
+    expired_at datetime(3)                    null comment '过期时间，为空代表永久有效',
+    comment    varchar(255) default ''        not null comment '说明',
+    created_id bigint                         not null comment '创建者 ID',
+    created_at datetime(3)                    not null comment '创建时间',
+    updated_at datetime(3)                    not null comment '更新时间'
+) comment '节点代理访问策略';
+
+create table proxy_denial
+(
+    id         bigint                         not null primary key,
+    user_id    bigint                         not null comment '用户 ID',
+    kind       varchar(10)                    not null comment '通道类型',
+    minion_id  bigint       default 0         not null comment '节点 ID',
+    broker_id  bigint       default 0         not null comment 'broker ID',
+    inet       varchar(50)  default ''        not null comment '节点 IP',
+    method     varchar(20)  default ''        not null comment '请求方法',
+    path       varchar(255) default ''        not null comment '请求路径',
+    policy_id  bigint       default 0         not null comment '命中的拒绝策略，0 代表没有允许的策略',
+    client_ip  varchar(50)  default ''        not null comment '客户端 IP',
+    created_at datetime(3)                    not null comment '请求时间'
+) comment '被访问策略拒绝的节点代理请求';
+
+create index proxy_denial_created_at_index
+    on proxy_denial (created_at);
+
+create table proxy_audit
+(
+    id          bigint                         not null primary key,
+    kind        varchar(10)                    not null comment '通道类型：brr arr',
+    user_id     bigint                         not null comment '用户 ID',
+    minion_id   bigint       default 0         not null comment '节点 ID',
+    broker_id   bigint       default 0         not null comment 'broker ID',
+    inet        varchar(50)  default ''        not null comment '节点 IP',
+    method      varchar(20)  default ''        not null comment '请求方法',
+    path        varchar(1024) default ''       not null comment '请求路径',
+    query       varchar(2048) default ''       not null comment '请求参数',
+    client_ip   varchar(50)  default ''        not null comment '客户端 IP',
+    status      int          default 0         not null comment '上游响应状态码',
+    req_size    bigint       default 0         not null comment '请求报文大小',
+    resp_size   bigint       default 0         not null comment '响应报文大小',
+    latency     bigint       default 0         not null comment '耗时（毫秒）',
+    resp_sample text                           null comment '响应报文采样',
+    created_at  datetime(3)                    not null comment '请求时间'
+) comment '节点代理请求审计';
+
+create index proxy_audit_created_at_index
+    on proxy_audit (created_at);
+
+create table alert_rule
+(
+    id           bigint                         not null primary key,
+    name         varchar(50)                    not null comment '规则名称',
+    source       varchar(20)                    not null comment '数据源：event risk logon minion',
+    filters      json                           not null comment '过滤条件',
+    cond         varchar(20)                    not null comment '条件类型：threshold rate absence',
+    threshold    int          default 0         not null comment '条数阈值',
+    ratio        int          default 0         not null comment 'rate 条件的增长百分比',
+    period       int          default 300       not null comment '时间窗口（秒）',
+    group_by     json                           not null comment '分组字段',
+    dedupe       int          default 0         not null comment '去重窗口（秒），0 代表与时间窗口一致',
+    severity     tinyint      default 1         not null comment '默认告警级别',
+    severity_map json                           not null comment '数据源级别到告警级别的映射',
+    notifier_ids json                           not null comment '通知人',
+    enabled      tinyint(1)   default 0         not null comment '是否启用',
+    comment      varchar(255) default ''        not null comment '说明',
+    created_id   bigint                         not null comment '创建者 ID',
+    created_at   datetime(3)                    not null comment '创建时间',
+    updated_at   datetime(3)                    not null comment '更新时间'
+) comment '告警规则';
+
+create table alert
+(
+    id          bigint                         not null primary key,
+    rule_id     bigint                         not null comment '规则 ID',
+    rule_name   varchar(50)                    not null comment '规则名称',
+    source      varchar(20)                    not null comment '数据源',
+    group_key   varchar(1024) default ''       not null comment '分组键',
+    labels      json                           null comment '分组字段的值',
+    severity    tinyint      default 1         not null comment '告警级别',
+    status      tinyint      default 1         not null comment '1-告警中 2-已确认 3-已解决',
+    summary     varchar(2048) default ''       not null comment '告警摘要',
+    count       int          default 1         not null comment '去重窗口内的触发次数',
+    notified    tinyint(1)   default 0         not null comment '是否通知成功',
+    notify_err  varchar(1024) default ''       not null comment '通知失败的原因',
+    first_at    datetime(3)                    not null comment '首次触发时间',
+    last_at     datetime(3)                    not null comment '最近一次触发时间',
+    acked_id    bigint       default 0         not null comment '确认人',
+    acked_at    datetime(3)                    null comment '确认时间',
+    resolved_id bigint       default 0         not null comment '解决人',
+    resolved_at datetime(3)                    null comment '解决时间',
+    remark      varchar(255) default ''        not null comment '处理备注',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间'
+) comment '告警';
+
+create index alert_rule_id_group_key_index
+    on alert (rule_id, group_key(255));
+
+create table risk_case
+(
+    id          bigint                         not null primary key,
+    risk_id     bigint                         not null comment '风险事件 ID',
+    assignee_id bigint                         not null comment '处理人',
+    assignee    varchar(50)  default ''        not null comment '处理人用户名',
+    assigner_id bigint                         not null comment '分派人',
+    due_at      datetime(3)                    null comment '处理期限',
+    breached    tinyint(1)   default 0         not null comment '是否超过处理期限',
+    created_at  datetime(3)                    not null comment '创建时间',
+    updated_at  datetime(3)                    not null comment '更新时间',
+    constraint risk_case_risk_id_uindex
+        unique (risk_id)
+) comment '风险事件处置工单';
+
+create index risk_case_assignee_id_index
+    on risk_case (assignee_id);
+
+create table risk_comment
+(
+    id         bigint                         not null primary key,
+    risk_id    bigint                         not null comment '风险事件 ID',
+    user_id    bigint                         not null comment '评论人',
+    username   varchar(50)  default ''        not null comment '评论人用户名',
+    content    text                           not null comment '评论内容',
+    file_id    bigint       default 0         not null comment '附件在 gridfs 中的 ID',
+    file_name  varchar(255) default ''        not null comment '附件名称',
+    file_size  bigint       default 0         not null comment '附件大小',
+    created_at datetime(3)                    not null comment '评论时间'
+) comment '风险事件评论';
+
+create index risk_comment_risk_id_index
+    on risk_comment (risk_id);
+
+create table risk_transition
+(
+    id          bigint                         not null primary key,
+    risk_id     bigint                         not null comment '风险事件 ID',
+    user_id     bigint                         not null comment '操作人',
+    username    varchar(50)  default ''        not null comment '操作人用户名',
+    from_status tinyint                        not null comment '变更前的状态',
+    to_status   tinyint                        not null comment '变更后的状态',
+    reason      varchar(255) default ''        not null comment '变更原因',
+    created_at  datetime(3)                    not null comment '变更时间'
+) comment '风险事件状态变更记录';
+
+create index risk_transition_risk_id_index
+    on risk_transition (risk_id);
+
+create table incident
+(
+    id         bigint                         not null primary key,
+    title      varchar(255)                   not null comment '事件标题',
+    corr_key   varchar(1024) default ''       not null comment '关联键，手动拆分的事件为空',
+    level      varchar(10)  default '低危'      not null comment '成员风险的最高级别',
+    status     tinyint      default 1         not null comment '1-待处理 2-调查中 3-已关闭',
+    risk_count int          default 0         not null comment '成员风险数量',
+    first_at   datetime(3)                    not null comment '最早的风险发生时间',
+    last_at    datetime(3)                    not null comment '最近的风险发生时间',
+    closed_id  bigint       default 0         not null comment '关闭人',
+    closed_at  datetime(3)                    null comment '关闭时间',
+    created_at datetime(3)                    not null comment '创建时间',
+    updated_at datetime(3)                    not null comment '更新时间'
+) comment '安全事件';
+
+create index incident_corr_key_index
+    on incident (corr_key(255));
+
+create table incident_risk
+(
+    id          bigint      not null primary key,
+    incident_id bigint      not null comment '事件 ID',
+    risk_id     bigint      not null comment '风险 ID',
+    created_at  datetime(3) not null comment '关联时间',
+    constraint incident_risk_risk_id_uindex
+        unique (risk_id)
+) comment '安全事件与风险的关联';
+
+create index incident_risk_incident_id_index
+    on incident_risk (incident_id);
+
+create table incident_timeline
+(
+    id          bigint                         not null primary key,
+    incident_id bigint                         not null comment '事件 ID',
+    user_id     bigint       default 0         not null comment '操作人，0 代表系统自动关联',
+    action      varchar(20)                    not null comment '动作：create merge split status',
+    content     varchar(2048) default ''       not null comment '内容',
+    created_at  datetime(3)                    not null comment '时间'
+) comment '安全事件时间线';
+
+create index incident_timeline_incident_id_index
+    on incident_timeline (incident_id);
+
+create table threat_indicator
+(
+    id          bigint                         not null primary key,
+    type        varchar(10)                    not null comment '类型：ip cidr domain url md5 sha1 sha256',
+    value       varchar(512)                   not null comment '值',
+    kind        varchar(100) default ''        not null comment '威胁分类',
+    source      varchar(50)  default ''        not null comment '情报来源',
+    confidence  int          default 0         not null comment '置信度 0-100',
+    description varchar(1024) default ''       not null comment '描述',
+    expired_at  datetime(3)                    null comment '过期时间，为空代表永久有效',
+    created_at  datetime(3)                    not null comment '首次导入时间',
+    updated_at  datetime(3)                    not null comment '最近导入时间',
+    constraint threat_indicator_type_value_uindex
+        unique (type, value)
+) comment '威胁情报指标';
+
+create table threat_import
+(
+    id         bigint                         not null primary key,
+    source     varchar(50)  default ''        not null comment '情报来源',
+    filename   varchar(255) default ''        not null comment '文件名',
+    format     varchar(10)                    not null comment '文件格式：csv txt stix',
+    digest     char(40)                       not null comment '文件 SHA-1',
+    total      int          default 0         not null comment '有效指标数',
+    invalid    int          default 0         not null comment '无法识别的条目数',
+    error      varchar(1024) default ''       not null comment '导入失败的原因',
+    created_id bigint       default 0         not null comment '导入者 ID，0 代表从目录自动导入',
+    created_at datetime(3)                    not null comment '导入时间'
+) comment '威胁情报导入记录';
+
+create index threat_import_digest_index
+    on threat_import (digest);
+
+create table threat_match
+(
+    id           bigint                         not null primary key,
+    indicator_id bigint                         not null comment '命中的指标',
+    type         varchar(10)                    not null comment '指标类型',
+    value        varchar(512)                   not null comment '指标值',
+    target       varchar(20)                    not null comment '命中的数据：risk pass_ip pass_dns',
+    target_id    bigint                         not null comment '命中数据的 ID',
+    matched      varchar(255) default ''        not null comment '命中数据的值',
+    acked        tinyint(1)   default 0         not null comment '是否已查看',
+    created_at   datetime(3)                    not null comment '命中时间',
+    constraint threat_match_indicator_target_uindex
+        unique (indicator_id, target, target_id)
+) comment '威胁情报命中记录';
+
+create table retention_policy
+(
+    id         bigint                  not null primary key,
+    table_name varchar(50)             not null comment '数据表',
+    max_days   int        default 0    not null comment '保留天数，0 代表不按照时间清理',
+    max_rows   bigint     default 0    not null comment '保留行数，0 代表不按照行数清理',
+    archive    tinyint(1) default 0    not null comment '清理前是否归档',
+    enabled    tinyint(1) default 0    not null comment '是否启用',
+    updated_id bigint     default 0    not null comment '修改者 ID',
+    created_at datetime(3)             not null comment '创建时间',
+    updated_at datetime(3)             not null comment '更新时间',
+    constraint retention_policy_table_name_uindex
+        unique (table_name)
+) comment '数据保留策略';
+
+create table retention_run
+(
+    id         bigint                         not null primary key,
+    table_name varchar(50)                    not null comment '数据表',
+    deleted    bigint        default 0        not null comment '删除的行数',
+    archived   bigint        default 0        not null comment '归档的行数',
+    file_id    bigint        default 0        not null comment '归档文件 ID',
+    file_size  bigint        default 0        not null comment '归档文件大小',
+    cutoff     datetime(3)                    null comment '按照时间清理的截止时间',
+    error      varchar(1024) default ''       not null comment '清理出错的原因',
+    manual     tinyint(1)    default 0        not null comment '是否手动触发',
+    started_at datetime(3)                    not null comment '开始时间',
+    ended_at   datetime(3)                    not null comment '结束时间'
+) comment '数据清理记录';
+
+create index retention_run_table_name_index
+    on retention_run (table_name);
+
+create table webhook
+(
+    id          bigint                        not null primary key,
+    name        varchar(50)                   not null comment '名称',
+    url         varchar(255)                  not null comment '推送地址',
+    secret      varchar(100)  default ''      not null comment '签名密钥',
+    topics      json                          not null comment '订阅的事件主题',
+    template    text                          null comment 'Go 模板，为空时推送默认的 JSON 格式',
+    max_retries int           default 0       not null comment '最大重试次数',
+    enabled     tinyint(1)    default 0       not null comment '是否启用',
+    created_id  bigint        default 0       not null comment '创建者 ID',
+    created_at  datetime(3)                   not null comment '创建时间',
+    updated_at  datetime(3)                   not null comment '更新时间'
+) comment 'webhook 订阅';
+
+create table webhook_delivery
+(
+    id          bigint                        not null primary key,
+    webhook_id  bigint                        not null comment '订阅 ID',
+    topic       varchar(20)                   not null comment '事件主题',
+    payload     mediumtext                    null comment '推送内容',
+    status      tinyint       default 1       not null comment '推送状态：1-等待推送 2-推送成功 3-推送失败',
+    attempts    int           default 0       not null comment '已推送次数',
+    status_code int           default 0       not null comment '最近一次推送的响应码',
+    response    varchar(1024) default ''      not null comment '最近一次推送的响应内容',
+    error       varchar(1024) default ''      not null comment '最近一次推送失败的原因',
+    next_at     datetime(3)                   null comment '下次推送时间',
+    done_at     datetime(3)                   null comment '推送结束时间',
+    created_at  datetime(3)                   not null comment '创建时间',
+    updated_at  datetime(3)                   not null comment '更新时间'
+) comment 'webhook 推送记录';
+
+create index webhook_delivery_webhook_id_index
+    on webhook_delivery (webhook_id);
+
+create index webhook_delivery_status_next_at_index
+    on webhook_delivery (status, next_at);
+
+create table siem_forwarder
+(
+    id           bigint                        not null primary key,
+    name         varchar(50)                   not null comment '名称',
+    network      varchar(10)                   not null comment '传输协议：udp tcp tls',
+    address      varchar(255)                  not null comment '接收端地址 host:port',
+    insecure     tinyint(1)    default 0       not null comment 'TLS 是否跳过证书校验',
+    format       varchar(10)                   not null comment '消息格式：cef leef json',
+    sources      json                          not null comment '转发的数据：risk event oplog',
+    facility     int           default 0       not null comment 'syslog facility，0 代表 local0',
+    enabled      tinyint(1)    default 0       not null comment '是否启用',
+    last_error   varchar(1024) default ''      not null comment '最近一次转发出错的原因',
+    last_sent_at datetime(3)                   null comment '最近一次转发成功的时间',
+    created_id   bigint        default 0       not null comment '创建者 ID',
+    created_at   datetime(3)                   not null comment '创建时间',
+    updated_at   datetime(3)                   not null comment '更新时间'
+) comment 'SIEM 转发器';
+
+create table siem_cursor
+(
+    id           bigint                  not null primary key,
+    forwarder_id bigint                  not null comment '转发器 ID',
+    source       varchar(20)             not null comment '数据源',
+    floor_id     bigint     default 0    not null comment '创建游标时的最大数据 ID',
+    last_id      bigint     default 0    not null comment '已转发的最大数据 ID',
+    sent         bigint     default 0    not null comment '累计转发条数',
+    updated_at   datetime(3)             not null comment '更新时间',
+    constraint siem_cursor_forwarder_id_source_uindex
+        unique (forwarder_id, source)
+) comment 'SIEM 转发游标';
+
+alter table email
+    add port int default 0 not null comment '端口，0 代表兼容 host:port 格式' after host,
+    add tls_mode varchar(10) default '' not null comment '加密方式：none starttls tls' after port,
+    add sender varchar(100) default '' not null comment '发件人地址' after password;
+
+create table notifier_delivery
+(
+    id          bigint                        not null primary key,
+    notifier_id bigint                        not null comment '通知人 ID',
+    channel     varchar(10)                   not null comment '通知方式：dong email wechat sms call',
+    source      varchar(10)                   not null comment '通知来源：event risk alert',
+    subject     varchar(255)  default ''      not null comment '通知标题',
+    status      tinyint                       not null comment '发送状态：1-成功 2-失败',
+    error       varchar(1024) default ''      not null comment '发送失败的原因',
+    performer   varchar(10)                   not null comment '执行方：manager broker，broker 发送通知后通过连接通道上报',
+    broker_id   bigint        default 0       not null comment '执行通知的 broker 节点 ID',
+    test        tinyint(1)    default 0       not null comment '是否是测试通知',
+    created_at  datetime(3)                   not null comment '发送时间'
+) comment '告警通知记录';
+
+create index notifier_delivery_notifier_id_index
+    on notifier_delivery (notifier_id);
+
+alter table pass_ip
+    add reason varchar(255) default '' not null comment '加白原因' after kind,
+    add created_id bigint default 0 not null comment '创建者 ID' after before_at,
+    add updated_id bigint default 0 not null comment '修改者 ID' after created_id;
+
+alter table pass_dns
+    add reason varchar(255) default '' not null comment '加白原因' after kind,
+    add created_id bigint default 0 not null comment '创建者 ID' after before_at,
+    add updated_id bigint default 0 not null comment '修改者 ID' after created_id;
+
+create table pass_changelog
+(
+    id         bigint                       not null primary key,
+    target     varchar(10)                  not null comment '白名单类型：ip dns',
+    rule_id    bigint                       not null comment '白名单 ID',
+    value      varchar(255)                 not null comment 'IP 或域名',
+    kind       varchar(20)   default ''     not null comment '数据维度',
+    action     varchar(10)                  not null comment '变更类型：create update delete expire import',
+    detail     varchar(1024) default ''     not null comment '变更内容',
+    user_id    bigint        default 0      not null comment '操作人 ID',
+    username   varchar(50)   default ''     not null comment '操作人',
+    created_at datetime(3)                  not null comment '变更时间'
+) comment '白名单变更记录';
+
+create index pass_changelog_target_rule_id_index
+    on pass_changelog (target, rule_id);
+
+create table attack_tactic
+(
+    id          bigint                    not null primary key,
+    tactic_id   varchar(20)               not null comment '战术编号，如 TA0006',
+    name        varchar(100)              not null comment '战术名称',
+    short_name  varchar(100) default ''   not null comment '短名称，如 credential-access',
+    description text                      null comment '描述',
+    url         varchar(255) default ''   not null comment '官方链接',
+    created_at  datetime(3)               not null comment '创建时间',
+    updated_at  datetime(3)               not null comment '更新时间',
+    constraint attack_tactic_tactic_id_uindex
+        unique (tactic_id)
+) comment 'MITRE ATT&CK 战术';
+
+create table attack_technique
+(
+    id           bigint                    not null primary key,
+    technique_id varchar(20)               not null comment '技术编号，如 T1110.001',
+    name         varchar(255)              not null comment '技术名称',
+    tactics      json                      not null comment '所属战术的短名称',
+    platforms    json                      not null comment '适用平台',
+    description  text                      null comment '描述',
+    url          varchar(255) default ''   not null comment '官方链接',
+    subtechnique tinyint(1)   default 0    not null comment '是否是子技术',
+    deprecated   tinyint(1)   default 0    not null comment '是否已废弃或撤销',
+    created_at   datetime(3)               not null comment '创建时间',
+    updated_at   datetime(3)               not null comment '更新时间',
+    constraint attack_technique_technique_id_uindex
+        unique (technique_id)
+) comment 'MITRE ATT&CK 技术';
+
+create table attack_mapping
+(
+    id         bigint                    not null primary key,
+    field      varchar(20)               not null comment '匹配字段：risk_type from_code',
+    value      varchar(100)              not null comment '字段的值',
+    techniques json                      not null comment 'ATT&CK 技术编号',
+    remark     varchar(255) default ''   not null comment '备注',
+    created_id bigint       default 0    not null comment '创建者 ID',
+    updated_id bigint       default 0    not null comment '修改者 ID',
+    created_at datetime(3)               not null comment '创建时间',
+    updated_at datetime(3)               not null comment '更新时间',
+    constraint attack_mapping_field_value_uindex
+        unique (field, value)
+) comment '风险与 ATT&CK 技术的映射';
+
+create table dash_rollup
+(
+    id         bigint                    not null primary key,
+    metric     varchar(10)               not null comment '统计指标：risk event logon online',
+    bucket     datetime                  not null comment '统计的小时',
+    dimension  varchar(20)  default ''   not null comment '细分维度：broker idc ibu risk_type，空代表总数',
+    name       varchar(100) default ''   not null comment '维度的值',
+    count      bigint       default 0    not null comment '数量',
+    updated_at datetime(3)               not null comment '更新时间',
+    constraint dash_rollup_metric_bucket_dimension_name_uindex
+        unique (metric, bucket, dimension, name)
+) comment '首页趋势统计预聚合数据';
+
+create table webhook_cursor
+(
+    id         bigint                  not null primary key,
+    topic      varchar(20)             not null comment '事件主题',
+    floor_id   bigint     default 0    not null comment '重新开始收集时的数据 ID',
+    last_id    bigint     default 0    not null comment '已收集的最大数据 ID',
+    updated_at datetime(3)             not null comment '更新时间',
+    constraint webhook_cursor_topic_uindex
+        unique (topic)
+) comment 'webhook 收集游标';
+
+create table cursor_seen
+(
+    id         bigint                  not null primary key,
+    cursor_key varchar(50)             not null comment '游标',
+    record_id  bigint                  not null comment '已处理的数据 ID',
+    created_at datetime(3)             not null comment '处理时间',
+    constraint cursor_seen_cursor_key_record_id_uindex
+        unique (cursor_key, record_id)
+) comment '游标回扫窗口内已处理的数据';
+
+create index cursor_seen_created_at_index
+    on cursor_seen (created_at);